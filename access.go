@@ -0,0 +1,116 @@
+package mux
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// RequestInfo describes a request served by a mux, for access logging.
+type RequestInfo struct {
+	Method string
+	Path   string
+
+	// Pattern is the pattern the request was routed to, as returned by
+	// MatchedPattern, or "" if it was not routed to a pattern, such as when
+	// it was redirected or handled by notFound.
+	Pattern string
+
+	// RequestID is the ID of the request, as returned by RequestID.
+	RequestID string
+
+	// ClientIP is the IP address of the client, as returned by ClientIP.
+	ClientIP string
+
+	Status   int   // status code of the response
+	Bytes    int64 // number of body bytes written
+	Duration time.Duration
+}
+
+// OnRequest returns an Option that makes mux call hook after serving each
+// request, including those answered by notFound, a redirect or middleware
+// added with Use, except for requests routed to Quiet routes. hook is called
+// by the goroutine serving the request, so it should not block.
+func OnRequest(hook func(info RequestInfo)) Option {
+	return func(mux *Mux) {
+		mux.onRequest = hook
+	}
+}
+
+// requestLogKey is the context key for the requestLog of a request.
+type requestLogKey struct{}
+
+// requestLog records how a mux with an OnRequest hook, Metrics or stats
+// served a request.
+type requestLog struct {
+	ResponseWriter
+	t       *table            // table of the mux serving the request
+	end     func(RequestInfo) // ends the span of the request if traced
+	method  string
+	start   time.Time
+	routed  bool // whether routed to a pattern
+	pattern string
+	quiet   bool
+}
+
+// newRequestLog returns a requestLog for r recording what is written to w and
+// r with the requestLog in its context.
+func newRequestLog(w http.ResponseWriter, r *http.Request, t *table) (*requestLog, *http.Request) {
+	l := &requestLog{ResponseWriter: WrapResponseWriter(w), t: t, method: r.Method, start: time.Now()}
+	return l, r.WithContext(context.WithValue(r.Context(), requestLogKey{}, l))
+}
+
+// recordRoute records that r was routed to pattern, if r is logged.
+func recordRoute(r *http.Request, pattern string, quiet bool) {
+	l, ok := r.Context().Value(requestLogKey{}).(*requestLog)
+	if !ok {
+		return
+	}
+	if metrics := l.t.metrics; metrics != nil {
+		// A mux the request is passed on to routes it again.
+		if l.routed && !l.quiet {
+			metrics.inFlight(l.method, l.pattern, -1)
+		}
+		if !quiet {
+			metrics.inFlight(l.method, pattern, 1)
+		}
+	}
+	l.routed, l.pattern, l.quiet = true, pattern, quiet
+}
+
+// done ends the span of the request, if traced, and calls the OnRequest hook
+// and records the metrics and stats of the request unless it was routed to a
+// Quiet route.
+func (l *requestLog) done(r *http.Request) {
+	info := RequestInfo{
+		Method:    r.Method,
+		Path:      r.URL.Path,
+		Pattern:   l.pattern,
+		RequestID: RequestID(r),
+		ClientIP:  ClientIP(r),
+		Status:    l.Status(),
+		Bytes:     l.BytesWritten(),
+		Duration:  time.Since(l.start),
+	}
+	if info.Status == 0 {
+		info.Status = http.StatusOK
+	}
+	if l.end != nil {
+		l.end(info)
+	}
+	if l.quiet {
+		return
+	}
+	if metrics := l.t.metrics; metrics != nil {
+		if l.routed {
+			metrics.inFlight(l.method, l.pattern, -1)
+		}
+		metrics.observe(info)
+	}
+	if l.t.stats != nil && l.routed {
+		l.t.stats.observe(info)
+	}
+	if l.t.onRequest != nil {
+		l.t.onRequest(info)
+	}
+}
@@ -0,0 +1,67 @@
+package mux_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestOnRequest(t *testing.T) {
+	var infos []mux.RequestInfo
+	m := mux.New(mux.OnRequest(func(info mux.RequestInfo) {
+		infos = append(infos, info)
+	}), mux.WithRecovery(nil))
+	m.HandleFunc("/users/{id}", handlerFactory(http.StatusTeapot, "user"))
+	m.HandleFunc("/health", handlerFactory(http.StatusOK, "ok"), mux.Quiet())
+	m.HandleFunc("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	m.HandleFunc("/empty", func(w http.ResponseWriter, r *http.Request) {})
+
+	sub := mux.New()
+	sub.HandleFunc("/posts/{post}", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "post")
+	})
+	m.MountLive("/blog", sub)
+
+	cases := []struct {
+		method string
+		path   string
+		want   *mux.RequestInfo
+	}{
+		{http.MethodGet, "/users/1", &mux.RequestInfo{ClientIP: "192.0.2.1", Method: "GET", Path: "/users/1", Pattern: "/users/{id}", Status: http.StatusTeapot, Bytes: 4}},
+		{http.MethodPost, "/users/1/", &mux.RequestInfo{ClientIP: "192.0.2.1", Method: "POST", Path: "/users/1/", Status: http.StatusPermanentRedirect}},
+		{http.MethodGet, "/missing", &mux.RequestInfo{ClientIP: "192.0.2.1", Method: "GET", Path: "/missing", Status: http.StatusNotFound, Bytes: 19}},
+		{http.MethodGet, "/health", nil},
+		{http.MethodGet, "/panic", &mux.RequestInfo{ClientIP: "192.0.2.1", Method: "GET", Path: "/panic", Pattern: "/panic", Status: http.StatusInternalServerError, Bytes: 22}},
+		{http.MethodGet, "/empty", &mux.RequestInfo{ClientIP: "192.0.2.1", Method: "GET", Path: "/empty", Pattern: "/empty", Status: http.StatusOK}},
+		{http.MethodGet, "/blog/posts/1", &mux.RequestInfo{ClientIP: "192.0.2.1", Method: "GET", Path: "/blog/posts/1", Pattern: "/posts/{post}", Status: http.StatusOK, Bytes: 4}},
+	}
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			infos = nil
+			m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(c.method, c.path, nil))
+
+			if c.want == nil {
+				if len(infos) != 0 {
+					t.Errorf("got %d calls, want none", len(infos))
+				}
+				return
+			}
+			if len(infos) != 1 {
+				t.Fatalf("got %d calls, want 1", len(infos))
+			}
+			got := infos[0]
+			if got.Duration <= 0 {
+				t.Errorf("got Duration %v, want positive", got.Duration)
+			}
+			got.Duration = 0
+			if got != *c.want {
+				t.Errorf("got %+v, want %+v", got, *c.want)
+			}
+		})
+	}
+}
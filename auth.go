@@ -0,0 +1,152 @@
+package mux
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// BasicAuth returns a middleware, for Use, WithMiddleware or a Group,
+// requiring HTTP basic authentication with one of the given passwords by
+// user name. Requests without valid credentials are answered with 401
+// Unauthorized and a WWW-Authenticate header naming realm. Handlers get the
+// user name with AuthUser.
+//
+// Credentials are compared in constant time, so the time taken does not
+// reveal how much of a password, or whether the user name, is right.
+//
+// Panics if users is empty.
+func BasicAuth(realm string, users map[string]string) func(http.Handler) http.Handler {
+	if len(users) == 0 {
+		panic("mux: no users")
+	}
+	passwords := make(map[string][sha256.Size]byte, len(users))
+	for user, password := range users {
+		passwords[user] = sha256.Sum256([]byte(password))
+	}
+	challenge := "Basic realm=" + strconv.Quote(realm) + `, charset="UTF-8"`
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, password, ok := r.BasicAuth()
+			want, known := passwords[user]
+			got := sha256.Sum256([]byte(password))
+			match := subtle.ConstantTimeCompare(got[:], want[:]) == 1
+			if !ok || !known || !match {
+				unauthorized(w, challenge)
+				return
+			}
+			next.ServeHTTP(w, withAuthUser(r, user))
+		})
+	}
+}
+
+// BearerToken returns a middleware, for Use, WithMiddleware or a Group,
+// requiring one of the given static tokens in an "Authorization: Bearer"
+// header. Requests without a valid token are answered with 401 Unauthorized.
+// Tokens are compared in constant time.
+//
+// Panics if no tokens are given or a token is empty.
+func BearerToken(tokens ...string) func(http.Handler) http.Handler {
+	hashes := hashTokens(tokens)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := bearerToken(r)
+			if !ok || !hashes.contain(token) {
+				unauthorized(w, "Bearer")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// APIKey returns a middleware, for Use, WithMiddleware or a Group, requiring
+// one of the given static keys in the request header name, like
+// "X-Api-Key". Requests without a valid key are answered with 401
+// Unauthorized. Keys are compared in constant time.
+//
+// Panics if no keys are given or a key is empty.
+func APIKey(name string, keys ...string) func(http.Handler) http.Handler {
+	hashes := hashTokens(keys)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !hashes.contain(r.Header.Get(name)) {
+				unauthorized(w, "")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// tokenHashes are the SHA-256 hashes of secret tokens.
+type tokenHashes [][sha256.Size]byte
+
+// hashTokens returns the hashes of tokens.
+// Panics if tokens is empty or a token is empty.
+func hashTokens(tokens []string) tokenHashes {
+	if len(tokens) == 0 {
+		panic("mux: no tokens")
+	}
+	hashes := make(tokenHashes, len(tokens))
+	for i, token := range tokens {
+		if token == "" {
+			panic("mux: empty token")
+		}
+		hashes[i] = sha256.Sum256([]byte(token))
+	}
+	return hashes
+}
+
+// contain reports whether token is one of the hashed tokens, comparing it
+// with every one in constant time.
+func (hashes tokenHashes) contain(token string) bool {
+	if token == "" {
+		return false
+	}
+	h := sha256.Sum256([]byte(token))
+	found := 0
+	for i := range hashes {
+		found |= subtle.ConstantTimeCompare(h[:], hashes[i][:])
+	}
+	return found == 1
+}
+
+// bearerToken returns the token of the bearer Authorization header of r.
+func bearerToken(r *http.Request) (string, bool) {
+	auth := r.Header.Get("Authorization")
+	const prefix = "Bearer "
+	if len(auth) <= len(prefix) || !strings.EqualFold(auth[:len(prefix)], prefix) {
+		return "", false
+	}
+	return strings.TrimSpace(auth[len(prefix):]), true
+}
+
+// unauthorized replies with 401 Unauthorized, with the WWW-Authenticate
+// challenge unless it is empty.
+func unauthorized(w http.ResponseWriter, challenge string) {
+	if challenge != "" {
+		w.Header().Set("WWW-Authenticate", challenge)
+	}
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
+
+// authUserKey is the context key for the user name of a request
+// authenticated by BasicAuth.
+type authUserKey struct{}
+
+// withAuthUser returns a shallow copy of r with the given user name.
+func withAuthUser(r *http.Request, user string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), authUserKey{}, user))
+}
+
+// AuthUser returns the user name of a request authenticated by BasicAuth or
+// "" if there is none.
+func AuthUser(r *http.Request) string {
+	user, _ := r.Context().Value(authUserKey{}).(string)
+	return user
+}
@@ -0,0 +1,281 @@
+package mux
+
+import (
+	"container/list"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CachedResponse is a response stored by a CacheStore.
+type CachedResponse struct {
+	Status  int
+	Header  http.Header
+	Body    []byte
+	Created time.Time // time the response was served
+}
+
+// CacheStore stores the responses cached by Cached, so that they can be kept
+// elsewhere than in memory, as in a cache shared by instances of a service.
+// Its methods are called concurrently.
+type CacheStore interface {
+	// Get returns the response stored under key, if it has not expired.
+	Get(key string) (*CachedResponse, bool)
+
+	// Set stores the response under key for ttl.
+	Set(key string, resp *CachedResponse, ttl time.Duration)
+}
+
+// Cache configures the response cache set by Cached.
+type Cache struct {
+	// TTL is how long responses are cached.
+	TTL time.Duration
+
+	// Store stores the responses. If nil, they are stored in memory, in a
+	// store of the route's own holding up to 1000 responses.
+	Store CacheStore
+
+	// Vary are the request headers, like "Accept-Language", whose values
+	// the responses of the route depend on. They are part of the key
+	// responses are cached under and added to the Vary header.
+	Vary []string
+}
+
+// Cached caches the responses to GET requests to the route, keyed by the
+// host, the pattern the request was routed to, its path parameters, its query
+// and the values of the headers in Vary. HEAD requests are answered from the
+// responses to GET requests.
+//
+// Only 200 OK responses without a Set-Cookie header or a Cache-Control
+// header with no-store or private are cached. Responses without a
+// Cache-Control header get one allowing clients to cache them for the TTL,
+// and responses served from the cache have an Age header.
+//
+// Responses to requests with an Authorization or Cookie header are neither
+// served from nor stored in the cache unless they have a Cache-Control header
+// with public or s-maxage, as they may differ between users. RouteOptions
+// wrap the handler in order, each around the ones before it, so options
+// authorizing requests, like WithMiddleware(RequireJWT(c)), coming after
+// Cached are run before it, and those coming before it only when the
+// response is not served from the cache.
+//
+// Panics if TTL is not positive.
+func Cached(c Cache) RouteOption {
+	if c.TTL <= 0 {
+		panic("mux: cache TTL must be positive")
+	}
+	if c.Store == nil {
+		c.Store = NewMemoryCache(1000)
+	}
+	vary := make([]string, len(c.Vary))
+	for i, name := range c.Vary {
+		vary[i] = http.CanonicalHeaderKey(name)
+	}
+	maxAge := "max-age=" + strconv.Itoa(int(c.TTL/time.Second))
+
+	return func(mux *Mux, e *muxEntry) {
+		next := e.handler
+		e.handler = func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next(w, r)
+				return
+			}
+			h := w.Header()
+			for _, name := range vary {
+				addVary(h, name)
+			}
+
+			key := cacheKey(r, vary)
+			credentials := r.Header.Get("Authorization") != "" || r.Header.Get("Cookie") != ""
+			if resp, ok := c.Store.Get(key); ok && (!credentials || shared(resp.Header)) {
+				for name, values := range resp.Header {
+					h[name] = append([]string(nil), values...)
+				}
+				h.Set("Age", strconv.Itoa(int(time.Since(resp.Created)/time.Second)))
+				w.WriteHeader(resp.Status)
+				if r.Method != http.MethodHead {
+					w.Write(resp.Body)
+				}
+				return
+			}
+			if r.Method == http.MethodHead {
+				next(w, r)
+				return
+			}
+
+			if h.Get("Cache-Control") == "" {
+				h.Set("Cache-Control", maxAge)
+			}
+			cw := &cacheWriter{ResponseWriter: WrapResponseWriter(w), created: time.Now()}
+			next(cw, r)
+			if resp, ok := cw.response(); ok && (!credentials || shared(resp.Header)) {
+				c.Store.Set(key, resp, c.TTL)
+			}
+		}
+	}
+}
+
+// cacheKey returns the key of the response to r with the given headers
+// varying it.
+func cacheKey(r *http.Request, vary []string) string {
+	var b strings.Builder
+	b.WriteString(r.Host)
+	b.WriteByte(' ')
+	b.WriteString(MatchedPattern(r))
+	params := Params(r)
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		b.WriteByte(' ')
+		b.WriteString(strconv.Quote(name))
+		b.WriteByte('=')
+		b.WriteString(strconv.Quote(params[name]))
+	}
+	b.WriteString(" ?")
+	b.WriteString(r.URL.Query().Encode())
+	for _, name := range vary {
+		b.WriteByte(' ')
+		b.WriteString(name)
+		b.WriteByte(':')
+		b.WriteString(strconv.Quote(strings.Join(r.Header.Values(name), ",")))
+	}
+	return b.String()
+}
+
+// cacheWriter records the response written through it.
+type cacheWriter struct {
+	ResponseWriter
+	created time.Time
+	header  http.Header // header written
+	body    []byte
+}
+
+func (cw *cacheWriter) WriteHeader(code int) {
+	if cw.header == nil && code >= http.StatusOK {
+		cw.header = cw.Header().Clone()
+	}
+	cw.ResponseWriter.WriteHeader(code)
+}
+
+func (cw *cacheWriter) Write(b []byte) (int, error) {
+	if cw.Status() == 0 {
+		cw.WriteHeader(http.StatusOK)
+	}
+	n, err := cw.ResponseWriter.Write(b)
+	cw.body = append(cw.body, b[:n]...)
+	return n, err
+}
+
+// ReadFrom copies from r with Write, so that the body is recorded.
+func (cw *cacheWriter) ReadFrom(r io.Reader) (int64, error) {
+	return io.Copy(writerOnly{cw}, r)
+}
+
+// response returns the response written if it may be cached. Responses of
+// hijacked connections have status 101 and are not.
+func (cw *cacheWriter) response() (*CachedResponse, bool) {
+	status := cw.Status()
+	if status == 0 {
+		status, cw.header = http.StatusOK, cw.Header().Clone()
+	}
+	if status != http.StatusOK || cw.header.Get("Set-Cookie") != "" {
+		return nil, false
+	}
+	for _, directive := range splitList(cw.header.Values("Cache-Control")) {
+		switch strings.ToLower(directive) {
+		case "no-store", "private":
+			return nil, false
+		}
+	}
+	return &CachedResponse{Status: status, Header: cw.header, Body: cw.body, Created: cw.created}, true
+}
+
+// shared reports whether the response with the header h may be served to
+// users other than the one it was written for, as its Cache-Control header
+// has public or s-maxage.
+func shared(h http.Header) bool {
+	for _, directive := range splitList(h.Values("Cache-Control")) {
+		directive = strings.ToLower(directive)
+		if directive == "public" || strings.HasPrefix(directive, "s-maxage=") {
+			return true
+		}
+	}
+	return false
+}
+
+// Flush flushes the underlying ResponseWriter if it is an http.Flusher.
+func (cw *cacheWriter) Flush() {
+	if _, ok := cw.Unwrap().(http.Flusher); ok && cw.Status() == 0 {
+		cw.WriteHeader(http.StatusOK)
+	}
+	cw.ResponseWriter.Flush()
+}
+
+// NewMemoryCache returns a CacheStore keeping up to maxEntries responses in
+// memory, dropping the least recently used ones to make room for new ones.
+//
+// Panics if maxEntries is less than 1.
+func NewMemoryCache(maxEntries int) CacheStore {
+	if maxEntries < 1 {
+		panic("mux: cache size must be at least 1")
+	}
+	return &memoryCache{max: maxEntries, entries: make(map[string]*list.Element), lru: list.New()}
+}
+
+// memoryCache is a CacheStore in memory.
+type memoryCache struct {
+	mu      sync.Mutex
+	max     int
+	entries map[string]*list.Element // elements of lru by key
+	lru     *list.List               // *memoryEntry, most recently used first
+}
+
+// memoryEntry is a response stored by a memoryCache.
+type memoryEntry struct {
+	key     string
+	resp    *CachedResponse
+	expires time.Time
+}
+
+func (c *memoryCache) Get(key string) (*CachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*memoryEntry)
+	if !time.Now().Before(entry.expires) {
+		c.lru.Remove(el)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.lru.MoveToFront(el)
+	return entry.resp, true
+}
+
+func (c *memoryCache) Set(key string, resp *CachedResponse, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &memoryEntry{key: key, resp: resp, expires: time.Now().Add(ttl)}
+	if el, ok := c.entries[key]; ok {
+		el.Value = entry
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.max {
+		el := c.lru.Back()
+		c.lru.Remove(el)
+		delete(c.entries, el.Value.(*memoryEntry).key)
+	}
+}
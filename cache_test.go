@@ -0,0 +1,166 @@
+package mux_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestCached(t *testing.T) {
+	var calls int
+	counter := func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		fmt.Fprintf(w, "%s %d", mux.Param(r, "id"), calls)
+	}
+
+	m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found")))
+	m.HandleFunc("/items/{id}", counter, mux.Cached(mux.Cache{TTL: time.Minute, Vary: []string{"accept-language"}}))
+	m.Get("/private", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Cache-Control", "private")
+		fmt.Fprint(w, calls)
+	}, mux.Cached(mux.Cache{TTL: time.Minute}))
+	m.Get("/error", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.Error(w, fmt.Sprint(calls), http.StatusInternalServerError)
+	}, mux.Cached(mux.Cache{TTL: time.Minute}))
+	m.Get("/added-private", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Add("Cache-Control", "private")
+		fmt.Fprint(w, calls)
+	}, mux.Cached(mux.Cache{TTL: time.Minute}))
+
+	serve := func(method, path, acceptLanguage string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, nil)
+		if acceptLanguage != "" {
+			r.Header.Set("Accept-Language", acceptLanguage)
+		}
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, r)
+		return rec
+	}
+
+	steps := []struct {
+		method         string
+		path           string
+		acceptLanguage string
+		body           string
+		cached         bool
+	}{
+		{http.MethodGet, "/items/1", "", "1 1", false},
+		{http.MethodGet, "/items/1", "", "1 1", true},
+		{http.MethodHead, "/items/1", "", "", true},
+		{http.MethodGet, "/items/2", "", "2 2", false},
+		{http.MethodGet, "/items/1?page=2", "", "1 3", false},
+		{http.MethodGet, "/items/1", "de", "1 4", false},
+		{http.MethodGet, "/items/1", "de", "1 4", true},
+		{http.MethodPost, "/items/1", "", "1 5", false},
+		{http.MethodGet, "/items/1", "", "1 1", true},
+		{http.MethodGet, "/private", "", "6", false},
+		{http.MethodGet, "/private", "", "7", false},
+		{http.MethodGet, "/error", "", "8\n", false},
+		{http.MethodGet, "/error", "", "9\n", false},
+		{http.MethodGet, "/added-private", "", "10", false},
+		{http.MethodGet, "/added-private", "", "11", false},
+	}
+	for i, s := range steps {
+		rec := serve(s.method, s.path, s.acceptLanguage)
+		if body := rec.Body.String(); body != s.body {
+			t.Errorf("%d. %s %s: got body %q, want %q", i, s.method, s.path, body, s.body)
+		}
+		if _, cached := rec.Header()["Age"]; cached != s.cached {
+			t.Errorf("%d. %s %s: got cached %t, want %t", i, s.method, s.path, cached, s.cached)
+		}
+	}
+
+	rec := serve(http.MethodGet, "/items/1", "")
+	if got := rec.Header().Get("Cache-Control"); got != "max-age=60" {
+		t.Errorf("got Cache-Control %q, want %q", got, "max-age=60")
+	}
+	if got := rec.Header().Get("Vary"); got != "Accept-Language" {
+		t.Errorf("got Vary %q, want %q", got, "Accept-Language")
+	}
+
+	t.Run("authorized", func(t *testing.T) {
+		m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found")))
+		m.Get("/account", handlerFactory(http.StatusOK, "account"),
+			mux.Cached(mux.Cache{TTL: time.Minute}),
+			mux.WithMiddleware(mux.BasicAuth("account", map[string]string{"ana": "secret"})))
+
+		r := httptest.NewRequest(http.MethodGet, "/account", nil)
+		r.SetBasicAuth("ana", "secret")
+		m.ServeHTTP(httptest.NewRecorder(), r)
+
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/account", nil))
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("got StatusCode %d, want %d", rec.Code, http.StatusUnauthorized)
+		}
+	})
+
+	t.Run("credentials", func(t *testing.T) {
+		user := func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, r.Header.Get("Authorization"))
+		}
+		m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found")))
+		m.Get("/me", user,
+			mux.Cached(mux.Cache{TTL: time.Minute}),
+			mux.WithMiddleware(mux.BearerToken("ana", "bob")))
+		m.Get("/public", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Cache-Control", "public, max-age=60")
+			user(w, r)
+		}, mux.Cached(mux.Cache{TTL: time.Minute}), mux.WithMiddleware(mux.BearerToken("ana", "bob")))
+
+		cases := []struct {
+			path  string
+			token string
+			body  string
+		}{
+			{"/me", "ana", "Bearer ana"},
+			{"/me", "bob", "Bearer bob"},
+			{"/me", "ana", "Bearer ana"},
+			{"/public", "ana", "Bearer ana"},
+			{"/public", "bob", "Bearer ana"},
+		}
+		for i, c := range cases {
+			r := httptest.NewRequest(http.MethodGet, c.path, nil)
+			r.Header.Set("Authorization", "Bearer "+c.token)
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+			if got := rec.Body.String(); got != c.body {
+				t.Errorf("%d. %s as %s: got body %q, want %q", i, c.path, c.token, got, c.body)
+			}
+		}
+	})
+
+	t.Run("invalid TTL", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("got no panic, want panic")
+			}
+		}()
+		mux.Cached(mux.Cache{})
+	})
+}
+
+func TestMemoryCache(t *testing.T) {
+	c := mux.NewMemoryCache(2)
+	resp := func(body string) *mux.CachedResponse {
+		return &mux.CachedResponse{Status: http.StatusOK, Body: []byte(body)}
+	}
+	c.Set("a", resp("a"), time.Minute)
+	c.Set("b", resp("b"), time.Minute)
+	c.Get("a")
+	c.Set("c", resp("c"), time.Minute)
+	c.Set("c", resp("c"), -time.Second)
+
+	for key, want := range map[string]bool{"a": true, "b": false, "c": false} {
+		if _, ok := c.Get(key); ok != want {
+			t.Errorf("%s: got %t, want %t", key, ok, want)
+		}
+	}
+}
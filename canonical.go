@@ -0,0 +1,196 @@
+package mux
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// RedirectCode sets the status code of the redirects to the canonical URL of
+// a request, like those removing a trailing slash or lowercasing the path,
+// instead of 308 Permanent Redirect, and of those of Locales to a locale
+// instead of 307 Temporary Redirect. Browsers cache permanent redirects, so a
+// temporary one eases changing routes later. Requests with methods other than
+// GET and HEAD are redirected with 307 instead of 302 and 303 and with 308
+// instead of 301, as clients may change their method to GET.
+//
+// Panics if code is not 301, 302, 303, 307 or 308.
+func RedirectCode(code int) Option {
+	switch code {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+	default:
+		panic("mux: invalid redirect code " + strconv.Itoa(code))
+	}
+
+	return func(mux *Mux) {
+		mux.redirectCode = code
+	}
+}
+
+// RewriteRedirects makes mux serve requests with methods other than GET and
+// HEAD that it would redirect to their canonical URL, like "/a/" to "/a", as
+// if they were made to the canonical URL, without a round trip to the client.
+// Many clients follow redirects of such requests with a GET, dropping the
+// body, or not at all. Handlers see the request with the canonical path.
+//
+// Without it, such requests are redirected with 307 Temporary Redirect or 308
+// Permanent Redirect, which preserve the method and body, even if
+// RedirectCode sets another code.
+func RewriteRedirects() Option {
+	return func(mux *Mux) {
+		mux.rewrite = true
+	}
+}
+
+// rewrittenKey is the context key marking requests served by RewriteRedirects
+// at their canonical URL.
+type rewrittenKey struct{}
+
+// rewrite returns a shallow copy of r with the path of u, marked as
+// rewritten so that it is redirected rather than rewritten again.
+func rewrite(r *http.Request, u *url.URL) *http.Request {
+	r2 := r.WithContext(context.WithValue(r.Context(), rewrittenKey{}, true))
+	v := *r.URL
+	v.Path, v.RawPath = u.Path, u.RawPath
+	r2.URL = &v
+	return r2
+}
+
+// methodPreserving returns the redirect status code preserving the request
+// method that corresponds to code.
+func methodPreserving(code int) int {
+	switch code {
+	case http.StatusMovedPermanently:
+		return http.StatusPermanentRedirect
+	case http.StatusFound, http.StatusSeeOther:
+		return http.StatusTemporaryRedirect
+	}
+	return code
+}
+
+// redirectStatus returns the status code of redirects to canonical URLs.
+func (t *table) redirectStatus() int {
+	if t.redirectCode == 0 {
+		return http.StatusPermanentRedirect
+	}
+	return t.redirectCode
+}
+
+// NoRedirect makes mux never redirect requests to the route, as webhook
+// receivers and OAuth callbacks need, whose clients may drop the body or
+// break the signature of a redirected request. The route serves requests
+// whose path it matches only with a trailing slash removed, or added for a
+// subtree root, as with IgnoreTrailingSlash, and it implies ExactPath, so
+// requests whose path it matches as it is are not redirected to the
+// lowercase, NFC or clean path either.
+func NoRedirect() RouteOption {
+	return func(mux *Mux, e *muxEntry) {
+		e.exactPath = true
+		e.noRedirect = true
+		e.slash = IgnoreTrailingSlash
+	}
+}
+
+// canonicalURL returns the URL to redirect u to so that it has the given
+// path. Everything but the path is kept verbatim, so a query like
+// "?code=AbC" survives any canonicalization of the path. Leading slashes are
+// collapsed to one, as a path like "//evil.com" would redirect to another
+// host.
+func canonicalURL(u *url.URL, path string) *url.URL {
+	if strings.HasPrefix(path, "//") {
+		path = "/" + strings.TrimLeft(path, "/")
+	}
+	return &url.URL{
+		Path:       path,
+		RawQuery:   u.RawQuery,
+		ForceQuery: u.ForceQuery,
+		Fragment:   u.Fragment,
+	}
+}
+
+// RedirectCleanPath makes mux, like http.ServeMux, redirect requests whose
+// path has "." or ".." segments or repeated slashes to the clean path, so
+// that "/a/../b" and "//b" do not bypass the routes for "/b" or reach
+// handlers with surprising paths. A trailing slash is kept and CONNECT
+// requests are never redirected.
+//
+// Without it, paths are routed as they are, as APIs whose paths carry keys
+// that may contain such sequences need.
+func RedirectCleanPath() Option {
+	return func(mux *Mux) {
+		mux.cleanPaths = true
+	}
+}
+
+// cleanPath returns the canonical path for p, eliminating "." and ".."
+// segments and repeated slashes but keeping a trailing slash.
+func cleanPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	if p[0] != '/' {
+		p = "/" + p
+	}
+	np := path.Clean(p)
+	if p[len(p)-1] == '/' && np != "/" {
+		np += "/"
+	}
+	return np
+}
+
+// canonicalize returns a handler redirecting r to its canonical URL if the
+// path of r is not canonical according to the clean path, lowercase and
+// Unicode options, recording the decision in ex unless ex is nil.
+//
+// All canonicalizations are applied in one redirect: the path is cleaned,
+// lowercased, normalized to NFC and then, if the resulting path would be
+// redirected because of its trailing slash, stripped of the trailing slash
+// too or, for a subtree, given one.
+func (t *table) canonicalize(r *http.Request, ex *Explanation) (http.HandlerFunc, bool) {
+	path := r.URL.Path
+	var reasons []string
+	if t.cleanPaths && path != "" && r.Method != http.MethodConnect {
+		if clean := cleanPath(path); clean != path {
+			path = clean
+			reasons = append(reasons, "clean path")
+		}
+	}
+	if t.lowercase {
+		if lower := toLower(path, t.lowercaseASCII); lower != path {
+			path = lower
+			reasons = append(reasons, "lowercase")
+		}
+	}
+	if t.unicode == unicodeRedirect && !norm.NFC.IsNormalString(path) {
+		path = norm.NFC.String(path)
+		reasons = append(reasons, "unicode normalization")
+	}
+	if reasons == nil || t.routedExactly(r) {
+		return nil, false
+	}
+
+	// Avoid a second redirect for the trailing slash.
+	r2 := new(http.Request)
+	*r2 = *r
+	r2.URL = canonicalURL(r.URL, path)
+	var next Explanation
+	t.route(r2, &next)
+	if next.Outcome == OutcomeRedirected && next.Reason == "trailing slash" {
+		if strings.HasSuffix(path, "/") {
+			path = path[:len(path)-1]
+		} else {
+			path += "/" // subtree
+		}
+		reasons = append(reasons, "trailing slash")
+	}
+
+	u := canonicalURL(r.URL, path)
+	ex.redirect(u, strings.Join(reasons, ", "))
+	return t.redirectHandler(u, t.redirectStatus(), strings.Join(reasons, ", ")), true
+}
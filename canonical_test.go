@@ -0,0 +1,240 @@
+package mux_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestCanonicalRedirect(t *testing.T) {
+	m := mux.New(mux.RedirectLowercase(), mux.RedirectUnicode())
+	m.HandleFunc("/caf\u00e9", handlerFactory(http.StatusTeapot, "cafe"))
+	m.HandleFunc("/a", handlerFactory(http.StatusTeapot, "a"))
+
+	cases := []struct {
+		path     string
+		location string
+		reason   string
+	}{
+		{"/A?Code=AbC&x=%2F", "/a?Code=AbC&x=%2F", "lowercase"},
+		{"/A/?Code=AbC", "/a?Code=AbC", "lowercase, trailing slash"},
+		{"/CAFE\u0301/?q=X", "/caf%C3%A9?q=X", "lowercase, unicode normalization, trailing slash"},
+		{"/a/?Code=AbC", "/a?Code=AbC", "trailing slash"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, c.path, nil)
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+			resp := rec.Result()
+
+			if resp.StatusCode != http.StatusPermanentRedirect {
+				t.Errorf("got StatusCode %d, want %d", resp.StatusCode, http.StatusPermanentRedirect)
+			}
+
+			location := resp.Header.Get("Location")
+			if location != c.location {
+				t.Errorf("got Location %q, want %q", location, c.location)
+			}
+
+			ex := m.Explain(httptest.NewRequest(http.MethodGet, c.path, nil))
+			if ex.Reason != c.reason {
+				t.Errorf("got Reason %q, want %q", ex.Reason, c.reason)
+			}
+		})
+	}
+}
+
+func TestCanonicalRedirectLeadingSlashes(t *testing.T) {
+	m := mux.New()
+	m.RegexpHandleFunc("^/.*$", handlerFactory(http.StatusTeapot, "any"))
+
+	for _, path := range []string{"//evil.com/", "///evil.com/"} {
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusPermanentRedirect {
+			t.Errorf("%s: got StatusCode %d, want %d", path, rec.Code, http.StatusPermanentRedirect)
+		}
+		if got, want := rec.Header().Get("Location"), "/evil.com"; got != want {
+			t.Errorf("%s: got Location %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestRedirectCleanPath(t *testing.T) {
+	m := mux.New(mux.RedirectCleanPath(), mux.RedirectLowercase())
+	m.HandleFunc("/a/b", handlerFactory(http.StatusTeapot, "b"))
+	m.HandleFunc("/files/{path...}", handlerFactory(http.StatusTeapot, "files"))
+
+	cases := []struct {
+		path       string
+		statusCode int
+		location   string
+		reason     string
+	}{
+		{"/a/b", http.StatusTeapot, "", ""},
+		{"/a/./b?q=1", http.StatusPermanentRedirect, "/a/b?q=1", "clean path"},
+		{"/a/c/../b", http.StatusPermanentRedirect, "/a/b", "clean path"},
+		{"//a//b", http.StatusPermanentRedirect, "/a/b", "clean path"},
+		{"/../a/b", http.StatusPermanentRedirect, "/a/b", "clean path"},
+		{"/A//B/", http.StatusPermanentRedirect, "/a/b", "clean path, lowercase, trailing slash"},
+		{"/files/x/../", http.StatusPermanentRedirect, "/files/", "clean path"},
+		{"/files/x/", http.StatusTeapot, "", ""},
+	}
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, c.path, nil)
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+			resp := rec.Result()
+
+			if resp.StatusCode != c.statusCode {
+				t.Errorf("got StatusCode %d, want %d", resp.StatusCode, c.statusCode)
+			}
+			if location := resp.Header.Get("Location"); location != c.location {
+				t.Errorf("got Location %q, want %q", location, c.location)
+			}
+			if ex := m.Explain(r); ex.Reason != c.reason {
+				t.Errorf("got Reason %q, want %q", ex.Reason, c.reason)
+			}
+		})
+	}
+}
+
+func TestRedirectCode(t *testing.T) {
+	inner := mux.New()
+	inner.HandleFunc("/about", handlerFactory(http.StatusTeapot, "about"))
+
+	m := mux.New(mux.RedirectCode(http.StatusFound), mux.RedirectLowercase())
+	m.HandleFunc("/a", handlerFactory(http.StatusTeapot, "a"))
+	m.Locales([]string{"en"}, "en", inner)
+
+	cases := []struct {
+		path     string
+		code     int
+		location string
+	}{
+		{"/a/", http.StatusFound, "/a"},
+		{"/A", http.StatusFound, "/a"},
+		{"/en/", http.StatusFound, "/en"},
+		{"/about", http.StatusFound, "/en/about"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, c.path, nil)
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+			resp := rec.Result()
+
+			if resp.StatusCode != c.code {
+				t.Errorf("got StatusCode %d, want %d", resp.StatusCode, c.code)
+			}
+
+			location := resp.Header.Get("Location")
+			if location != c.location {
+				t.Errorf("got Location %q, want %q", location, c.location)
+			}
+		})
+	}
+
+	t.Run("POST", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/a/", nil))
+		if rec.Code != http.StatusTemporaryRedirect {
+			t.Errorf("got StatusCode %d, want %d", rec.Code, http.StatusTemporaryRedirect)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("got no panic, want panic")
+			}
+		}()
+
+		mux.RedirectCode(http.StatusOK)
+	})
+}
+
+func TestRewriteRedirects(t *testing.T) {
+	echo := func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		io.WriteString(w, r.URL.Path+"?"+r.URL.RawQuery+" "+string(body))
+	}
+	m := mux.New(mux.RewriteRedirects(), mux.RedirectLowercase(), mux.RedirectCode(http.StatusMovedPermanently))
+	m.HandleFunc("/a", echo)
+	m.HandleFunc("/b/{id}", echo)
+
+	cases := []struct {
+		method   string
+		path     string
+		code     int
+		body     string
+		location string
+	}{
+		{http.MethodPost, "/a/?x=1", http.StatusOK, "/a?x=1 data", ""},
+		{http.MethodPut, "/A", http.StatusOK, "/a? data", ""},
+		{http.MethodDelete, "/B/Id/", http.StatusOK, "/b/id? data", ""},
+		{http.MethodGet, "/a/", http.StatusMovedPermanently, "", "/a"},
+		{http.MethodHead, "/A", http.StatusMovedPermanently, "", "/a"},
+		{http.MethodPost, "/c/", http.StatusNotFound, "", ""},
+	}
+	for _, c := range cases {
+		t.Run(c.method+" "+c.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, httptest.NewRequest(c.method, c.path, strings.NewReader("data")))
+			if rec.Code != c.code {
+				t.Errorf("got StatusCode %d, want %d", rec.Code, c.code)
+			}
+			if c.body != "" && rec.Body.String() != c.body {
+				t.Errorf("got body %q, want %q", rec.Body.String(), c.body)
+			}
+			if location := rec.Header().Get("Location"); location != c.location {
+				t.Errorf("got Location %q, want %q", location, c.location)
+			}
+		})
+	}
+}
+
+func TestNoRedirect(t *testing.T) {
+	m := mux.New(mux.RedirectLowercase(), mux.RedirectCleanPath(), mux.SubtreePatterns())
+	m.Post("/hooks/GitHub", handlerFactory(http.StatusTeapot, "github"), mux.NoRedirect())
+	m.HandleFunc("/callback/", handlerFactory(http.StatusTeapot, "callback"), mux.NoRedirect())
+	m.Post("/other", handlerFactory(http.StatusTeapot, "other"))
+
+	cases := []struct {
+		method   string
+		path     string
+		code     int
+		body     string
+		location string
+	}{
+		{http.MethodPost, "/hooks/GitHub", http.StatusTeapot, "github", ""},
+		{http.MethodPost, "/hooks/GitHub/", http.StatusTeapot, "github", ""},
+		{http.MethodGet, "/callback", http.StatusTeapot, "callback", ""},
+		{http.MethodGet, "/callback/x", http.StatusTeapot, "callback", ""},
+		{http.MethodPost, "/other/", http.StatusPermanentRedirect, "", "/other"},
+		{http.MethodPost, "/OTHER", http.StatusPermanentRedirect, "", "/other"},
+	}
+	for _, c := range cases {
+		t.Run(c.method+" "+c.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, httptest.NewRequest(c.method, c.path, nil))
+			if rec.Code != c.code {
+				t.Errorf("got StatusCode %d, want %d", rec.Code, c.code)
+			}
+			if c.body != "" && rec.Body.String() != c.body {
+				t.Errorf("got body %q, want %q", rec.Body.String(), c.body)
+			}
+			if location := rec.Header().Get("Location"); location != c.location {
+				t.Errorf("got Location %q, want %q", location, c.location)
+			}
+		})
+	}
+}
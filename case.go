@@ -0,0 +1,83 @@
+package mux
+
+import (
+	"net/http"
+	"strings"
+)
+
+// RedirectLowercase returns an Option that makes matching case-insensitive by
+// redirecting requests whose path has uppercase letters to the lowercase
+// path, like a path with a trailing slash is redirected to the path without.
+// Only the path is lowercased; the query is kept as it is. Patterns that are
+// not regular expressions are lowercased when registered, except for their
+// parameter names, so "/About" serves "/about"; regexp patterns must match
+// lowercase paths themselves.
+//
+// Non-ASCII letters are lowercased too, by Unicode rules that do not suit
+// every language: the Turkish "İ" becomes "i̇", an "i" with a combining dot.
+// Use RedirectLowercaseASCII to keep them as they are.
+//
+// Without it, mux is case-sensitive and never redirects to lowercase paths.
+func RedirectLowercase() Option {
+	return func(mux *Mux) {
+		mux.lowercase = true
+		mux.lowercaseASCII = false
+	}
+}
+
+// RedirectLowercaseASCII is RedirectLowercase except that only the ASCII
+// letters A to Z are lowercased, so paths in other scripts are matched as
+// they are.
+func RedirectLowercaseASCII() Option {
+	return func(mux *Mux) {
+		mux.lowercase = true
+		mux.lowercaseASCII = true
+	}
+}
+
+// ExactPath makes the route match request paths exactly as they are: its
+// pattern is neither lowercased by RedirectLowercase nor normalized by
+// NormalizeUnicode or RedirectUnicode when registered, and requests whose path
+// the route matches as it is are neither redirected to the lowercase or NFC
+// path nor normalized.
+func ExactPath() RouteOption {
+	return func(mux *Mux, e *muxEntry) {
+		e.exactPath = true
+	}
+}
+
+// lowercasePattern returns the non-regexp pattern with its segments, except
+// for parameters, lowercased, only the ASCII letters if ascii.
+func lowercasePattern(pattern string, ascii bool) string {
+	segments := strings.Split(pattern, "/")
+	for i, s := range segments {
+		if !strings.HasPrefix(s, "{") && !strings.HasPrefix(s, ":") {
+			segments[i] = toLower(s, ascii)
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// toLower returns s lowercased, only the ASCII letters if ascii.
+func toLower(s string, ascii bool) string {
+	if !ascii {
+		return strings.ToLower(s)
+	}
+	return strings.Map(func(r rune) rune {
+		if 'A' <= r && r <= 'Z' {
+			return r + 'a' - 'A'
+		}
+		return r
+	}, s)
+}
+
+// routedExactly reports whether r, with its path as it is, is routed to a
+// route with ExactPath.
+func (t *table) routedExactly(r *http.Request) bool {
+	if !t.exactPaths {
+		return false
+	}
+	var ex Explanation
+	t.route(r, &ex)
+	return ex.Outcome == OutcomeMatched && t.m[ex.Pattern].exactPath
+}
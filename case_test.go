@@ -0,0 +1,150 @@
+package mux_test
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestRedirectLowercase(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		io.WriteString(w, r.URL.Path+" "+mux.Param(r, "userID"))
+	}
+
+	t.Run("redirect", func(t *testing.T) {
+		m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found")), mux.RedirectLowercase())
+		m.HandleFunc("/About", h)
+		m.HandleFunc("/users/{userID}", h)
+
+		cases := []struct {
+			path     string
+			code     int
+			body     string
+			location string
+		}{
+			{"/about", http.StatusTeapot, "/about ", ""},
+			{"/ABOUT", http.StatusPermanentRedirect, "", "/about"},
+			{"/About?Code=AbC", http.StatusPermanentRedirect, "", "/about?Code=AbC"},
+			{"/users/ab", http.StatusTeapot, "/users/ab ab", ""},
+			{"/Users/AB", http.StatusPermanentRedirect, "", "/users/ab"},
+		}
+
+		for _, c := range cases {
+			t.Run(c.path, func(t *testing.T) {
+				r := httptest.NewRequest(http.MethodGet, c.path, nil)
+				rec := httptest.NewRecorder()
+				m.ServeHTTP(rec, r)
+				resp := rec.Result()
+
+				if resp.StatusCode != c.code {
+					t.Errorf("got StatusCode %d, want %d", resp.StatusCode, c.code)
+				}
+
+				location := resp.Header.Get("Location")
+				if location != c.location {
+					t.Errorf("got Location %q, want %q", location, c.location)
+				}
+
+				if c.location != "" {
+					return
+				}
+
+				b, err := ioutil.ReadAll(resp.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				body := string(b)
+				if body != c.body {
+					t.Errorf("got body %q, want %q", body, c.body)
+				}
+			})
+		}
+	})
+
+	t.Run("case-sensitive by default", func(t *testing.T) {
+		m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found")))
+		m.HandleFunc("/users/{id}", h)
+
+		r := httptest.NewRequest(http.MethodGet, "/users/AbC", nil)
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, r)
+		resp := rec.Result()
+
+		if resp.StatusCode != http.StatusTeapot {
+			t.Errorf("got StatusCode %d, want %d", resp.StatusCode, http.StatusTeapot)
+		}
+	})
+}
+
+func TestRedirectLowercaseASCII(t *testing.T) {
+	m := mux.New(mux.RedirectLowercaseASCII())
+	m.HandleFunc("/İstanbul", handlerFactory(http.StatusTeapot, "istanbul"))
+	m.HandleFunc("/About", handlerFactory(http.StatusTeapot, "about"))
+
+	cases := []struct {
+		path     string
+		code     int
+		location string
+	}{
+		{"/%C4%B0stanbul", http.StatusTeapot, ""},
+		{"/ABOUT", http.StatusPermanentRedirect, "/about"},
+		{"/about", http.StatusTeapot, ""},
+	}
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, c.path, nil))
+			if rec.Code != c.code {
+				t.Errorf("got StatusCode %d, want %d", rec.Code, c.code)
+			}
+			if location := rec.Header().Get("Location"); location != c.location {
+				t.Errorf("got Location %q, want %q", location, c.location)
+			}
+		})
+	}
+}
+
+func TestExactPath(t *testing.T) {
+	m := mux.New(mux.RedirectLowercase(), mux.NormalizeUnicode())
+	m.HandleFunc("/Webhook/{Token}", handlerFactory(http.StatusTeapot, "webhook"), mux.ExactPath())
+	m.HandleFunc("/cafe\u0301", handlerFactory(http.StatusTeapot, "nfd"), mux.ExactPath())
+	m.HandleFunc("/About", handlerFactory(http.StatusTeapot, "about"))
+
+	cases := []struct {
+		path     string
+		code     int
+		body     string
+		location string
+	}{
+		{"/Webhook/AbC", http.StatusTeapot, "webhook", ""},
+		{"/webhook/abc", http.StatusNotFound, "", ""},
+		{"/cafe%CC%81", http.StatusTeapot, "nfd", ""},
+		{"/caf%C3%A9", http.StatusNotFound, "", ""},
+		{"/ABOUT", http.StatusPermanentRedirect, "", "/about"},
+	}
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, c.path, nil))
+			if rec.Code != c.code {
+				t.Errorf("got StatusCode %d, want %d", rec.Code, c.code)
+			}
+			if c.body != "" && rec.Body.String() != c.body {
+				t.Errorf("got body %q, want %q", rec.Body.String(), c.body)
+			}
+			if location := rec.Header().Get("Location"); location != c.location {
+				t.Errorf("got Location %q, want %q", location, c.location)
+			}
+		})
+	}
+
+	if !m.Deregister("/Webhook/{Token}") {
+		t.Error("got Deregister false for ExactPath route, want true")
+	}
+}
@@ -0,0 +1,74 @@
+package mux
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+)
+
+// clientBase is the URL relative request URLs of clients returned by Client
+// are resolved against.
+var clientBase = &url.URL{Scheme: "http", Host: "mux.local", Path: "/"}
+
+// Client returns an HTTP client that sends requests directly to mux, without
+// a network connection, which makes it convenient for tests. Relative request
+// URLs like "/a" are resolved against "http://mux.local"; the host of
+// absolute ones is passed to handlers as the request host. Redirects are
+// followed and cookies are kept in a cookie jar as with any other client.
+func (mux *Mux) Client() *http.Client {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		panic(err)
+	}
+	return &http.Client{
+		Transport: transport{mux},
+		Jar:       baseJar{jar},
+	}
+}
+
+// transport is an http.RoundTripper that serves requests with a handler.
+type transport struct {
+	handler http.Handler
+}
+
+func (t transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		defer req.Body.Close()
+	}
+
+	u := clientBase.ResolveReference(req.URL)
+	r := req.Clone(req.Context())
+	r.URL = &url.URL{Path: u.Path, RawPath: u.RawPath, RawQuery: u.RawQuery}
+	r.Host = u.Host
+	r.RequestURI = r.URL.RequestURI()
+	r.RemoteAddr = "192.0.2.1:1234"
+	r.Proto, r.ProtoMajor, r.ProtoMinor = "HTTP/1.1", 1, 1
+	if r.Body == nil {
+		r.Body = http.NoBody
+	}
+	if u.Scheme == "https" {
+		r.TLS = &tls.ConnectionState{HandshakeComplete: true, ServerName: u.Hostname()}
+	}
+
+	rec := httptest.NewRecorder()
+	t.handler.ServeHTTP(rec, r)
+
+	resp := rec.Result()
+	resp.Request = req
+	return resp, nil
+}
+
+// baseJar is a cookie jar that resolves relative URLs against clientBase.
+type baseJar struct {
+	jar http.CookieJar
+}
+
+func (j baseJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.jar.SetCookies(clientBase.ResolveReference(u), cookies)
+}
+
+func (j baseJar) Cookies(u *url.URL) []*http.Cookie {
+	return j.jar.Cookies(clientBase.ResolveReference(u))
+}
@@ -0,0 +1,124 @@
+package mux_test
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestClient(t *testing.T) {
+	newMux := func() *mux.Mux {
+		m := mux.New()
+		m.HandleFunc("/a", handlerFactory(http.StatusTeapot, "a"))
+		m.HandleFunc("/echo", func(w http.ResponseWriter, r *http.Request) {
+			b, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				panic(err)
+			}
+			io.WriteString(w, r.Method+" "+r.Host+" "+r.URL.String()+" "+string(b))
+		})
+		m.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "s1", Path: "/"})
+		})
+		m.HandleFunc("/me", func(w http.ResponseWriter, r *http.Request) {
+			c, err := r.Cookie("session")
+			if err != nil {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			io.WriteString(w, c.Value)
+		})
+
+		users := mux.New()
+		users.HandleFunc("/report", handlerFactory(http.StatusTeapot, "user report"))
+		m.Mount("/users", users)
+
+		return m
+	}
+
+	cases := []struct {
+		name   string
+		method string
+		url    string
+		body   string
+		code   int
+		want   string
+	}{
+		{"relative", http.MethodGet, "/a", "", http.StatusTeapot, "a"},
+		{"absolute", http.MethodGet, "http://mux.local/a", "", http.StatusTeapot, "a"},
+		{"redirect", http.MethodGet, "/a/", "", http.StatusTeapot, "a"},
+		{"mounted", http.MethodGet, "/users/report/", "", http.StatusTeapot, "user report"},
+		{"not found", http.MethodGet, "/b", "", http.StatusNotFound, "404 page not found\n"},
+		{"body", http.MethodPost, "/echo?q=1", "hello", http.StatusOK, "POST mux.local /echo?q=1 hello"},
+		{"host", http.MethodPut, "https://example.com/echo", "", http.StatusOK, "PUT example.com /echo "},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req, err := http.NewRequest(c.method, c.url, strings.NewReader(c.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := newMux().Client().Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != c.code {
+				t.Errorf("got StatusCode %d, want %d", resp.StatusCode, c.code)
+			}
+
+			b, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body := string(b)
+			if body != c.want {
+				t.Errorf("got body %q, want %q", body, c.want)
+			}
+		})
+	}
+
+	t.Run("cookies", func(t *testing.T) {
+		client := newMux().Client()
+
+		resp, err := client.Get("/me")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("got StatusCode %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+		}
+
+		resp, err = client.Get("/login")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		resp, err = client.Get("/me")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		b, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body := string(b)
+		if body != "s1" {
+			t.Errorf("got body %q, want s1", body)
+		}
+	})
+}
@@ -0,0 +1,328 @@
+package mux
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Compression configures the response compression set up by UseCompression
+// and Compressed.
+type Compression struct {
+	// Level is the compression level, from gzip.BestSpeed to
+	// gzip.BestCompression, or gzip.DefaultCompression if zero.
+	Level int
+
+	// MinSize is the size in bytes below which response bodies are sent
+	// uncompressed, as compressing them gains little. Responses flushed
+	// before reaching it are compressed anyway.
+	MinSize int
+
+	// ContentTypes are the media types of the responses compressed, like
+	// "application/json", and may contain "*" matching any string without
+	// "/", like "text/*" or "application/*+json". If empty, text types,
+	// JSON, JavaScript, XML and SVG are compressed.
+	ContentTypes []string
+}
+
+// defaultCompressedTypes are the media types compressed unless
+// Compression.ContentTypes is set.
+var defaultCompressedTypes = []string{
+	"text/*",
+	"application/json",
+	"application/*+json",
+	"application/javascript",
+	"application/xml",
+	"application/*+xml",
+	"image/svg+xml",
+}
+
+// UseCompression adds a middleware, as with Use, compressing the responses of
+// mux with gzip or deflate, as the Accept-Encoding header of the request
+// prefers, according to c. Responses already encoded, partial responses,
+// responses without a body and responses to HEAD requests are sent as they
+// are. The Vary header of responses includes Accept-Encoding and the ETag
+// header of compressed responses is made weak.
+//
+// Flushing a response sends the data compressed so far, so streamed
+// responses stay streamed, and hijacking the connection of a response not
+// written yet is passed through.
+//
+// Panics if Level is invalid.
+func (mux *Mux) UseCompression(c Compression) {
+	comp := newCompressor(c)
+	mux.Use(comp.wrap)
+}
+
+// Compressed compresses the responses of the route as UseCompression does.
+//
+// Panics if Level is invalid.
+func Compressed(c Compression) RouteOption {
+	comp := newCompressor(c)
+	return func(mux *Mux, e *muxEntry) {
+		e.handler = comp.wrap(e.handler).ServeHTTP
+	}
+}
+
+// compressor compresses responses according to its Compression.
+type compressor struct {
+	Compression
+	gzip sync.Pool // *gzip.Writer
+	zlib sync.Pool // *zlib.Writer
+}
+
+// newCompressor returns a compressor for c. Panics if Level is invalid.
+func newCompressor(c Compression) *compressor {
+	if c.Level == 0 {
+		c.Level = gzip.DefaultCompression
+	}
+	if _, err := gzip.NewWriterLevel(io.Discard, c.Level); err != nil {
+		panic("mux: invalid compression level " + strconv.Itoa(c.Level))
+	}
+	if len(c.ContentTypes) == 0 {
+		c.ContentTypes = defaultCompressedTypes
+	}
+	return &compressor{Compression: c}
+}
+
+// wrap returns a handler compressing the responses of next.
+func (c *compressor) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		addVary(w.Header(), "Accept-Encoding")
+		encoding := acceptedEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		cw := &compressWriter{ResponseWriter: WrapResponseWriter(w), c: c, encoding: encoding}
+		next.ServeHTTP(cw, r)
+		// Not deferred, so that a panic leaves a response not written yet
+		// to be answered by recovery.
+		cw.close()
+	})
+}
+
+// compressible reports whether responses of the content type are compressed.
+func (c *compressor) compressible(contentType string) bool {
+	mediatype, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, pattern := range c.ContentTypes {
+		if ok, _ := path.Match(pattern, mediatype); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// writer returns a writer compressing to w with the encoding.
+func (c *compressor) writer(encoding string, w io.Writer) io.WriteCloser {
+	if encoding == "gzip" {
+		if zw, ok := c.gzip.Get().(*gzip.Writer); ok {
+			zw.Reset(w)
+			return zw
+		}
+		zw, _ := gzip.NewWriterLevel(w, c.Level)
+		return zw
+	}
+	if zw, ok := c.zlib.Get().(*zlib.Writer); ok {
+		zw.Reset(w)
+		return zw
+	}
+	zw, _ := zlib.NewWriterLevel(w, c.Level)
+	return zw
+}
+
+// release returns the closed writer zw to its pool.
+func (c *compressor) release(zw io.WriteCloser) {
+	switch zw := zw.(type) {
+	case *gzip.Writer:
+		c.gzip.Put(zw)
+	case *zlib.Writer:
+		c.zlib.Put(zw)
+	}
+}
+
+// acceptedEncoding returns "gzip" or "deflate", whichever the Accept-Encoding
+// header prefers, gzip if both equally, or "" if it accepts neither.
+func acceptedEncoding(acceptEncoding string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+	gzipQ, deflateQ, anyQ := -1.0, -1.0, 0.0
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		fields := strings.Split(part, ";")
+		coding := strings.ToLower(strings.TrimSpace(fields[0]))
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if f, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = f
+				}
+			}
+		}
+		switch coding {
+		case "gzip", "x-gzip":
+			gzipQ = q
+		case "deflate":
+			deflateQ = q
+		case "*":
+			anyQ = q
+		}
+	}
+	if gzipQ < 0 {
+		gzipQ = anyQ
+	}
+	if deflateQ < 0 {
+		deflateQ = anyQ
+	}
+	switch {
+	case gzipQ > 0 && gzipQ >= deflateQ:
+		return "gzip"
+	case deflateQ > 0:
+		return "deflate"
+	}
+	return ""
+}
+
+// compressWriter compresses the response written to it, once it knows the
+// response is to be compressed. Until then it buffers the body.
+type compressWriter struct {
+	ResponseWriter
+	c        *compressor
+	encoding string // content coding of compressed responses
+	code     int    // status code written, 0 if none
+	buf      []byte // body written before deciding
+	decided  bool   // whether the header was written
+	zw       io.WriteCloser
+}
+
+func (cw *compressWriter) WriteHeader(code int) {
+	if code < http.StatusOK && code != http.StatusSwitchingProtocols {
+		// Informational responses precede the response.
+		cw.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if cw.decided {
+		cw.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if cw.code == 0 {
+		cw.code = code
+	}
+}
+
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if cw.decided {
+		return cw.write(b)
+	}
+	cw.buf = append(cw.buf, b...)
+	if len(cw.buf) >= cw.c.MinSize {
+		if err := cw.decide(false); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// ReadFrom copies from r with Write, so that the body is compressed.
+func (cw *compressWriter) ReadFrom(r io.Reader) (int64, error) {
+	return io.Copy(writerOnly{cw}, r)
+}
+
+// write writes b to the compressing writer, if the response is compressed,
+// and otherwise to the ResponseWriter.
+func (cw *compressWriter) write(b []byte) (int, error) {
+	if cw.zw != nil {
+		return cw.zw.Write(b)
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+// decide decides whether to compress the response, writes the header and the
+// body buffered so far. If final, the body is complete.
+func (cw *compressWriter) decide(final bool) error {
+	cw.decided = true
+	if cw.code == 0 {
+		cw.code = http.StatusOK
+	}
+	h := cw.Header()
+	if h.Get("Content-Type") == "" && len(cw.buf) > 0 {
+		h.Set("Content-Type", http.DetectContentType(cw.buf))
+	}
+
+	compress := h.Get("Content-Encoding") == "" &&
+		cw.code != http.StatusNoContent && cw.code != http.StatusNotModified &&
+		cw.code != http.StatusPartialContent && cw.code != http.StatusSwitchingProtocols &&
+		!(final && len(cw.buf) < cw.c.MinSize) && !(final && len(cw.buf) == 0) &&
+		cw.c.compressible(h.Get("Content-Type"))
+	if compress {
+		h.Set("Content-Encoding", cw.encoding)
+		h.Del("Content-Length")
+		if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			h.Set("ETag", "W/"+etag)
+		}
+		cw.zw = cw.c.writer(cw.encoding, cw.ResponseWriter)
+	}
+	cw.ResponseWriter.WriteHeader(cw.code)
+
+	buf := cw.buf
+	cw.buf = nil
+	if len(buf) > 0 {
+		if _, err := cw.write(buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// close writes what remains of the response.
+func (cw *compressWriter) close() {
+	if !cw.decided {
+		cw.decide(true)
+	}
+	if cw.zw != nil {
+		cw.zw.Close()
+		cw.c.release(cw.zw)
+		cw.zw = nil
+	}
+}
+
+// Flush sends the response compressed so far, if the underlying
+// ResponseWriter is an http.Flusher.
+func (cw *compressWriter) Flush() {
+	if _, ok := cw.Unwrap().(http.Flusher); !ok {
+		return
+	}
+	if !cw.decided {
+		cw.decide(false)
+	}
+	if zf, ok := cw.zw.(interface{ Flush() error }); ok {
+		zf.Flush()
+	}
+	cw.ResponseWriter.Flush()
+}
+
+// Hijack hijacks the connection of the underlying ResponseWriter if it is an
+// http.Hijacker and the response has not been written.
+func (cw *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if cw.decided {
+		return nil, nil, errors.New("mux: hijack of a response already written")
+	}
+	conn, rw, err := cw.ResponseWriter.Hijack()
+	if err == nil {
+		cw.decided = true
+	}
+	return conn, rw, err
+}
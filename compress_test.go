@@ -0,0 +1,213 @@
+package mux_test
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestCompression(t *testing.T) {
+	long := strings.Repeat("compress me ", 200)
+	text := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			w.Header().Set("Content-Length", "999")
+			w.Header().Set("ETag", `"v1"`)
+			io.WriteString(w, body)
+		}
+	}
+
+	m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found")))
+	m.UseCompression(mux.Compression{MinSize: 100})
+	m.Get("/long", text(long))
+	m.Get("/short", text("short"))
+	m.Get("/sniffed", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "<html>"+long+"</html>")
+	})
+	m.Get("/png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		io.WriteString(w, long)
+	})
+	m.Get("/encoded", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Content-Encoding", "br")
+		io.WriteString(w, long)
+	})
+	m.Get("/created", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, "[")
+		io.WriteString(w, strings.Repeat(`"item",`, 100))
+		io.WriteString(w, `"item"]`)
+	})
+	m.Get("/empty", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	cases := []struct {
+		method         string
+		path           string
+		acceptEncoding string
+		code           int
+		encoding       string
+		body           string
+	}{
+		{http.MethodGet, "/long", "gzip, deflate", http.StatusOK, "gzip", long},
+		{http.MethodGet, "/long", "deflate, gzip;q=0.5", http.StatusOK, "deflate", long},
+		{http.MethodGet, "/long", "*", http.StatusOK, "gzip", long},
+		{http.MethodGet, "/long", "gzip;q=0, *", http.StatusOK, "deflate", long},
+		{http.MethodGet, "/long", "br", http.StatusOK, "", long},
+		{http.MethodGet, "/long", "", http.StatusOK, "", long},
+		{http.MethodHead, "/long", "gzip", http.StatusOK, "", ""},
+		{http.MethodGet, "/short", "gzip", http.StatusOK, "", "short"},
+		{http.MethodGet, "/sniffed", "gzip", http.StatusOK, "gzip", "<html>" + long + "</html>"},
+		{http.MethodGet, "/png", "gzip", http.StatusOK, "", long},
+		{http.MethodGet, "/encoded", "gzip", http.StatusOK, "br", long},
+		{http.MethodGet, "/created", "gzip", http.StatusCreated, "gzip", "[" + strings.Repeat(`"item",`, 100) + `"item"]`},
+		{http.MethodGet, "/empty", "gzip", http.StatusNoContent, "", ""},
+		{http.MethodGet, "/missing", "gzip", http.StatusNotFound, "", "not found"},
+	}
+	for _, c := range cases {
+		t.Run(c.method+" "+c.path+" "+c.acceptEncoding, func(t *testing.T) {
+			r := httptest.NewRequest(c.method, c.path, nil)
+			r.Header.Set("Accept-Encoding", c.acceptEncoding)
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+			if rec.Code != c.code {
+				t.Errorf("got StatusCode %d, want %d", rec.Code, c.code)
+			}
+			h := rec.Header()
+			if got := h.Get("Content-Encoding"); got != c.encoding {
+				t.Errorf("got Content-Encoding %q, want %q", got, c.encoding)
+			}
+			if got := h.Get("Vary"); got != "Accept-Encoding" {
+				t.Errorf("got Vary %q, want %q", got, "Accept-Encoding")
+			}
+			if body := decode(t, c.encoding, rec.Body); body != c.body {
+				t.Errorf("got body %q, want %q", body, c.body)
+			}
+			if c.encoding == "gzip" && c.path == "/long" {
+				if got := h.Get("Content-Length"); got != "" {
+					t.Errorf("got Content-Length %q, want none", got)
+				}
+				if got := h.Get("ETag"); got != `W/"v1"` {
+					t.Errorf("got ETag %q, want %q", got, `W/"v1"`)
+				}
+			}
+		})
+	}
+
+	t.Run("Compressed", func(t *testing.T) {
+		m := mux.New()
+		m.Get("/long", text(long), mux.Compressed(mux.Compression{ContentTypes: []string{"text/plain"}}))
+		m.Get("/plain", text(long))
+		for path, encoding := range map[string]string{"/long": "gzip", "/plain": ""} {
+			r := httptest.NewRequest(http.MethodGet, path, nil)
+			r.Header.Set("Accept-Encoding", "gzip")
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+			if got := rec.Header().Get("Content-Encoding"); got != encoding {
+				t.Errorf("%s: got Content-Encoding %q, want %q", path, got, encoding)
+			}
+			if body := decode(t, encoding, rec.Body); body != long {
+				t.Errorf("%s: got body %q, want %q", path, body, long)
+			}
+		}
+	})
+
+	t.Run("Flush", func(t *testing.T) {
+		m := mux.New()
+		m.UseCompression(mux.Compression{MinSize: 1 << 20})
+		m.Get("/events", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			io.WriteString(w, "data: 1\n\n")
+			w.(http.Flusher).Flush()
+			io.WriteString(w, "data: 2\n\n")
+		})
+		r := httptest.NewRequest(http.MethodGet, "/events", nil)
+		r.Header.Set("Accept-Encoding", "gzip")
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, r)
+		if !rec.Flushed {
+			t.Error("got no flush, want flush")
+		}
+		if got := rec.Header().Get("Content-Encoding"); got != "gzip" {
+			t.Errorf("got Content-Encoding %q, want %q", got, "gzip")
+		}
+		if body := decode(t, "gzip", rec.Body); body != "data: 1\n\ndata: 2\n\n" {
+			t.Errorf("got body %q, want %q", body, "data: 1\n\ndata: 2\n\n")
+		}
+	})
+
+	t.Run("Hijack", func(t *testing.T) {
+		m := mux.New()
+		m.UseCompression(mux.Compression{})
+		m.Get("/raw", func(w http.ResponseWriter, r *http.Request) {
+			conn, buf, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer conn.Close()
+			buf.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 3\r\nConnection: close\r\n\r\nraw")
+			buf.Flush()
+		})
+		srv := httptest.NewServer(m)
+		defer srv.Close()
+
+		conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		io.WriteString(conn, "GET /raw HTTP/1.1\r\nHost: example.com\r\nAccept-Encoding: gzip\r\n\r\n")
+		resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != "raw" {
+			t.Errorf("got body %q, want %q", b, "raw")
+		}
+	})
+
+	t.Run("invalid level", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("got no panic, want panic")
+			}
+		}()
+		mux.Compressed(mux.Compression{Level: 10})
+	})
+}
+
+// decode returns the body r encoded with the content coding encoding.
+func decode(t *testing.T, encoding string, r io.Reader) string {
+	t.Helper()
+	var err error
+	switch encoding {
+	case "gzip":
+		r, err = gzip.NewReader(r)
+	case "deflate":
+		r, err = zlib.NewReader(r)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
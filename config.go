@@ -0,0 +1,135 @@
+package mux
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Config describes the routes of a mux declaratively, so that they can be
+// defined in a file by operators rather than in code. Handlers and middleware
+// are named by identifiers resolved through a Registry. It decodes from JSON
+// with ParseConfig and, as its fields have yaml tags, from YAML with a YAML
+// package like gopkg.in/yaml.v3:
+//
+//	middleware: [logRequests]
+//	routes:
+//	  - pattern: /users/{id}
+//	    methods: [GET]
+//	    handler: getUser
+//	    middleware: [requireUser]
+//	    tags: [users]
+type Config struct {
+	// Middleware are the identifiers of the middleware added with Use,
+	// outermost first.
+	Middleware []string `json:"middleware,omitempty" yaml:"middleware,omitempty"`
+
+	Routes []RouteConfig `json:"routes" yaml:"routes"`
+}
+
+// RouteConfig describes a route of a Config.
+type RouteConfig struct {
+	Pattern string `json:"pattern" yaml:"pattern"`
+	Regexp  bool   `json:"regexp,omitempty" yaml:"regexp,omitempty"` // whether Pattern is a regular expression
+
+	// Methods are the methods the handler is registered for or, if empty,
+	// all methods.
+	Methods []string `json:"methods,omitempty" yaml:"methods,omitempty"`
+
+	Handler string `json:"handler" yaml:"handler"` // identifier of the handler
+
+	// Middleware are the identifiers of the middleware added with
+	// WithMiddleware, outermost first.
+	Middleware []string `json:"middleware,omitempty" yaml:"middleware,omitempty"`
+
+	Name string                 `json:"name,omitempty" yaml:"name,omitempty"` // set with Name
+	Tags []string               `json:"tags,omitempty" yaml:"tags,omitempty"` // set with Tags
+	Meta map[string]interface{} `json:"meta,omitempty" yaml:"meta,omitempty"` // set with Meta
+}
+
+// Registry resolves the identifiers of handlers and middleware of a Config.
+type Registry struct {
+	Handlers   map[string]http.Handler
+	Middleware map[string]func(http.Handler) http.Handler
+}
+
+// ParseConfig decodes the JSON configuration read from r. Unknown fields are
+// an error, so that misspelled ones are not silently ignored.
+func ParseConfig(r io.Reader) (Config, error) {
+	var c Config
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c); err != nil {
+		return Config{}, fmt.Errorf("mux: parse config: %v", err)
+	}
+	return c, nil
+}
+
+// NewFromConfig returns a new mux with the options opts, as returned by New
+// with a nil notFound, and the middleware and routes described by c, in
+// order. Use Swap to put the routes into service on a mux already serving.
+//
+// NewFromConfig returns an error if an identifier is not in reg or a route
+// can not be registered, such as because its pattern is malformed or
+// registered twice.
+func NewFromConfig(c Config, reg Registry, opts ...Option) (*Mux, error) {
+	mux := New(opts...)
+
+	for _, id := range c.Middleware {
+		mw, ok := reg.Middleware[id]
+		if !ok || mw == nil {
+			return nil, fmt.Errorf("mux: config: unknown middleware %q", id)
+		}
+		mux.Use(mw)
+	}
+
+	for i, rc := range c.Routes {
+		if err := mux.addConfigRoute(rc, reg); err != nil {
+			return nil, fmt.Errorf("mux: config: route %d (%s): %v", i, rc.Pattern, err)
+		}
+	}
+	return mux, nil
+}
+
+// addConfigRoute registers the route described by rc.
+func (mux *Mux) addConfigRoute(rc RouteConfig, reg Registry) error {
+	h, ok := reg.Handlers[rc.Handler]
+	if !ok || h == nil {
+		return fmt.Errorf("unknown handler %q", rc.Handler)
+	}
+
+	var opts []RouteOption
+	for _, id := range rc.Middleware {
+		mw, ok := reg.Middleware[id]
+		if !ok || mw == nil {
+			return fmt.Errorf("unknown middleware %q", id)
+		}
+		opts = append(opts, WithMiddleware(mw))
+	}
+	if len(rc.Tags) > 0 {
+		opts = append(opts, Tags(rc.Tags...))
+	}
+	for key, value := range rc.Meta {
+		opts = append(opts, Meta(key, value))
+	}
+
+	methods := rc.Methods
+	if len(methods) == 0 {
+		methods = []string{""}
+	}
+	for i, method := range methods {
+		opts := opts
+		if i == 0 && rc.Name != "" {
+			// A name is registered once for the pattern.
+			opts = append(opts[:len(opts):len(opts)], Name(rc.Name))
+		}
+		err := try(func() {
+			mux.register(method, rc.Pattern, muxEntry{handler: h.ServeHTTP, regexp: rc.Regexp}, opts...)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package mux_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+// configRegistry returns a registry with the handlers "a" and "b" and the
+// middleware "header" setting X-Middleware.
+func configRegistry() mux.Registry {
+	return mux.Registry{
+		Handlers: map[string]http.Handler{
+			"a": handlerFactory(http.StatusTeapot, "a"),
+			"b": handlerFactory(http.StatusTeapot, "b"),
+		},
+		Middleware: map[string]func(http.Handler) http.Handler{
+			"header": func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("X-Middleware", "header")
+					next.ServeHTTP(w, r)
+				})
+			},
+		},
+	}
+}
+
+func TestNewFromConfig(t *testing.T) {
+	c, err := mux.ParseConfig(strings.NewReader(`{
+		"routes": [
+			{"pattern": "/a", "handler": "a", "name": "a", "tags": ["letters"]},
+			{"pattern": "/b/{id}", "methods": ["GET", "POST"], "handler": "b", "middleware": ["header"], "name": "b"},
+			{"pattern": "^/c[0-9]$", "regexp": true, "methods": ["GET"], "handler": "a", "meta": {"scope": "admin"}}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := mux.NewFromConfig(c, configRegistry())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		method     string
+		path       string
+		code       int
+		body       string
+		middleware string
+	}{
+		{http.MethodGet, "/a", http.StatusTeapot, "a", ""},
+		{http.MethodPost, "/b/1", http.StatusTeapot, "b", "header"},
+		{http.MethodDelete, "/b/1", http.StatusMethodNotAllowed, "", ""},
+		{http.MethodGet, "/c1", http.StatusTeapot, "a", ""},
+		{http.MethodPost, "/c1", http.StatusMethodNotAllowed, "", ""},
+	}
+	for _, c := range cases {
+		t.Run(c.method+" "+c.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, httptest.NewRequest(c.method, c.path, nil))
+			if rec.Code != c.code {
+				t.Errorf("got StatusCode %d, want %d", rec.Code, c.code)
+			}
+			if c.body != "" && rec.Body.String() != c.body {
+				t.Errorf("got body %q, want %q", rec.Body.String(), c.body)
+			}
+			if got := rec.Header().Get("X-Middleware"); got != c.middleware {
+				t.Errorf("got X-Middleware %q, want %q", got, c.middleware)
+			}
+		})
+	}
+
+	if u, err := m.URL("b", "id", "2"); err != nil || u != "/b/2" {
+		t.Errorf("got URL %q, %v, want %q", u, err, "/b/2")
+	}
+	if rt, _, _ := m.Match(http.MethodGet, "/c2"); rt.Meta["scope"] != "admin" {
+		t.Errorf("got Meta %v, want scope admin", rt.Meta)
+	}
+}
+
+func TestNewFromConfigErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		config string
+		err    string
+	}{
+		{"unknown field", `{"routes": [{"path": "/a", "handler": "a"}]}`, "unknown field"},
+		{"unknown handler", `{"routes": [{"pattern": "/a", "handler": "c"}]}`, `unknown handler "c"`},
+		{"unknown middleware", `{"middleware": ["gzip"], "routes": []}`, `unknown middleware "gzip"`},
+		{"unknown route middleware", `{"routes": [{"pattern": "/a", "handler": "a", "middleware": ["gzip"]}]}`, `unknown middleware "gzip"`},
+		{"malformed pattern", `{"routes": [{"pattern": "a", "handler": "a"}]}`, "must begin with"},
+		{"invalid regexp", `{"routes": [{"pattern": "(", "regexp": true, "handler": "a"}]}`, "regexp: "},
+		{"duplicate", `{"routes": [{"pattern": "/a", "handler": "a"}, {"pattern": "/a", "handler": "b"}]}`, "route 1 (/a): mux: multiple registrations"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			config, err := mux.ParseConfig(strings.NewReader(c.config))
+			if err == nil {
+				_, err = mux.NewFromConfig(config, configRegistry())
+			}
+			if err == nil || !strings.Contains(err.Error(), c.err) {
+				t.Errorf("got error %v, want error containing %q", err, c.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package mux
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// RequireContentType makes the route accept only requests whose Content-Type
+// is one of the given media types. Parameters such as charset are ignored.
+// Requests without a body and without a Content-Type are accepted.
+//
+// Other requests are rejected before the handler runs: the accepted types are
+// listed in the Accept-Patch header for PATCH requests and in the Accept-Post
+// header otherwise, and the mux's unsupported media type handler is called.
+//
+// Panics if no types are given or a type is not a valid media type.
+func RequireContentType(types ...string) RouteOption {
+	if len(types) == 0 {
+		panic("mux: no content types")
+	}
+	accepted := make([]string, len(types))
+	for i, t := range types {
+		mediatype, _, err := mime.ParseMediaType(t)
+		if err != nil || !strings.Contains(mediatype, "/") {
+			panic("mux: invalid content type " + t)
+		}
+		accepted[i] = mediatype
+	}
+	list := strings.Join(accepted, ", ")
+
+	return func(mux *Mux, e *muxEntry) {
+		next := e.handler
+		e.handler = func(w http.ResponseWriter, r *http.Request) {
+			if acceptsContentType(r, accepted) {
+				next(w, r)
+				return
+			}
+
+			if r.Method == http.MethodPatch {
+				w.Header().Set("Accept-Patch", list)
+			} else {
+				w.Header().Set("Accept-Post", list)
+			}
+			mux.serveUnsupportedMediaType(w, r)
+		}
+	}
+}
+
+// acceptsContentType determines whether the Content-Type of r is one of the
+// accepted media types.
+func acceptsContentType(r *http.Request, accepted []string) bool {
+	ct := r.Header.Get("Content-Type")
+	if ct == "" {
+		return r.ContentLength == 0
+	}
+
+	mediatype, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return false
+	}
+	for _, t := range accepted {
+		if mediatype == t {
+			return true
+		}
+	}
+	return false
+}
+
+// UnsupportedMediaType sets the handler called when a request is rejected by
+// RequireContentType. The default handler replies with 415 Unsupported Media
+// Type.
+func (mux *Mux) UnsupportedMediaType(handler http.HandlerFunc) {
+	mux.lock()
+	defer mux.unlock()
+
+	mux.unsupportedMediaType = handler
+}
+
+// serveUnsupportedMediaType calls the unsupported media type handler.
+func (mux *Mux) serveUnsupportedMediaType(w http.ResponseWriter, r *http.Request) {
+	h := mux.load().unsupportedMediaType
+
+	if h == nil {
+		h = unsupportedMediaType
+	}
+	h(w, r)
+}
+
+// unsupportedMediaType replies with 415 Unsupported Media Type, listing the
+// accepted media types.
+func unsupportedMediaType(w http.ResponseWriter, r *http.Request) {
+	msg := http.StatusText(http.StatusUnsupportedMediaType)
+	if accepted := w.Header().Get("Accept-Post") + w.Header().Get("Accept-Patch"); accepted != "" {
+		msg += ", accepted: " + accepted
+	}
+	http.Error(w, msg, http.StatusUnsupportedMediaType)
+}
@@ -0,0 +1,134 @@
+package mux_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestRequireContentType(t *testing.T) {
+	newMux := func() *mux.Mux {
+		m := mux.New()
+		m.HandleFunc("/a", handlerFactory(http.StatusTeapot, "a"), mux.RequireContentType("application/json", "text/csv"))
+		return m
+	}
+
+	t.Run("green", func(t *testing.T) {
+		cases := []struct {
+			name        string
+			method      string
+			contentType string
+			body        string
+		}{
+			{"json", http.MethodPost, "application/json", "{}"},
+			{"charset", http.MethodPost, "application/json; charset=utf-8", "{}"},
+			{"case", http.MethodPost, "Application/JSON", "{}"},
+			{"second", http.MethodPut, "text/csv", "a,b"},
+			{"GET without body", http.MethodGet, "", ""},
+			{"DELETE without body", http.MethodDelete, "", ""},
+		}
+
+		for _, c := range cases {
+			t.Run(c.name, func(t *testing.T) {
+				r := httptest.NewRequest(c.method, "/a", strings.NewReader(c.body))
+				if c.contentType != "" {
+					r.Header.Set("Content-Type", c.contentType)
+				}
+				rec := httptest.NewRecorder()
+				newMux().ServeHTTP(rec, r)
+				resp := rec.Result()
+
+				if resp.StatusCode != http.StatusTeapot {
+					t.Errorf("got StatusCode %d, want %d", resp.StatusCode, http.StatusTeapot)
+				}
+			})
+		}
+	})
+
+	t.Run("red", func(t *testing.T) {
+		cases := []struct {
+			name        string
+			method      string
+			contentType string
+			body        string
+			header      string
+		}{
+			{"xml", http.MethodPost, "application/xml", "<a/>", "Accept-Post"},
+			{"invalid", http.MethodPost, "application/", "{}", "Accept-Post"},
+			{"missing with body", http.MethodPost, "", "{}", "Accept-Post"},
+			{"patch", http.MethodPatch, "text/plain", "a", "Accept-Patch"},
+		}
+
+		for _, c := range cases {
+			t.Run(c.name, func(t *testing.T) {
+				r := httptest.NewRequest(c.method, "/a", strings.NewReader(c.body))
+				if c.contentType != "" {
+					r.Header.Set("Content-Type", c.contentType)
+				}
+				rec := httptest.NewRecorder()
+				newMux().ServeHTTP(rec, r)
+				resp := rec.Result()
+
+				if resp.StatusCode != http.StatusUnsupportedMediaType {
+					t.Errorf("got StatusCode %d, want %d", resp.StatusCode, http.StatusUnsupportedMediaType)
+				}
+
+				accepted := resp.Header.Get(c.header)
+				if accepted != "application/json, text/csv" {
+					t.Errorf("got %s %q, want %q", c.header, accepted, "application/json, text/csv")
+				}
+
+				b, err := ioutil.ReadAll(resp.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				body := string(b)
+				if !strings.Contains(body, "application/json, text/csv") {
+					t.Errorf("got body %q, want accepted types listed", body)
+				}
+			})
+		}
+	})
+
+	t.Run("handler", func(t *testing.T) {
+		m := newMux()
+		m.UnsupportedMediaType(handlerFactory(http.StatusBadRequest, `{"error":"unsupported media type"}`))
+
+		r := httptest.NewRequest(http.MethodPost, "/a", strings.NewReader("a"))
+		r.Header.Set("Content-Type", "text/plain")
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, r)
+		resp := rec.Result()
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("got StatusCode %d, want %d", resp.StatusCode, http.StatusBadRequest)
+		}
+
+		b, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body := string(b)
+		if body != `{"error":"unsupported media type"}` {
+			t.Errorf("got body %q, want %q", body, `{"error":"unsupported media type"}`)
+		}
+	})
+
+	for _, typ := range []string{"application/", "json", "/json"} {
+		t.Run("invalid option", func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%q: got no panic, want panic", typ)
+				}
+			}()
+
+			mux.RequireContentType(typ)
+		})
+	}
+}
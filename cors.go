@@ -0,0 +1,208 @@
+package mux
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORS configures the cross-origin resource sharing set up by UseCORS.
+type CORS struct {
+	// AllowedOrigins are the origins allowed to make cross-origin requests,
+	// like "https://example.com". An origin may contain one "*" matching
+	// any string, like "https://*.example.com", and "*" allows all origins.
+	AllowedOrigins []string
+
+	// AllowedMethods, if not empty, restricts the methods allowed in
+	// preflight requests to these, in addition to those having to be
+	// routed by mux.
+	AllowedMethods []string
+
+	// AllowedHeaders are the request headers allowed in addition to the
+	// CORS-safelisted ones. "*" allows all headers.
+	AllowedHeaders []string
+
+	// ExposedHeaders are the response headers scripts may read in addition
+	// to the CORS-safelisted ones.
+	ExposedHeaders []string
+
+	// AllowCredentials allows requests with cookies or HTTP authentication.
+	AllowCredentials bool
+
+	// MaxAge is how long the result of a preflight request may be cached,
+	// unless zero.
+	MaxAge time.Duration
+}
+
+// UseCORS adds a middleware, as with Use, answering cross-origin requests
+// from the allowed origins according to c.
+//
+// Preflight requests are answered by the middleware. The methods they allow
+// are taken from the routes of mux: a method is allowed for a path if mux
+// routes a request with the method and path to a handler, and the
+// Access-Control-Allow-Methods header lists the methods registered for the
+// path, like the Allow header of OPTIONS requests. Preflight requests for
+// paths mux routes to no handler are passed on and so answered by notFound.
+//
+// Requests from origins that are not allowed are passed on without CORS
+// headers, so browsers do not let scripts read the response.
+//
+// Panics if AllowCredentials is set while AllowedOrigins contains "*".
+func (mux *Mux) UseCORS(c CORS) {
+	for _, origin := range c.AllowedOrigins {
+		if origin == "*" && c.AllowCredentials {
+			panic("mux: CORS with credentials allowed for all origins")
+		}
+	}
+	mux.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Add("Vary", "Origin")
+			origin := r.Header.Get("Origin")
+			if origin == "" || !c.allowsOrigin(origin) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			method := r.Header.Get("Access-Control-Request-Method")
+			if r.Method != http.MethodOptions || method == "" {
+				c.setOrigin(h, origin)
+				if len(c.ExposedHeaders) > 0 {
+					h.Set("Access-Control-Expose-Headers", strings.Join(c.ExposedHeaders, ", "))
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			allow, ok := mux.corsMethods(r, method)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+			headers := r.Header.Get("Access-Control-Request-Headers")
+			allow = c.allowedMethods(allow)
+			if allow != "" && c.allowsMethod(method) && c.allowsHeaders(headers) {
+				c.setOrigin(h, origin)
+				h.Set("Access-Control-Allow-Methods", allow)
+				if headers != "" {
+					h.Set("Access-Control-Allow-Headers", headers)
+				}
+				if c.MaxAge > 0 {
+					h.Set("Access-Control-Max-Age", strconv.Itoa(int(c.MaxAge/time.Second)))
+				}
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	})
+}
+
+// corsMethods returns the methods allowed for the path of the preflight
+// request r, or "" if method is not one of them, and reports whether mux
+// routes the path to a handler.
+func (mux *Mux) corsMethods(r *http.Request, method string) (string, bool) {
+	r2 := r.Clone(r.Context())
+	r2.Method = method
+	switch ex := innermost(mux.Explain(r2)); ex.Outcome {
+	case OutcomeNotFound, OutcomeRedirected:
+		return "", false
+	case OutcomeMethodNotAllowed:
+		return "", true
+	}
+	if ex := innermost(mux.Explain(r)); ex.Outcome == OutcomeOptions {
+		return ex.Allow, true
+	}
+	// The handler accepts any method.
+	return method, true
+}
+
+// innermost returns the explanation of the innermost mux routing the request
+// ex explains.
+func innermost(ex Explanation) Explanation {
+	for ex.Inner != nil {
+		ex = *ex.Inner
+	}
+	return ex
+}
+
+// allowsOrigin reports whether c allows requests from origin.
+func (c CORS) allowsOrigin(origin string) bool {
+	for _, o := range c.AllowedOrigins {
+		if o == "*" || strings.EqualFold(o, origin) {
+			return true
+		}
+		if i := strings.IndexByte(o, '*'); i >= 0 {
+			prefix, suffix := o[:i], o[i+1:]
+			if len(origin) >= len(prefix)+len(suffix) &&
+				strings.EqualFold(origin[:len(prefix)], prefix) &&
+				strings.EqualFold(origin[len(origin)-len(suffix):], suffix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// allowsMethod reports whether c allows the method in preflight requests.
+func (c CORS) allowsMethod(method string) bool {
+	if len(c.AllowedMethods) == 0 {
+		return true
+	}
+	for _, m := range c.AllowedMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// allowedMethods returns the methods of the comma-separated list allow that c
+// allows.
+func (c CORS) allowedMethods(allow string) string {
+	if len(c.AllowedMethods) == 0 || allow == "" {
+		return allow
+	}
+	var methods []string
+	for _, method := range strings.Split(allow, ", ") {
+		if c.allowsMethod(method) {
+			methods = append(methods, method)
+		}
+	}
+	return strings.Join(methods, ", ")
+}
+
+// allowsHeaders reports whether c allows all headers of the comma-separated
+// list headers.
+func (c CORS) allowsHeaders(headers string) bool {
+	if headers == "" {
+		return true
+	}
+	for _, header := range strings.Split(headers, ",") {
+		header = strings.TrimSpace(header)
+		var ok bool
+		for _, h := range c.AllowedHeaders {
+			if h == "*" || strings.EqualFold(h, header) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// setOrigin sets the headers allowing the origin.
+func (c CORS) setOrigin(h http.Header, origin string) {
+	if len(c.AllowedOrigins) == 1 && c.AllowedOrigins[0] == "*" {
+		h.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		h.Set("Access-Control-Allow-Origin", origin)
+	}
+	if c.AllowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
@@ -0,0 +1,167 @@
+package mux_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestUseCORS(t *testing.T) {
+	m := mux.New()
+	m.UseCORS(mux.CORS{
+		AllowedOrigins:   []string{"https://example.com", "https://*.example.org"},
+		AllowedHeaders:   []string{"Content-Type"},
+		ExposedHeaders:   []string{"X-Total"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	})
+	m.Get("/users/{id}", handlerFactory(http.StatusTeapot, ""))
+	m.Put("/users/{id}", handlerFactory(http.StatusTeapot, ""))
+	m.HandleFunc("/any", handlerFactory(http.StatusTeapot, ""))
+
+	type headers map[string]string
+	cases := []struct {
+		name       string
+		method     string
+		path       string
+		header     headers
+		statusCode int
+		want       headers
+	}{
+		{
+			"simple",
+			http.MethodGet,
+			"/users/1",
+			headers{"Origin": "https://example.com"},
+			http.StatusTeapot,
+			headers{
+				"Access-Control-Allow-Origin":      "https://example.com",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Expose-Headers":    "X-Total",
+				"Vary":                             "Origin",
+			},
+		},
+		{
+			"no origin",
+			http.MethodGet,
+			"/users/1",
+			nil,
+			http.StatusTeapot,
+			headers{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			"origin not allowed",
+			http.MethodGet,
+			"/users/1",
+			headers{"Origin": "https://example.net"},
+			http.StatusTeapot,
+			headers{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			"preflight",
+			http.MethodOptions,
+			"/users/1",
+			headers{
+				"Origin":                         "https://api.example.org",
+				"Access-Control-Request-Method":  "PUT",
+				"Access-Control-Request-Headers": "content-type",
+			},
+			http.StatusNoContent,
+			headers{
+				"Access-Control-Allow-Origin":  "https://api.example.org",
+				"Access-Control-Allow-Methods": "GET, HEAD, OPTIONS, PUT",
+				"Access-Control-Allow-Headers": "content-type",
+				"Access-Control-Max-Age":       "600",
+			},
+		},
+		{
+			"preflight method not allowed",
+			http.MethodOptions,
+			"/users/1",
+			headers{"Origin": "https://example.com", "Access-Control-Request-Method": "DELETE"},
+			http.StatusNoContent,
+			headers{"Access-Control-Allow-Origin": "", "Access-Control-Allow-Methods": ""},
+		},
+		{
+			"preflight header not allowed",
+			http.MethodOptions,
+			"/users/1",
+			headers{
+				"Origin":                         "https://example.com",
+				"Access-Control-Request-Method":  "PUT",
+				"Access-Control-Request-Headers": "X-Secret",
+			},
+			http.StatusNoContent,
+			headers{"Access-Control-Allow-Origin": "", "Access-Control-Allow-Methods": ""},
+		},
+		{
+			"preflight any method",
+			http.MethodOptions,
+			"/any",
+			headers{"Origin": "https://example.com", "Access-Control-Request-Method": "DELETE"},
+			http.StatusNoContent,
+			headers{"Access-Control-Allow-Origin": "https://example.com", "Access-Control-Allow-Methods": "DELETE"},
+		},
+		{
+			"preflight not found",
+			http.MethodOptions,
+			"/missing",
+			headers{"Origin": "https://example.com", "Access-Control-Request-Method": "GET"},
+			http.StatusNotFound,
+			headers{"Access-Control-Allow-Origin": ""},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := httptest.NewRequest(c.method, c.path, nil)
+			for name, value := range c.header {
+				r.Header.Set(name, value)
+			}
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+			resp := rec.Result()
+
+			if resp.StatusCode != c.statusCode {
+				t.Errorf("got StatusCode %d, want %d", resp.StatusCode, c.statusCode)
+			}
+			for name, want := range c.want {
+				if got := resp.Header.Get(name); got != want {
+					t.Errorf("got %s %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+
+	t.Run("AllowedMethods", func(t *testing.T) {
+		m := mux.New()
+		m.UseCORS(mux.CORS{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"GET", "PUT"}})
+		m.Get("/a", handlerFactory(http.StatusTeapot, ""))
+		m.Put("/a", handlerFactory(http.StatusTeapot, ""))
+		m.Delete("/a", handlerFactory(http.StatusTeapot, ""))
+
+		for method, want := range map[string]string{"PUT": "GET, PUT", "DELETE": ""} {
+			r := httptest.NewRequest(http.MethodOptions, "/a", nil)
+			r.Header.Set("Origin", "https://example.com")
+			r.Header.Set("Access-Control-Request-Method", method)
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+
+			if got := rec.Header().Get("Access-Control-Allow-Methods"); got != want {
+				t.Errorf("%s: got Access-Control-Allow-Methods %q, want %q", method, got, want)
+			}
+		}
+	})
+
+	t.Run("red", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("got no panic, want panic")
+			}
+		}()
+
+		mux.New().UseCORS(mux.CORS{AllowedOrigins: []string{"*"}, AllowCredentials: true})
+	})
+}
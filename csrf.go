@@ -0,0 +1,164 @@
+package mux
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+)
+
+// CSRF configures the cross-site request forgery protection set up by
+// UseCSRF.
+type CSRF struct {
+	// CookieName is the name of the cookie holding the token,
+	// "csrf_token" if empty.
+	CookieName string
+
+	// HeaderName is the request header submitting the token,
+	// "X-CSRF-Token" if empty, and FieldName the form field submitting it
+	// if the header is not set, "csrf_token" if empty.
+	HeaderName string
+	FieldName  string
+
+	// Path, Domain, Secure and SameSite are the attributes of the cookie.
+	// Path is "/" if empty and SameSite Lax if zero.
+	Path     string
+	Domain   string
+	Secure   bool
+	SameSite http.SameSite
+
+	// HTTPOnly hides the cookie from scripts, which must then get the token
+	// from the page, as rendered with CSRFToken.
+	HTTPOnly bool
+
+	// Failed handles requests without a valid token. If nil, they are
+	// answered with 403 Forbidden.
+	Failed http.HandlerFunc
+}
+
+// csrfTokenLen is the number of random bytes of a token.
+const csrfTokenLen = 32
+
+// UseCSRF protects the routes of mux against cross-site request forgery with
+// double-submit cookies: every request routed to a route with a method that
+// is not safe, not GET, HEAD, OPTIONS or TRACE, must submit the token of its
+// cookie in a header or form field as configured by c. The cookie is set on
+// responses to requests without one, and handlers get the token to embed in
+// forms or pages with CSRFToken.
+//
+// The token is checked after routing, so requests answered with a redirect,
+// 405 Method Not Allowed or by notFound are not, and routes registered with
+// CSRFExempt, such as webhooks authenticated otherwise, are left out.
+func (mux *Mux) UseCSRF(c CSRF) {
+	mux.lock()
+	defer mux.unlock()
+
+	if c.CookieName == "" {
+		c.CookieName = "csrf_token"
+	}
+	if c.HeaderName == "" {
+		c.HeaderName = "X-CSRF-Token"
+	}
+	if c.FieldName == "" {
+		c.FieldName = "csrf_token"
+	}
+	if c.Path == "" {
+		c.Path = "/"
+	}
+	if c.SameSite == 0 {
+		c.SameSite = http.SameSiteLaxMode
+	}
+	if c.Failed == nil {
+		c.Failed = func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		}
+	}
+	mux.csrf = &c
+}
+
+// CSRFExempt leaves the route out of the protection set up by UseCSRF.
+func CSRFExempt() RouteOption {
+	return func(mux *Mux, e *muxEntry) {
+		e.csrfExempt = true
+	}
+}
+
+// protect returns a handler checking the token of requests with methods that
+// are not safe before calling next and setting the cookie if there is none.
+func (c *CSRF) protect(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := c.cookieToken(r)
+		if !safeMethod(r.Method) && (token == "" || !c.submitted(r, token)) {
+			c.Failed(w, r)
+			return
+		}
+		if token == "" {
+			token = newCSRFToken()
+			http.SetCookie(w, &http.Cookie{
+				Name:     c.CookieName,
+				Value:    token,
+				Path:     c.Path,
+				Domain:   c.Domain,
+				Secure:   c.Secure,
+				HttpOnly: c.HTTPOnly,
+				SameSite: c.SameSite,
+			})
+		}
+		next(w, r.WithContext(context.WithValue(r.Context(), csrfKey{}, token)))
+	}
+}
+
+// cookieToken returns the token of the cookie of r or "" if there is none or
+// it is not a token.
+func (c *CSRF) cookieToken(r *http.Request) string {
+	cookie, err := r.Cookie(c.CookieName)
+	if err != nil {
+		return ""
+	}
+	b, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+	if err != nil || len(b) != csrfTokenLen {
+		return ""
+	}
+	return cookie.Value
+}
+
+// submitted reports whether r submits token in the header or, failing that,
+// the form field.
+func (c *CSRF) submitted(r *http.Request, token string) bool {
+	got := r.Header.Get(c.HeaderName)
+	if got == "" {
+		got = r.PostFormValue(c.FieldName)
+	}
+	return subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
+
+// newCSRFToken returns a new random token.
+func newCSRFToken() string {
+	b := make([]byte, csrfTokenLen)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// safeMethod reports whether method is safe, not changing the state of the
+// server.
+func safeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// csrfKey is the context key for the CSRF token of a request.
+type csrfKey struct{}
+
+// CSRFToken returns the token requests must submit to the routes protected
+// by UseCSRF, to be embedded in forms or pages, or "" if r was not routed to
+// a protected route.
+func CSRFToken(r *http.Request) string {
+	token, _ := r.Context().Value(csrfKey{}).(string)
+	return token
+}
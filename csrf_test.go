@@ -0,0 +1,95 @@
+package mux_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestCSRF(t *testing.T) {
+	form := func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, mux.CSRFToken(r))
+	}
+
+	m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found")))
+	m.UseCSRF(mux.CSRF{})
+	m.Get("/form", form)
+	m.Post("/form", handlerFactory(http.StatusOK, "posted"))
+	m.Post("/webhook", handlerFactory(http.StatusOK, "hook"), mux.CSRFExempt())
+
+	// Get a token and its cookie.
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/form", nil))
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != "csrf_token" || cookies[0].Value == "" {
+		t.Fatalf("got cookies %v, want a csrf_token cookie", cookies)
+	}
+	cookie := cookies[0]
+	if token := rec.Body.String(); token != cookie.Value {
+		t.Errorf("got token %q, want %q", token, cookie.Value)
+	}
+	if cookie.SameSite != http.SameSiteLaxMode || cookie.Path != "/" {
+		t.Errorf("got SameSite %v and Path %q, want Lax and %q", cookie.SameSite, cookie.Path, "/")
+	}
+
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		cookie bool
+		header string
+		field  string
+		code   int
+	}{
+		{"header", http.MethodPost, "/form", true, cookie.Value, "", http.StatusOK},
+		{"field", http.MethodPost, "/form", true, "", cookie.Value, http.StatusOK},
+		{"no cookie", http.MethodPost, "/form", false, cookie.Value, "", http.StatusForbidden},
+		{"no token", http.MethodPost, "/form", true, "", "", http.StatusForbidden},
+		{"wrong token", http.MethodPost, "/form", true, "x" + cookie.Value[1:], "", http.StatusForbidden},
+		{"safe", http.MethodGet, "/form", false, "", "", http.StatusOK},
+		{"exempt", http.MethodPost, "/webhook", false, "", "", http.StatusOK},
+		{"not found", http.MethodPost, "/missing", false, "", "", http.StatusNotFound},
+		{"method not allowed", http.MethodPut, "/form", false, "", "", http.StatusMethodNotAllowed},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var body io.Reader
+			if c.field != "" {
+				body = strings.NewReader(url.Values{"csrf_token": {c.field}}.Encode())
+			}
+			r := httptest.NewRequest(c.method, c.path, body)
+			if c.field != "" {
+				r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+			if c.cookie {
+				r.AddCookie(cookie)
+			}
+			if c.header != "" {
+				r.Header.Set("X-CSRF-Token", c.header)
+			}
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+			if rec.Code != c.code {
+				t.Errorf("got StatusCode %d, want %d", rec.Code, c.code)
+			}
+		})
+	}
+
+	t.Run("cookie kept", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/form", nil)
+		r.AddCookie(cookie)
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, r)
+		if cookies := rec.Result().Cookies(); len(cookies) != 0 {
+			t.Errorf("got cookies %v, want none", cookies)
+		}
+		if token := rec.Body.String(); token != cookie.Value {
+			t.Errorf("got token %q, want %q", token, cookie.Value)
+		}
+	})
+}
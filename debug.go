@@ -0,0 +1,176 @@
+package mux
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/http/pprof"
+	"net/url"
+	"strings"
+)
+
+// MountDebug registers the runtime profiling endpoints of net/http/pprof
+// under prefix + "/pprof/", like "/debug/pprof/heap" for prefix "/debug", and
+// the expvar endpoint at prefix + "/vars". prefix + "/pprof" is redirected
+// to the index page of the profiles.
+//
+// The options apply to all debug routes, so the endpoints can be protected
+// with WithMiddleware:
+//
+//	m.MountDebug("/debug", mux.WithMiddleware(requireAdmin))
+//
+// Note that net/http/pprof and expvar also register their handlers on
+// http.DefaultServeMux when imported.
+//
+// Panics if prefix does not begin with "/" or ends with "/", or if a debug
+// pattern is already registered.
+func (mux *Mux) MountDebug(prefix string, opts ...RouteOption) {
+	if prefix == "" || prefix[0] != '/' || prefix[len(prefix)-1] == '/' {
+		panic("mux: prefix must begin with \"/\" and must not end with \"/\"")
+	}
+
+	mux.HandleFunc(prefix+"/pprof", redirectHandler(&url.URL{Path: prefix + "/pprof/"}, http.StatusMovedPermanently), opts...)
+	mux.HandleFunc(prefix+"/pprof/{profile...}", servePprof, opts...)
+	mux.Handle(prefix+"/vars", expvar.Handler(), opts...)
+}
+
+// servePprof serves the pprof profile named by the profile parameter or the
+// index of the profiles if it is empty.
+func servePprof(w http.ResponseWriter, r *http.Request) {
+	switch name := Param(r, "profile"); name {
+	case "":
+		pprof.Index(w, r)
+	case "cmdline":
+		pprof.Cmdline(w, r)
+	case "profile":
+		pprof.Profile(w, r)
+	case "symbol":
+		pprof.Symbol(w, r)
+	case "trace":
+		pprof.Trace(w, r)
+	default:
+		pprof.Handler(name).ServeHTTP(w, r)
+	}
+}
+
+// debugTable is the routing table of a mux as rendered by DebugHandler.
+type debugTable struct {
+	Middleware []string     `json:"middleware"` // added with Use
+	Routes     []debugRoute `json:"routes"`
+	Mounts     []debugMount `json:"mounts"` // handlers other than muxes
+}
+
+// debugRoute is a route as rendered by DebugHandler.
+type debugRoute struct {
+	Mount       string   `json:"mount,omitempty"` // prefix of the mux of the route
+	Pattern     string   `json:"pattern"`
+	Methods     []string `json:"methods"` // "*" for any method
+	Regexp      bool     `json:"regexp,omitempty"`
+	Conditional bool     `json:"conditional,omitempty"`
+	Middleware  []string `json:"middleware,omitempty"`
+}
+
+// debugMount is a mounted handler as rendered by DebugHandler.
+type debugMount struct {
+	Prefix  string `json:"prefix"`
+	Handler string `json:"handler"`
+}
+
+// DebugHandler returns a handler rendering the routing table of mux: the
+// middleware added with Use, the routes of mux and of the muxes mounted with
+// MountLive or MountHandler, with the prefix they are mounted at, and the
+// other mounted handlers. It renders an HTML page or, if the request accepts
+// application/json or has the query "format=json", JSON. Together with
+// Explain, it answers why a request does not reach the expected handler
+// during development; register it behind authentication if mux is public:
+//
+//	m.Handle("/debug/routes", m.DebugHandler(), mux.WithMiddleware(requireAdmin))
+//
+// Middleware and handlers are named after their functions, as far as the
+// runtime knows them.
+func (mux *Mux) DebugHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		dt := debugTable{Middleware: []string{}, Routes: []debugRoute{}, Mounts: []debugMount{}}
+		for _, mw := range mux.load().middleware {
+			dt.Middleware = append(dt.Middleware, funcName(mw))
+		}
+		mux.debugTable("", &dt)
+
+		if r.URL.Query().Get("format") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json") {
+			w.Header().Set("Content-Type", "application/json")
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			enc.Encode(dt)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		debugTemplate.Execute(w, dt)
+	})
+}
+
+// debugTable adds the routes and mounts of mux, mounted at prefix, to dt.
+func (mux *Mux) debugTable(prefix string, dt *debugTable) {
+	for _, rt := range mux.Routes() {
+		methods := rt.Methods
+		if rt.Handler != nil {
+			methods = append(methods, "*")
+		}
+		dt.Routes = append(dt.Routes, debugRoute{
+			Mount:       prefix,
+			Pattern:     rt.Pattern,
+			Methods:     methods,
+			Regexp:      rt.Regexp,
+			Conditional: rt.Conditional,
+			Middleware:  rt.Middleware,
+		})
+	}
+	for _, m := range mux.load().mounts {
+		if m.keepPath {
+			continue // notFound of a submux copied by Mount or NotFoundUnder
+		}
+		if submux, ok := m.handler.(*Mux); ok {
+			submux.debugTable(prefix+m.prefix, dt)
+			continue
+		}
+		dt.Mounts = append(dt.Mounts, debugMount{Prefix: prefix + m.prefix, Handler: handlerName(m.handler)})
+	}
+}
+
+// handlerName returns the name of the function of h if it is an
+// http.HandlerFunc or the type of h otherwise.
+func handlerName(h http.Handler) string {
+	if f, ok := h.(http.HandlerFunc); ok {
+		return funcName(f)
+	}
+	return fmt.Sprintf("%T", h)
+}
+
+var debugTemplate = template.Must(template.New("routes").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Routes</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 0.2em 0.5em; text-align: left; }
+td { font-family: monospace; }
+</style>
+</head>
+<body>
+<h1>Routes</h1>
+{{if .Middleware}}<p>Middleware: {{range $i, $mw := .Middleware}}{{if $i}}, {{end}}<code>{{$mw}}</code>{{end}}</p>{{end}}
+<table>
+<tr><th>Mount</th><th>Pattern</th><th>Methods</th><th>Kind</th><th>Middleware</th></tr>
+{{range .Routes}}<tr><td>{{.Mount}}</td><td>{{.Pattern}}</td><td>{{range $i, $m := .Methods}}{{if $i}} {{end}}{{$m}}{{end}}</td><td>{{if .Regexp}}regexp{{end}}{{if .Conditional}} conditional{{end}}</td><td>{{range $i, $mw := .Middleware}}{{if $i}}<br>{{end}}{{$mw}}{{end}}</td></tr>
+{{end}}</table>
+{{if .Mounts}}<h2>Mounted handlers</h2>
+<table>
+<tr><th>Prefix</th><th>Handler</th></tr>
+{{range .Mounts}}<tr><td>{{.Prefix}}</td><td>{{.Handler}}</td></tr>
+{{end}}</table>
+{{end}}</body>
+</html>
+`))
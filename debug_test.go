@@ -0,0 +1,130 @@
+package mux_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestMountDebug(t *testing.T) {
+	requireToken := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	m := mux.New()
+	m.MountDebug("/debug", mux.WithMiddleware(requireToken))
+
+	cases := []struct {
+		path       string
+		token      bool
+		statusCode int
+		body       string // substring of the body
+		location   string
+	}{
+		{"/debug/pprof/", true, http.StatusOK, "goroutine", ""},
+		{"/debug/pprof/goroutine?debug=1", true, http.StatusOK, "goroutine profile", ""},
+		{"/debug/pprof/cmdline", true, http.StatusOK, "", ""},
+		{"/debug/pprof/missing", true, http.StatusNotFound, "Unknown profile", ""},
+		{"/debug/pprof", true, http.StatusMovedPermanently, "", "/debug/pprof/"},
+		{"/debug/vars", true, http.StatusOK, `"memstats"`, ""},
+		{"/debug/vars", false, http.StatusUnauthorized, "", ""},
+		{"/debug/pprof/heap", false, http.StatusUnauthorized, "", ""},
+	}
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, c.path, nil)
+			if c.token {
+				r.Header.Set("Authorization", "Bearer secret")
+			}
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+
+			if rec.Code != c.statusCode {
+				t.Errorf("got StatusCode %d, want %d", rec.Code, c.statusCode)
+			}
+			if !strings.Contains(rec.Body.String(), c.body) {
+				t.Errorf("got body %q, want it to contain %q", rec.Body.String(), c.body)
+			}
+			if location := rec.Header().Get("Location"); location != c.location {
+				t.Errorf("got Location %q, want %q", location, c.location)
+			}
+		})
+	}
+
+	t.Run("red", func(t *testing.T) {
+		prefixes := []string{"", "debug", "/debug/"}
+		for _, prefix := range prefixes {
+			t.Run(prefix, func(t *testing.T) {
+				defer func() {
+					if recover() == nil {
+						t.Error("got no panic, want panic")
+					}
+				}()
+
+				mux.New().MountDebug(prefix)
+			})
+		}
+	})
+}
+
+func requireAdmin(next http.Handler) http.Handler {
+	return next
+}
+
+func TestDebugHandler(t *testing.T) {
+	m := mux.New()
+	m.Use(requireAdmin)
+	m.HandleFunc("GET /users/{id}", handlerFactory(http.StatusOK, "user"), mux.WithMiddleware(requireAdmin))
+	m.RegexpHandleFunc(`^/posts/(\d+)$`, handlerFactory(http.StatusOK, "post"))
+	sub := mux.New()
+	sub.HandleFunc("/a", handlerFactory(http.StatusOK, "a"))
+	m.MountLive("/sub", sub)
+	m.MountHandler("/files", http.FileServer(http.Dir(".")))
+	m.Handle("/debug/routes", m.DebugHandler())
+
+	t.Run("json", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/routes?format=json", nil))
+		if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("got Content-Type %q, want application/json", ct)
+		}
+		body := rec.Body.String()
+		for _, want := range []string{
+			`"github.com/touchmarine/mux_test.requireAdmin"`,
+			`"pattern": "/users/{id}"`,
+			`"GET"`,
+			`"regexp": true`,
+			`"mount": "/sub"`,
+			`"pattern": "/a"`,
+			`"prefix": "/files"`,
+			`"handler": "*http.fileHandler"`,
+		} {
+			if !strings.Contains(body, want) {
+				t.Errorf("got body\n%s\nwant it to contain %s", body, want)
+			}
+		}
+	})
+
+	t.Run("html", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/routes", nil))
+		if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+			t.Errorf("got Content-Type %q, want text/html", ct)
+		}
+		body := rec.Body.String()
+		for _, want := range []string{"<td>/users/{id}</td>", "<td>/sub</td>", "mux_test.requireAdmin"} {
+			if !strings.Contains(body, want) {
+				t.Errorf("got body\n%s\nwant it to contain %s", body, want)
+			}
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package mux
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// logLevel is the level of a diagnostic log record, with the values of the
+// corresponding slog levels.
+type logLevel int
+
+const (
+	levelDebug logLevel = -4
+	levelInfo  logLevel = 0
+	levelWarn  logLevel = 4
+	levelError logLevel = 8
+)
+
+// logFunc logs a diagnostic record with the message and alternating keys and
+// values.
+type logFunc func(ctx context.Context, level logLevel, msg string, args ...interface{})
+
+// logf logs a diagnostic record if mux has a logger.
+func (t *table) logf(ctx context.Context, level logLevel, msg string, args ...interface{}) {
+	if t.log != nil {
+		t.log(ctx, level, msg, args...)
+	}
+}
+
+// redirectHandler returns a handler that redirects to u with the given status
+// code, logging the redirect and its reason if mux has a logger. Requests
+// with methods other than GET and HEAD are redirected with the status code
+// preserving their method or, if mux has RewriteRedirects, served as if made
+// to u.
+func (t *table) redirectHandler(u *url.URL, code int, reason string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		code := code
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			if t.rewrite && r.Context().Value(rewrittenKey{}) == nil {
+				t.logf(r.Context(), levelDebug, "mux: rewrite",
+					"method", r.Method,
+					"path", r.URL.Path,
+					"location", u.String(),
+					"reason", reason,
+				)
+				t.serve(w, rewrite(r, u))
+				return
+			}
+			code = methodPreserving(code)
+		}
+		t.logf(r.Context(), levelDebug, "mux: redirect",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"location", u.String(),
+			"status", code,
+			"reason", reason,
+		)
+		redirect(w, r, u, code)
+	}
+}
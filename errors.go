@@ -0,0 +1,143 @@
+package mux
+
+import (
+	"encoding/json"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// NegotiatedErrors returns an Option that sets the notFound and method not
+// allowed handlers, unless already set, to built-in handlers that reply in
+// the format the client prefers according to its Accept header:
+//
+//	application/json  {"error":"not found","path":"/a"}
+//	text/html         a minimal HTML page
+//	text/plain        otherwise
+//
+// The JSON object always has exactly the members "error", the lowercase
+// status text such as "not found" or "method not allowed", and "path", the
+// request path.
+//
+// To use a custom notFound handler but negotiated 405 responses, pass the
+// handler to New:
+//
+//	m := mux.New(mux.WithNotFound(notFound), mux.NegotiatedErrors())
+func NegotiatedErrors() Option {
+	return func(mux *Mux) {
+		mux.negotiated = true
+		if mux.notFound == nil {
+			mux.notFound = negotiatedError(http.StatusNotFound)
+		}
+		if mux.methodNotAllowed == nil {
+			mux.methodNotAllowed = negotiatedError(http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// replyError replies to the request with the given status code, in the
+// format preferred by the client if mux uses NegotiatedErrors.
+func (t *table) replyError(w http.ResponseWriter, r *http.Request, code int) {
+	if t.negotiated {
+		negotiatedError(code)(w, r)
+		return
+	}
+	http.Error(w, http.StatusText(code), code)
+}
+
+// errorBody is the JSON body of negotiated error responses.
+type errorBody struct {
+	Error string `json:"error"`
+	Path  string `json:"path"`
+}
+
+// negotiatedError returns a handler that replies with the given status code
+// in the format preferred by the client.
+func negotiatedError(code int) http.HandlerFunc {
+	text := strings.ToLower(http.StatusText(code))
+	return func(w http.ResponseWriter, r *http.Request) {
+		var contentType, body string
+		switch negotiate(r.Header.Get("Accept"), "text/plain", "application/json", "text/html") {
+		case "application/json":
+			b, err := json.Marshal(errorBody{text, r.URL.Path})
+			if err != nil {
+				panic(err)
+			}
+			contentType, body = "application/json; charset=utf-8", string(b)
+		case "text/html":
+			title := strconv.Itoa(code) + " " + http.StatusText(code)
+			contentType = "text/html; charset=utf-8"
+			body = "<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\"><title>" + title +
+				"</title></head><body><h1>" + title + "</h1><p>" + html.EscapeString(r.URL.Path) +
+				"</p></body></html>\n"
+		default:
+			contentType, body = "text/plain; charset=utf-8", strconv.Itoa(code)+" "+text+"\n"
+		}
+
+		h := w.Header()
+		h.Set("Content-Type", contentType)
+		h.Set("Content-Length", strconv.Itoa(len(body)))
+		h.Set("X-Content-Type-Options", "nosniff")
+		h.Add("Vary", "Accept")
+		w.WriteHeader(code)
+		w.Write([]byte(body))
+	}
+}
+
+// negotiate returns the offered media type the accept header prefers. Ties are
+// broken by how specifically the header names a type and then by the order
+// of offers. The first offer is returned if the header is empty or accepts
+// none of the offers.
+func negotiate(accept string, offers ...string) string {
+	if accept == "" {
+		return offers[0]
+	}
+
+	best, bestQ, bestSpecificity := offers[0], 0.0, -1
+	for _, offer := range offers {
+		q, specificity := acceptQuality(accept, offer)
+		if q > bestQ || q == bestQ && q > 0 && specificity > bestSpecificity {
+			best, bestQ, bestSpecificity = offer, q, specificity
+		}
+	}
+	return best
+}
+
+// acceptQuality returns the quality the accept header assigns to the media
+// type and the specificity of the media range it was taken from: 0 for
+// "*/*", 1 for "type/*" and 2 for "type/subtype".
+func acceptQuality(accept, mediatype string) (float64, int) {
+	typ := mediatype[:strings.IndexByte(mediatype, '/')]
+
+	q, specificity := 0.0, -1
+	for _, part := range strings.Split(accept, ",") {
+		fields := strings.Split(part, ";")
+		rng := strings.ToLower(strings.TrimSpace(fields[0]))
+
+		s := -1
+		switch rng {
+		case "*/*":
+			s = 0
+		case typ + "/*":
+			s = 1
+		case mediatype:
+			s = 2
+		}
+		if s <= specificity {
+			continue
+		}
+
+		rq := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if f, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					rq = f
+				}
+			}
+		}
+		q, specificity = rq, s
+	}
+	return q, specificity
+}
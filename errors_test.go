@@ -0,0 +1,148 @@
+package mux_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestNegotiatedErrors(t *testing.T) {
+	t.Run("not found", func(t *testing.T) {
+		cases := []struct {
+			accept      string
+			contentType string
+			body        string
+		}{
+			{
+				"",
+				"text/plain; charset=utf-8",
+				"404 not found\n",
+			},
+			{
+				"*/*",
+				"text/plain; charset=utf-8",
+				"404 not found\n",
+			},
+			{
+				"application/json",
+				"application/json; charset=utf-8",
+				`{"error":"not found","path":"/a\u003cb"}`,
+			},
+			{
+				"application/xml;q=0.9, application/json;q=0.8",
+				"application/json; charset=utf-8",
+				`{"error":"not found","path":"/a\u003cb"}`,
+			},
+			{
+				"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+				"text/html; charset=utf-8",
+				"<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\"><title>404 Not Found</title></head>" +
+					"<body><h1>404 Not Found</h1><p>/a&lt;b</p></body></html>\n",
+			},
+			{
+				"text/*",
+				"text/plain; charset=utf-8",
+				"404 not found\n",
+			},
+			{
+				"image/png",
+				"text/plain; charset=utf-8",
+				"404 not found\n",
+			},
+		}
+
+		for _, c := range cases {
+			t.Run(c.accept, func(t *testing.T) {
+				m := mux.New(mux.NegotiatedErrors())
+				r := httptest.NewRequest(http.MethodGet, "/a%3Cb", nil)
+				r.Header.Set("Accept", c.accept)
+				rec := httptest.NewRecorder()
+				m.ServeHTTP(rec, r)
+				resp := rec.Result()
+
+				if resp.StatusCode != http.StatusNotFound {
+					t.Errorf("got StatusCode %d, want %d", resp.StatusCode, http.StatusNotFound)
+				}
+
+				contentType := resp.Header.Get("Content-Type")
+				if contentType != c.contentType {
+					t.Errorf("got Content-Type %q, want %q", contentType, c.contentType)
+				}
+
+				contentLength := resp.Header.Get("Content-Length")
+				if contentLength != strconv.Itoa(len(c.body)) {
+					t.Errorf("got Content-Length %s, want %d", contentLength, len(c.body))
+				}
+
+				vary := resp.Header.Get("Vary")
+				if vary != "Accept" {
+					t.Errorf("got Vary %q, want Accept", vary)
+				}
+
+				b, err := ioutil.ReadAll(resp.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				body := string(b)
+				if body != c.body {
+					t.Errorf("got body %q, want %q", body, c.body)
+				}
+			})
+		}
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		m := mux.New(mux.NegotiatedErrors())
+		m.Get("/a", handlerFactory(http.StatusTeapot, "a"))
+
+		r := httptest.NewRequest(http.MethodPost, "/a", nil)
+		r.Header.Set("Accept", "application/json")
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, r)
+		resp := rec.Result()
+
+		if resp.StatusCode != http.StatusMethodNotAllowed {
+			t.Errorf("got StatusCode %d, want %d", resp.StatusCode, http.StatusMethodNotAllowed)
+		}
+
+		allow := resp.Header.Get("Allow")
+		if allow != "GET, HEAD, OPTIONS" {
+			t.Errorf("got Allow %q, want %q", allow, "GET, HEAD, OPTIONS")
+		}
+
+		b, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body := string(b)
+		want := `{"error":"method not allowed","path":"/a"}`
+		if body != want {
+			t.Errorf("got body %q, want %q", body, want)
+		}
+	})
+
+	t.Run("user notFound", func(t *testing.T) {
+		m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "a")), mux.NegotiatedErrors())
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept", "application/json")
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, r)
+		resp := rec.Result()
+
+		b, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body := string(b)
+		if body != "a" {
+			t.Errorf("got body %q, want a", body)
+		}
+	})
+}
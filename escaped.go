@@ -0,0 +1,56 @@
+package mux
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// MatchEscapedPath makes mux match patterns against the escaped path of
+// requests, as returned by r.URL.EscapedPath, instead of the decoded
+// r.URL.Path, so that "/files/a%2Fb" is not routed like "/files/a/b": it
+// matches "/files/{name}" with the parameter "a%2Fb" while "/files/a/b" does
+// not. This matters for APIs addressing files or keys that may contain
+// slashes. Path parameters and named regexp submatches are passed escaped;
+// decode them with url.PathUnescape. Patterns must match escaped paths too,
+// so a pattern for a path with a space is written "/a%20b". It applies to the
+// routes registered after it, so pass it to New.
+//
+// Mounted handlers and handlers of routes still see the request as it is,
+// with r.URL.Path decoded.
+func MatchEscapedPath() Option {
+	return func(mux *Mux) {
+		mux.escaped = true
+	}
+}
+
+// escapedRequest returns a shallow copy of r whose path is the escaped path
+// of r, for routing.
+func escapedRequest(r *http.Request) *http.Request {
+	r2 := new(http.Request)
+	*r2 = *r
+	u := *r.URL
+	u.Path, u.RawPath = r.URL.EscapedPath(), ""
+	r2.URL = &u
+	return r2
+}
+
+// unescapeURL returns u, whose path is an escaped path, with the path
+// decoded and kept escaped as it was as the raw path, so that it is not
+// escaped again.
+func unescapeURL(u *url.URL) *url.URL {
+	p, err := url.PathUnescape(u.Path)
+	if err != nil {
+		return u
+	}
+	c := *u
+	c.Path, c.RawPath = p, u.Path
+	return &c
+}
+
+// path returns the path of r the pattern of e is matched against.
+func (e muxEntry) path(r *http.Request) string {
+	if e.escaped {
+		return r.URL.EscapedPath()
+	}
+	return r.URL.Path
+}
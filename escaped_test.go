@@ -0,0 +1,66 @@
+package mux_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestMatchEscapedPath(t *testing.T) {
+	handler := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+			io.WriteString(w, name+" "+mux.Param(r, "key")+" "+r.URL.Path)
+		}
+	}
+
+	m := mux.New(mux.MatchEscapedPath())
+	m.HandleFunc("/keys/{key}", handler("key"))
+	m.HandleFunc("/keys/{key}/meta", handler("meta"))
+	m.RegexpHandleFunc(`^/r/(?P<key>[^/]+)$`, handler("regexp"))
+	m.HandleFunc("/files/{key...}", handler("files"))
+
+	cases := []struct {
+		path       string
+		statusCode int
+		body       string
+		location   string
+	}{
+		{"/keys/a%2Fb", http.StatusTeapot, "key a%2Fb /keys/a/b", ""},
+		{"/keys/a/b", http.StatusNotFound, "", ""},
+		{"/keys/a%2Fb/meta", http.StatusTeapot, "meta a%2Fb /keys/a/b/meta", ""},
+		{"/keys/a%20b", http.StatusTeapot, "key a%20b /keys/a b", ""},
+		{"/r/a%2Fb", http.StatusTeapot, "regexp a%2Fb /r/a/b", ""},
+		{"/files/a%2Fb/c", http.StatusTeapot, "files a%2Fb/c /files/a/b/c", ""},
+		{"/keys/a%2Fb/", http.StatusPermanentRedirect, "", "/keys/a%2Fb"},
+	}
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, c.path, nil))
+			if rec.Code != c.statusCode {
+				t.Errorf("got StatusCode %d, want %d", rec.Code, c.statusCode)
+			}
+			if c.body != "" && rec.Body.String() != c.body {
+				t.Errorf("got body %q, want %q", rec.Body.String(), c.body)
+			}
+			if location := rec.Header().Get("Location"); location != c.location {
+				t.Errorf("got Location %q, want %q", location, c.location)
+			}
+		})
+	}
+}
+
+func TestMatchEscapedPathDisabled(t *testing.T) {
+	m := mux.New()
+	m.HandleFunc("/keys/{key}", handlerFactory(http.StatusTeapot, "key"))
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/keys/a%2Fb", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("got StatusCode %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
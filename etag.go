@@ -0,0 +1,177 @@
+package mux
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ETags makes the route answer conditional GET and HEAD requests: the body
+// of each 200 OK response is buffered and, unless the handler sets an ETag
+// header, given one computed from it, weak if weak. Requests with an
+// If-None-Match header naming the ETag, or without one and with an
+// If-Modified-Since header not before the Last-Modified header the handler
+// sets, are answered with 304 Not Modified without a body.
+//
+// Responses flushed by the handler are sent as they are written, without an
+// ETag, as are responses to other requests.
+func ETags(weak bool) RouteOption {
+	return func(mux *Mux, e *muxEntry) {
+		e.handler = conditional(weak, e.handler).ServeHTTP
+	}
+}
+
+// UseETags adds a middleware, as with Use, answering conditional requests to
+// all routes of mux as ETags does. HEAD requests handled by the GET handler of
+// a route get the ETag of its GET response; those handled by a HEAD handler
+// of their own are passed on without one, as their body is not known.
+func (mux *Mux) UseETags(weak bool) {
+	mux.Use(func(next http.Handler) http.Handler {
+		tagged := conditional(weak, next.ServeHTTP)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodHead {
+				// The body is discarded by discardBody, which tags it
+				// before it is.
+				next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), etagsKey{}, weak)))
+				return
+			}
+			tagged.ServeHTTP(w, r)
+		})
+	})
+}
+
+// etagsKey is the context key for whether the ETags of HEAD requests answered
+// by GET handlers are weak, set by UseETags.
+type etagsKey struct{}
+
+// conditional returns a handler answering conditional requests to next.
+func conditional(weak bool, next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next(w, r)
+			return
+		}
+		ew := &etagWriter{ResponseWriter: WrapResponseWriter(w)}
+		next(ew, r)
+		ew.finish(r, weak)
+	})
+}
+
+// etagWriter buffers a response until it is complete, unless the response
+// is not a 200 OK or is flushed.
+type etagWriter struct {
+	ResponseWriter
+	status      int
+	buf         []byte
+	passThrough bool // whether the response is written as it is
+}
+
+func (ew *etagWriter) WriteHeader(code int) {
+	if ew.passThrough || code < http.StatusOK {
+		ew.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if ew.status != 0 {
+		return
+	}
+	ew.status = code
+	if code != http.StatusOK {
+		ew.passThrough = true
+		ew.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (ew *etagWriter) Write(b []byte) (int, error) {
+	if ew.status == 0 {
+		ew.WriteHeader(http.StatusOK)
+	}
+	if ew.passThrough {
+		return ew.ResponseWriter.Write(b)
+	}
+	ew.buf = append(ew.buf, b...)
+	return len(b), nil
+}
+
+// ReadFrom copies from r with Write, so that the body is buffered.
+func (ew *etagWriter) ReadFrom(r io.Reader) (int64, error) {
+	return io.Copy(writerOnly{ew}, r)
+}
+
+// finish writes the buffered response to r, or 304 Not Modified.
+func (ew *etagWriter) finish(r *http.Request, weak bool) {
+	if ew.passThrough {
+		return
+	}
+	h := ew.Header()
+	etag := h.Get("ETag")
+	if etag == "" {
+		sum := sha256.Sum256(ew.buf)
+		etag = `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+		if weak {
+			etag = "W/" + etag
+		}
+		h.Set("ETag", etag)
+	}
+
+	if notModified(r, etag, h.Get("Last-Modified")) {
+		for _, name := range []string{"Content-Type", "Content-Length", "Content-Encoding"} {
+			h.Del(name)
+		}
+		ew.ResponseWriter.WriteHeader(http.StatusNotModified)
+		return
+	}
+	ew.ResponseWriter.WriteHeader(http.StatusOK)
+	ew.ResponseWriter.Write(ew.buf)
+}
+
+// notModified reports whether the representation of r with the ETag etag and
+// the Last-Modified header lastModified is one the client has, as its
+// If-None-Match or, without one, its If-Modified-Since header says.
+func notModified(r *http.Request, etag, lastModified string) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return etagMatch(inm, etag)
+	}
+
+	ims, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	modified, err := http.ParseTime(lastModified)
+	if err != nil {
+		return false
+	}
+	return !modified.Truncate(time.Second).After(ims)
+}
+
+// Flush sends the response buffered so far and makes the rest be written as
+// it is, if the underlying ResponseWriter is an http.Flusher.
+func (ew *etagWriter) Flush() {
+	if _, ok := ew.Unwrap().(http.Flusher); !ok {
+		return
+	}
+	if !ew.passThrough {
+		ew.passThrough = true
+		if ew.status == 0 {
+			ew.status = http.StatusOK
+		}
+		ew.ResponseWriter.WriteHeader(ew.status)
+		ew.ResponseWriter.Write(ew.buf)
+		ew.buf = nil
+	}
+	ew.ResponseWriter.Flush()
+}
+
+// Hijack hijacks the connection of the underlying ResponseWriter if it is an
+// http.Hijacker.
+func (ew *etagWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := ew.ResponseWriter.Hijack()
+	if err == nil {
+		ew.passThrough = true
+	}
+	return conn, rw, err
+}
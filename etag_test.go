@@ -0,0 +1,138 @@
+package mux_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestETags(t *testing.T) {
+	modified := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found")))
+	m.Get("/strong", handlerFactory(http.StatusOK, "strong"), mux.ETags(false))
+	m.Get("/weak", handlerFactory(http.StatusOK, "weak"), mux.ETags(true))
+	m.Get("/set", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+		fmt.Fprint(w, "set")
+	}, mux.ETags(false))
+	m.Get("/error", handlerFactory(http.StatusInternalServerError, "error"), mux.ETags(false))
+	m.Get("/plain", handlerFactory(http.StatusOK, "plain"))
+
+	etagOf := func(path string) string {
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Header().Get("ETag")
+	}
+	strong, weak := etagOf("/strong"), etagOf("/weak")
+	if strong == "" || !strings.HasPrefix(strong, `"`) {
+		t.Fatalf("got strong ETag %q, want quoted", strong)
+	}
+	if !strings.HasPrefix(weak, `W/"`) {
+		t.Fatalf("got weak ETag %q, want W/ prefix", weak)
+	}
+
+	cases := []struct {
+		name       string
+		method     string
+		path       string
+		header     map[string]string
+		statusCode int
+		body       string
+		etag       string
+	}{
+		{"strong", http.MethodGet, "/strong", nil, http.StatusOK, "strong", strong},
+		{"strong match", http.MethodGet, "/strong", map[string]string{"If-None-Match": strong}, http.StatusNotModified, "", strong},
+		{"strong match weak", http.MethodGet, "/strong", map[string]string{"If-None-Match": "W/" + strong}, http.StatusNotModified, "", strong},
+		{"list match", http.MethodGet, "/strong", map[string]string{"If-None-Match": `"other", ` + strong}, http.StatusNotModified, "", strong},
+		{"star", http.MethodGet, "/strong", map[string]string{"If-None-Match": "*"}, http.StatusNotModified, "", strong},
+		{"mismatch", http.MethodGet, "/strong", map[string]string{"If-None-Match": `"other"`}, http.StatusOK, "strong", strong},
+		{"head match", http.MethodHead, "/strong", map[string]string{"If-None-Match": strong}, http.StatusNotModified, "", strong},
+		{"weak match", http.MethodGet, "/weak", map[string]string{"If-None-Match": weak}, http.StatusNotModified, "", weak},
+		{"handler etag", http.MethodGet, "/set", map[string]string{"If-None-Match": `"v1"`}, http.StatusNotModified, "", `"v1"`},
+		{"not modified since", http.MethodGet, "/set", map[string]string{"If-Modified-Since": modified.Format(http.TimeFormat)}, http.StatusNotModified, "", `"v1"`},
+		{"modified since", http.MethodGet, "/set", map[string]string{"If-Modified-Since": modified.Add(-time.Hour).Format(http.TimeFormat)}, http.StatusOK, "set", `"v1"`},
+		{"if-none-match precedes", http.MethodGet, "/set", map[string]string{"If-None-Match": `"v0"`, "If-Modified-Since": modified.Format(http.TimeFormat)}, http.StatusOK, "set", `"v1"`},
+		{"error", http.MethodGet, "/error", map[string]string{"If-None-Match": "*"}, http.StatusInternalServerError, "error", ""},
+		{"no option", http.MethodGet, "/plain", map[string]string{"If-None-Match": "*"}, http.StatusOK, "plain", ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := httptest.NewRequest(c.method, c.path, nil)
+			for name, value := range c.header {
+				r.Header.Set(name, value)
+			}
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+
+			if rec.Code != c.statusCode {
+				t.Errorf("got StatusCode %d, want %d", rec.Code, c.statusCode)
+			}
+			if got := rec.Body.String(); c.method == http.MethodGet && got != c.body {
+				t.Errorf("got body %q, want %q", got, c.body)
+			}
+			if got := rec.Header().Get("ETag"); got != c.etag {
+				t.Errorf("got ETag %q, want %q", got, c.etag)
+			}
+			if rec.Code == http.StatusNotModified && rec.Header().Get("Content-Type") != "" {
+				t.Errorf("got Content-Type %q, want none", rec.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestUseETags(t *testing.T) {
+	m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found")))
+	m.UseETags(false)
+	m.Get("/", handlerFactory(http.StatusOK, "index"))
+	m.Get("/stream", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "part")
+		w.(http.Flusher).Flush()
+	})
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	etag := rec.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("got no ETag, want one")
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	m.ServeHTTP(rec, r)
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("got StatusCode %d, want %d", rec.Code, http.StatusNotModified)
+	}
+
+	rec = httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodHead, "/", nil))
+	if got := rec.Header().Get("ETag"); got != etag {
+		t.Errorf("got HEAD ETag %q, want GET ETag %q", got, etag)
+	}
+	r = httptest.NewRequest(http.MethodHead, "/", nil)
+	r.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	m.ServeHTTP(rec, r)
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("got HEAD StatusCode %d, want %d", rec.Code, http.StatusNotModified)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/stream", nil)
+	r.Header.Set("If-None-Match", "*")
+	rec = httptest.NewRecorder()
+	m.ServeHTTP(rec, r)
+	if rec.Code != http.StatusOK || rec.Body.String() != "part" || !rec.Flushed {
+		t.Errorf("got %d %q flushed %t, want 200 \"part\" flushed", rec.Code, rec.Body.String(), rec.Flushed)
+	}
+	if got := rec.Header().Get("ETag"); got != "" {
+		t.Errorf("got ETag %q, want none", got)
+	}
+}
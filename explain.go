@@ -0,0 +1,293 @@
+package mux
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Outcome is the final routing decision for a request.
+type Outcome int
+
+const (
+	// OutcomeNotFound means no pattern matched and notFound is called.
+	OutcomeNotFound Outcome = iota
+	// OutcomeMatched means the handler of a pattern is called.
+	OutcomeMatched
+	// OutcomeRedirected means the request is redirected to its canonical
+	// URL.
+	OutcomeRedirected
+	// OutcomeLocale means the request is routed into the inner mux of
+	// Locales.
+	OutcomeLocale
+	// OutcomeFallback means the request is passed to the fallback handler.
+	OutcomeFallback
+	// OutcomeMethodNotAllowed means a pattern matched but has no handler
+	// for the request method.
+	OutcomeMethodNotAllowed
+	// OutcomeMounted means the request is passed to a handler mounted at a
+	// path prefix.
+	OutcomeMounted
+	// OutcomeOptions means patterns matched an OPTIONS request without an
+	// OPTIONS handler and it is answered with the allowed methods.
+	OutcomeOptions
+	// OutcomeVersion means the request, without a version prefix, is routed
+	// into the submux of an API version registered with Version.
+	OutcomeVersion
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case OutcomeNotFound:
+		return "not found"
+	case OutcomeMatched:
+		return "matched"
+	case OutcomeRedirected:
+		return "redirected"
+	case OutcomeLocale:
+		return "locale"
+	case OutcomeFallback:
+		return "fallback"
+	case OutcomeMethodNotAllowed:
+		return "method not allowed"
+	case OutcomeMounted:
+		return "mounted"
+	case OutcomeOptions:
+		return "options"
+	case OutcomeVersion:
+		return "version"
+	}
+	return fmt.Sprintf("Outcome(%d)", int(o))
+}
+
+// Explanation describes how a mux routes a request.
+type Explanation struct {
+	Method string // request method
+	Path   string // request path
+
+	// Candidates are the patterns considered, in the order they were
+	// considered.
+	Candidates []Candidate
+
+	Outcome  Outcome
+	Pattern  string // matched pattern if OutcomeMatched or OutcomeRedirected, or mount prefix
+	Location string // redirect URL if OutcomeRedirected
+	Reason   string // canonicalization causing the redirect if OutcomeRedirected
+	Locale   string // locale if OutcomeLocale
+	Version  string // API version if OutcomeVersion
+	Allow    string // allowed methods if OutcomeMethodNotAllowed or OutcomeOptions
+
+	// Params are the path parameters and named regexp submatches the
+	// handler is passed, by name, if OutcomeMatched and the pattern has any.
+	Params map[string]string
+
+	// Inner explains the routing in the inner mux of Locales, in a mux
+	// mounted with MountLive or Version or in the fallback mux, if any.
+	Inner *Explanation
+
+	route *Route // matched route if OutcomeMatched
+}
+
+// Candidate describes a pattern considered for a request.
+type Candidate struct {
+	Pattern string
+	Regexp  bool // whether Pattern is a regular expression
+	Params  bool // whether Pattern has parameters
+
+	// Matched reports whether the pattern matches the request, directly or
+	// after canonicalization.
+	Matched bool
+
+	// Reason tells why the pattern was not chosen or, if it was, what it
+	// does with the request.
+	Reason string
+}
+
+// Explain returns how mux would route r without calling any handler. It can
+// be used to answer why a request does not reach the expected handler.
+func (mux *Mux) Explain(r *http.Request) Explanation {
+	ex := Explanation{Method: r.Method, Path: r.URL.Path}
+	mux.match(r, &ex)
+	return ex
+}
+
+// String returns a multi-line, human readable description of ex.
+func (ex Explanation) String() string {
+	var b strings.Builder
+	ex.write(&b, "")
+	return b.String()
+}
+
+// write writes ex to b with each line indented by indent.
+func (ex Explanation) write(b *strings.Builder, indent string) {
+	fmt.Fprintf(b, "%s%s %s\n", indent, ex.Method, ex.Path)
+	for _, c := range ex.Candidates {
+		kind := "exact"
+		switch {
+		case c.Regexp:
+			kind = "regexp"
+		case c.Params:
+			kind = "param"
+		}
+		verdict := "rejected"
+		if c.Matched {
+			verdict = "matched"
+		}
+		fmt.Fprintf(b, "%s  %-6s %q %s: %s\n", indent, kind, c.Pattern, verdict, c.Reason)
+	}
+
+	fmt.Fprintf(b, "%s=> %s", indent, ex.Outcome)
+	switch ex.Outcome {
+	case OutcomeMatched, OutcomeMounted:
+		fmt.Fprintf(b, " %q", ex.Pattern)
+		if len(ex.Params) > 0 {
+			names := make([]string, 0, len(ex.Params))
+			for name := range ex.Params {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			b.WriteString(" (")
+			for i, name := range names {
+				if i > 0 {
+					b.WriteString(", ")
+				}
+				fmt.Fprintf(b, "%s=%q", name, ex.Params[name])
+			}
+			b.WriteString(")")
+		}
+	case OutcomeRedirected:
+		fmt.Fprintf(b, " to %s (%s)", ex.Location, ex.Reason)
+	case OutcomeLocale:
+		fmt.Fprintf(b, " %q", ex.Locale)
+	case OutcomeVersion:
+		fmt.Fprintf(b, " %q", ex.Version)
+	case OutcomeMethodNotAllowed, OutcomeOptions:
+		fmt.Fprintf(b, " (allow %s)", ex.Allow)
+	}
+	b.WriteString("\n")
+
+	if ex.Inner != nil {
+		ex.Inner.write(b, indent+"    ")
+	}
+}
+
+// candidate records that the pattern of e was considered for r. matched tells
+// whether it matches the path, u is the URL to redirect to if it matches only
+// after canonicalization and chosen whether the pattern is the one routed to.
+func (ex *Explanation) candidate(pattern string, e muxEntry, r *http.Request, matched bool, u *url.URL, chosen bool) {
+	c := Candidate{Pattern: pattern, Regexp: e.regexp, Params: e.segments != nil, Matched: matched}
+	v, applies := e.variantFor(r)
+	switch {
+	case !applies:
+		c.Reason = "rejected by route matchers"
+	case matched && u == nil && e.handlerFor(r.Method) == nil:
+		c.Matched = false
+		c.Reason = "method not allowed"
+	case !matched && e.regexp:
+		c.Reason = fmt.Sprintf("regexp does not match %q", e.target(r.URL.Path, r.URL))
+	case !matched && e.segments != nil:
+		c.Reason = "path does not match pattern"
+	case !matched:
+		c.Reason = "path does not equal pattern"
+	case !chosen:
+		c.Reason = "shadowed by " + ex.Pattern
+	case u != nil:
+		c.Reason = "redirect to " + u.String() + " (trailing slash)"
+		ex.redirect(u, "trailing slash")
+		ex.Pattern = pattern
+	default:
+		c.Reason = "handles the request"
+		ex.Outcome = OutcomeMatched
+		ex.Pattern = pattern
+		ex.Params = e.paramMap(r)
+		rt := newRoute(pattern, v)
+		ex.route = &rt
+	}
+	ex.Candidates = append(ex.Candidates, c)
+}
+
+// redirect records a redirect to u because of the given canonicalization.
+func (ex *Explanation) redirect(u *url.URL, reason string) {
+	if ex == nil {
+		return
+	}
+	ex.Outcome = OutcomeRedirected
+	ex.Location = u.String()
+	ex.Reason = reason
+}
+
+// nested returns a new explanation for r routed by another mux or nil if ex
+// is nil.
+func (ex *Explanation) nested(r *http.Request) *Explanation {
+	if ex == nil {
+		return nil
+	}
+	return &Explanation{Method: r.Method, Path: r.URL.Path}
+}
+
+// methodNotAllowed records that patterns matched the path but not the method
+// of the request, allowing only the given methods.
+func (ex *Explanation) methodNotAllowed(allow string) {
+	if ex == nil {
+		return
+	}
+	ex.Outcome = OutcomeMethodNotAllowed
+	ex.Allow = allow
+}
+
+// options records that patterns matched the path of an OPTIONS request
+// without an OPTIONS handler, allowing the given methods.
+func (ex *Explanation) options(allow string) {
+	if ex == nil {
+		return
+	}
+	ex.Outcome = OutcomeOptions
+	ex.Allow = allow
+}
+
+// mount records that the request is passed to the handler mounted at the
+// given prefix.
+func (ex *Explanation) mount(prefix string, inner *Explanation) {
+	if ex == nil {
+		return
+	}
+	ex.Outcome = OutcomeMounted
+	ex.Pattern = prefix
+	ex.Inner = inner
+}
+
+// locale records that the request is routed into the inner mux of Locales in
+// the given locale.
+func (ex *Explanation) locale(locale string, inner *Explanation) {
+	if ex == nil {
+		return
+	}
+	ex.Outcome = OutcomeLocale
+	ex.Locale = locale
+	ex.Inner = inner
+}
+
+// version records that the request is routed into the submux of the given
+// API version.
+func (ex *Explanation) version(version string, inner *Explanation) {
+	if ex == nil {
+		return
+	}
+	ex.Outcome = OutcomeVersion
+	ex.Version = version
+	ex.Inner = inner
+}
+
+// fallback records that the request was passed on to the fallback handler,
+// which handles it if ok.
+func (ex *Explanation) fallback(inner *Explanation, ok bool) {
+	if ex == nil {
+		return
+	}
+	if ok {
+		ex.Outcome = OutcomeFallback
+	}
+	ex.Inner = inner
+}
@@ -0,0 +1,220 @@
+package mux_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestExplain(t *testing.T) {
+	newMux := func(t *testing.T) *mux.Mux {
+		h := func(w http.ResponseWriter, r *http.Request) {
+			t.Error("handler called")
+		}
+
+		m := mux.New()
+		m.HandleFunc("/a", h)
+		m.RegexpHandleFunc("^/b/[0-9]+$", h)
+		m.RegexpHandleFunc("^/b/1", h)
+		return m
+	}
+
+	t.Run("outcome", func(t *testing.T) {
+		cases := []struct {
+			path     string
+			outcome  mux.Outcome
+			pattern  string
+			location string
+		}{
+			{"/a", mux.OutcomeMatched, "/a", ""},
+			{"/b/2", mux.OutcomeMatched, "^/b/[0-9]+$", ""},
+			{"/a/?q=1", mux.OutcomeRedirected, "/a", "/a?q=1"},
+			{"/c", mux.OutcomeNotFound, "", ""},
+		}
+
+		for _, c := range cases {
+			t.Run(c.path, func(t *testing.T) {
+				r := httptest.NewRequest(http.MethodGet, c.path, nil)
+				ex := newMux(t).Explain(r)
+
+				if ex.Outcome != c.outcome {
+					t.Errorf("got Outcome %s, want %s", ex.Outcome, c.outcome)
+				}
+				if ex.Pattern != c.pattern {
+					t.Errorf("got Pattern %q, want %q", ex.Pattern, c.pattern)
+				}
+				if ex.Location != c.location {
+					t.Errorf("got Location %q, want %q", ex.Location, c.location)
+				}
+				if len(ex.Candidates) != 3 {
+					t.Errorf("got %d candidates, want 3", len(ex.Candidates))
+				}
+			})
+		}
+	})
+
+	t.Run("candidates", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/b/1", nil)
+		ex := newMux(t).Explain(r)
+
+		var matched, shadowed, rejected int
+		for _, c := range ex.Candidates {
+			switch {
+			case !c.Matched:
+				rejected++
+				if c.Pattern != "/a" {
+					t.Errorf("got %q rejected, want matched", c.Pattern)
+				}
+			case strings.HasPrefix(c.Reason, "shadowed by "):
+				shadowed++
+				if c.Reason != "shadowed by "+ex.Pattern {
+					t.Errorf("got Reason %q, want shadowed by %q", c.Reason, ex.Pattern)
+				}
+			default:
+				matched++
+			}
+		}
+
+		if matched != 1 || shadowed != 1 || rejected != 1 {
+			t.Errorf("got %d matched, %d shadowed, %d rejected, want 1 of each", matched, shadowed, rejected)
+		}
+	})
+
+	t.Run("locale", func(t *testing.T) {
+		inner := mux.New()
+		inner.HandleFunc("/about", handlerFactory(http.StatusTeapot, ""))
+
+		m := mux.New()
+		m.Locales([]string{"en", "de"}, "en", inner)
+
+		r := httptest.NewRequest(http.MethodGet, "/de/about", nil)
+		ex := m.Explain(r)
+
+		if ex.Outcome != mux.OutcomeLocale || ex.Locale != "de" {
+			t.Errorf("got Outcome %s %q, want locale \"de\"", ex.Outcome, ex.Locale)
+		}
+		if ex.Inner == nil || ex.Inner.Path != "/about" || ex.Inner.Outcome != mux.OutcomeMatched {
+			t.Errorf("got Inner %+v, want /about matched", ex.Inner)
+		}
+
+		r = httptest.NewRequest(http.MethodGet, "/about", nil)
+		ex = m.Explain(r)
+
+		if ex.Outcome != mux.OutcomeRedirected || ex.Location != "/en/about" || ex.Reason != "default locale" {
+			t.Errorf("got Outcome %s to %q (%s), want redirected to /en/about (default locale)", ex.Outcome, ex.Location, ex.Reason)
+		}
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		fb := mux.New()
+		fb.HandleFunc("/old", handlerFactory(http.StatusTeapot, ""))
+
+		m := newMux(t)
+		m.SetFallback(fb)
+
+		r := httptest.NewRequest(http.MethodGet, "/old", nil)
+		ex := m.Explain(r)
+
+		if ex.Outcome != mux.OutcomeFallback {
+			t.Errorf("got Outcome %s, want %s", ex.Outcome, mux.OutcomeFallback)
+		}
+		if ex.Inner == nil || ex.Inner.Pattern != "/old" {
+			t.Errorf("got Inner %+v, want /old matched", ex.Inner)
+		}
+
+		r = httptest.NewRequest(http.MethodGet, "/missing", nil)
+		ex = m.Explain(r)
+
+		if ex.Outcome != mux.OutcomeNotFound {
+			t.Errorf("got Outcome %s, want %s", ex.Outcome, mux.OutcomeNotFound)
+		}
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		m := mux.New()
+		m.Get("/a", handlerFactory(http.StatusTeapot, ""))
+		m.Post("/a", handlerFactory(http.StatusTeapot, ""))
+
+		r := httptest.NewRequest(http.MethodPut, "/a", nil)
+		got := m.Explain(r).String()
+		want := "PUT /a\n" +
+			"  exact  \"/a\" rejected: method not allowed\n" +
+			"=> method not allowed (allow GET, HEAD, OPTIONS, POST)\n"
+		if got != want {
+			t.Errorf("got\n%s\nwant\n%s", got, want)
+		}
+	})
+
+	t.Run("options", func(t *testing.T) {
+		m := mux.New()
+		m.Get("/a", handlerFactory(http.StatusTeapot, ""))
+
+		r := httptest.NewRequest(http.MethodOptions, "/a", nil)
+		got := m.Explain(r).String()
+		want := "OPTIONS /a\n" +
+			"  exact  \"/a\" rejected: method not allowed\n" +
+			"=> options (allow GET, HEAD, OPTIONS)\n"
+		if got != want {
+			t.Errorf("got\n%s\nwant\n%s", got, want)
+		}
+	})
+
+	t.Run("mounted", func(t *testing.T) {
+		m := newMux(t)
+		m.MountHandler("/static", handlerFactory(http.StatusTeapot, ""))
+
+		r := httptest.NewRequest(http.MethodGet, "/static/app.css", nil)
+		ex := m.Explain(r)
+
+		if ex.Outcome != mux.OutcomeMounted || ex.Pattern != "/static" {
+			t.Errorf("got Outcome %s %q, want mounted \"/static\"", ex.Outcome, ex.Pattern)
+		}
+	})
+
+	t.Run("String", func(t *testing.T) {
+		m := mux.New()
+		m.HandleFunc("/a", handlerFactory(http.StatusTeapot, ""))
+
+		r := httptest.NewRequest(http.MethodGet, "/a/", nil)
+		got := m.Explain(r).String()
+		want := "GET /a/\n" +
+			"  exact  \"/a\" matched: redirect to /a (trailing slash)\n" +
+			"=> redirected to /a (trailing slash)\n"
+		if got != want {
+			t.Errorf("got\n%s\nwant\n%s", got, want)
+		}
+	})
+}
+
+func TestExplainParams(t *testing.T) {
+	m := mux.New()
+	m.HandleFunc("/users/{id}/files/{path...}", handlerFactory(http.StatusTeapot, ""))
+	m.RegexpHandleFunc(`^/posts/(?P<slug>[a-z-]+)$`, handlerFactory(http.StatusTeapot, ""))
+	m.HandleFunc("/a", handlerFactory(http.StatusTeapot, ""))
+
+	cases := []struct {
+		path   string
+		params map[string]string
+		line   string // last line of String
+	}{
+		{"/users/1/files/a/b.txt", map[string]string{"id": "1", "path": "a/b.txt"}, `=> matched "/users/{id}/files/{path...}" (id="1", path="a/b.txt")`},
+		{"/posts/hello-world", map[string]string{"slug": "hello-world"}, `=> matched "^/posts/(?P<slug>[a-z-]+)$" (slug="hello-world")`},
+		{"/a", nil, `=> matched "/a"`},
+	}
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			ex := m.Explain(httptest.NewRequest(http.MethodGet, c.path, nil))
+			if !reflect.DeepEqual(ex.Params, c.params) {
+				t.Errorf("got Params %v, want %v", ex.Params, c.params)
+			}
+			lines := strings.Split(strings.TrimSuffix(ex.String(), "\n"), "\n")
+			if got := lines[len(lines)-1]; got != c.line {
+				t.Errorf("got %s, want %s", got, c.line)
+			}
+		})
+	}
+}
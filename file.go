@@ -0,0 +1,89 @@
+package mux
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+)
+
+// HandleFile registers a handler serving the file name from fsys at pattern,
+// like robots.txt or favicon.ico. The Content-Type is derived from the file
+// extension and conditional requests are answered with 304 Not Modified based
+// on the file's ETag and Last-Modified time.
+//
+// The ETag is derived from the size and modification time of the file or,
+// for files without a modification time such as those of an embed.FS, from
+// the file content.
+//
+// Panics if the file does not exist or is a directory.
+func (mux *Mux) HandleFile(pattern string, fsys fs.FS, name string, opts ...RouteOption) {
+	info, err := fs.Stat(fsys, name)
+	if err != nil {
+		panic("mux: " + err.Error())
+	}
+	if info.IsDir() {
+		panic("mux: " + name + " is a directory")
+	}
+
+	var contentETag string
+	if info.ModTime().IsZero() {
+		b, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			panic("mux: " + err.Error())
+		}
+		contentETag = fmt.Sprintf(`"%x"`, sha256.Sum256(b))
+	}
+
+	mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		serveFile(w, r, fsys, name, contentETag)
+	}, opts...)
+}
+
+// serveFile serves the file name of fsys. etag is the ETag of the file or, if
+// empty, one derived from its size and modification time.
+func serveFile(w http.ResponseWriter, r *http.Request, fsys fs.FS, name, etag string) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		b, err := io.ReadAll(f)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		content = bytes.NewReader(b)
+	}
+
+	if etag == "" {
+		etag = fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size())
+	}
+	w.Header().Set("ETag", etag)
+	http.ServeContent(w, r, path.Base(name), info.ModTime(), content)
+}
+
+// CacheControl makes the route set the Cache-Control header of its responses
+// to value.
+func CacheControl(value string) RouteOption {
+	return func(mux *Mux, e *muxEntry) {
+		next := e.handler
+		e.handler = func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Cache-Control", value)
+			next(w, r)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package mux_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestHandleFile(t *testing.T) {
+	modTime := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"robots.txt":           {Data: []byte("User-agent: *\n"), ModTime: modTime},
+		"static/favicon.ico":   {Data: []byte{0, 0, 1, 0}, ModTime: modTime},
+		"manifest.json":        {Data: []byte("{}")},
+		"static/dir/file.html": {Data: []byte("<p>")},
+	}
+
+	newMux := func() *mux.Mux {
+		m := mux.New()
+		m.HandleFile("/robots.txt", fsys, "robots.txt", mux.CacheControl("max-age=3600"))
+		m.HandleFile("/favicon.ico", fsys, "static/favicon.ico")
+		m.HandleFile("/manifest.json", fsys, "manifest.json")
+		return m
+	}
+
+	t.Run("green", func(t *testing.T) {
+		cases := []struct {
+			path         string
+			contentType  string
+			body         string
+			cacheControl string
+			lastModified string
+		}{
+			{
+				"/robots.txt",
+				"text/plain; charset=utf-8",
+				"User-agent: *\n",
+				"max-age=3600",
+				"Sat, 02 Jan 2021 03:04:05 GMT",
+			},
+			{
+				"/favicon.ico",
+				"image/vnd.microsoft.icon",
+				"\x00\x00\x01\x00",
+				"",
+				"Sat, 02 Jan 2021 03:04:05 GMT",
+			},
+			{
+				"/manifest.json",
+				"application/json",
+				"{}",
+				"",
+				"",
+			},
+		}
+
+		for _, c := range cases {
+			t.Run(c.path, func(t *testing.T) {
+				m := newMux()
+				r := httptest.NewRequest(http.MethodGet, c.path, nil)
+				rec := httptest.NewRecorder()
+				m.ServeHTTP(rec, r)
+				resp := rec.Result()
+
+				if resp.StatusCode != http.StatusOK {
+					t.Errorf("got StatusCode %d, want %d", resp.StatusCode, http.StatusOK)
+				}
+
+				contentType := resp.Header.Get("Content-Type")
+				if contentType != c.contentType {
+					t.Errorf("got Content-Type %q, want %q", contentType, c.contentType)
+				}
+
+				cacheControl := resp.Header.Get("Cache-Control")
+				if cacheControl != c.cacheControl {
+					t.Errorf("got Cache-Control %q, want %q", cacheControl, c.cacheControl)
+				}
+
+				lastModified := resp.Header.Get("Last-Modified")
+				if lastModified != c.lastModified {
+					t.Errorf("got Last-Modified %q, want %q", lastModified, c.lastModified)
+				}
+
+				b, err := ioutil.ReadAll(resp.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				body := string(b)
+				if body != c.body {
+					t.Errorf("got body %q, want %q", body, c.body)
+				}
+
+				etag := resp.Header.Get("ETag")
+				if etag == "" {
+					t.Fatal("got no ETag, want ETag")
+				}
+
+				r = httptest.NewRequest(http.MethodGet, c.path, nil)
+				r.Header.Set("If-None-Match", etag)
+				rec = httptest.NewRecorder()
+				m.ServeHTTP(rec, r)
+
+				if rec.Code != http.StatusNotModified {
+					t.Errorf("got StatusCode %d for If-None-Match, want %d", rec.Code, http.StatusNotModified)
+				}
+			})
+		}
+	})
+
+	t.Run("If-Modified-Since", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/robots.txt", nil)
+		r.Header.Set("If-Modified-Since", modTime.Add(time.Hour).Format(http.TimeFormat))
+		rec := httptest.NewRecorder()
+		newMux().ServeHTTP(rec, r)
+
+		if rec.Code != http.StatusNotModified {
+			t.Errorf("got StatusCode %d, want %d", rec.Code, http.StatusNotModified)
+		}
+	})
+
+	t.Run("red", func(t *testing.T) {
+		cases := []struct {
+			name string
+			file string
+		}{
+			{"missing", "missing.txt"},
+			{"directory", "static/dir"},
+		}
+
+		for _, c := range cases {
+			t.Run(c.name, func(t *testing.T) {
+				defer func() {
+					if recover() == nil {
+						t.Error("got no panic, want panic")
+					}
+				}()
+
+				m := mux.New()
+				m.HandleFile("/a", fsys, c.file)
+			})
+		}
+	})
+}
@@ -0,0 +1,183 @@
+package mux
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+)
+
+// StaticOption configures the file serving set up by Static.
+type StaticOption func(*fileServer)
+
+// StaticRouteOptions applies the route options, like CacheControl, to the
+// routes registered by Static.
+func StaticRouteOptions(opts ...RouteOption) StaticOption {
+	return func(s *fileServer) {
+		s.opts = append(s.opts, opts...)
+	}
+}
+
+// SPAFallback makes Static serve the file name of its file system, usually
+// "index.html", for requests under the prefix for which no file exists, so that
+// a single-page app can route them on the client. Files that exist are
+// served as usual.
+func SPAFallback(name string) StaticOption {
+	return func(s *fileServer) {
+		s.fallback = name
+	}
+}
+
+// fileServer serves the files of a file system under a path prefix.
+type fileServer struct {
+	mux  *Mux
+	fsys fs.FS
+	opts []RouteOption
+
+	fallback string // file served instead of calling notFound or ""
+
+	etags sync.Map // file name to ETag of files without a modification time
+}
+
+// Static serves the files of fsys, such as an embed.FS or an os.DirFS, under
+// prefix, so that for prefix "/assets" the file "css/app.css" is served at
+// "/assets/css/app.css". Files are served like with HandleFile.
+//
+// A directory is served its index.html at its path with a trailing slash, like
+// "/assets/" or "/assets/docs/", and requests for its path without one are
+// redirected there. Requests for files that do not exist and for directories
+// without an index.html are handled by notFound, or the handler set by
+// NotFoundUnder for their path.
+//
+// Static registers the wildcard pattern prefix + "/*" and, unless prefix is
+// empty, prefix, so patterns registered for paths under prefix take precedence
+// over the files. The patterns are registered with ExactPath, since file names
+// are case-sensitive: with RedirectLowercase, "/assets/Logo.PNG" is served the
+// file "Logo.PNG" rather than redirected, and prefix is matched as it is given.
+// With an empty prefix the files match every path, so mixed-case paths of
+// other routes are not redirected either; use a prefix with RedirectLowercase.
+//
+// Panics if prefix is not empty and does not begin with "/" or ends with "/",
+// or if the SPAFallback file does not exist or is a directory.
+func (mux *Mux) Static(prefix string, fsys fs.FS, opts ...StaticOption) {
+	if prefix != "" && (prefix[0] != '/' || prefix[len(prefix)-1] == '/') {
+		panic("mux: static prefix must begin with \"/\" and must not end with \"/\"")
+	}
+	if fsys == nil {
+		panic("mux: nil file system")
+	}
+
+	s := &fileServer{mux: mux, fsys: fsys}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.fallback != "" {
+		info, err := fs.Stat(fsys, s.fallback)
+		if err != nil {
+			panic("mux: " + err.Error())
+		}
+		if info.IsDir() {
+			panic("mux: " + s.fallback + " is a directory")
+		}
+	}
+	routeOpts := append([]RouteOption{ExactPath()}, s.opts...)
+	mux.HandleFunc(prefix+"/*", s.serve, routeOpts...)
+	if prefix != "" {
+		mux.HandleFunc(prefix, s.redirectToDir, routeOpts...)
+	}
+}
+
+// serve serves the file named by the wildcard of the request path.
+func (s *fileServer) serve(w http.ResponseWriter, r *http.Request) {
+	name := Param(r, "*")
+	dir := name == "" || strings.HasSuffix(name, "/")
+	if dir {
+		name = path.Join(name, "index.html")
+	}
+	if !fs.ValidPath(name) {
+		s.notFound(w, r)
+		return
+	}
+
+	info, err := fs.Stat(s.fsys, name)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		s.notFound(w, r)
+		return
+	case err != nil:
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	case info.IsDir() && !dir:
+		if _, err := fs.Stat(s.fsys, path.Join(name, "index.html")); err != nil {
+			s.notFound(w, r)
+			return
+		}
+		s.redirectToDir(w, r)
+		return
+	case info.IsDir():
+		s.notFound(w, r)
+		return
+	}
+
+	s.serveFile(w, r, name, info)
+}
+
+// serveFile serves the file name with the given info.
+func (s *fileServer) serveFile(w http.ResponseWriter, r *http.Request, name string, info fs.FileInfo) {
+	var etag string
+	if info.ModTime().IsZero() {
+		var err error
+		etag, err = s.contentETag(name)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+	}
+	serveFile(w, r, s.fsys, name, etag)
+}
+
+// contentETag returns the ETag of the file name derived from its content.
+// Files without a modification time, such as those of an embed.FS, are
+// assumed not to change, so the ETag is computed once.
+func (s *fileServer) contentETag(name string) (string, error) {
+	if etag, ok := s.etags.Load(name); ok {
+		return etag.(string), nil
+	}
+	b, err := fs.ReadFile(s.fsys, name)
+	if err != nil {
+		return "", err
+	}
+	etag := fmt.Sprintf(`"%x"`, sha256.Sum256(b))
+	s.etags.Store(name, etag)
+	return etag, nil
+}
+
+// redirectToDir redirects the request to its path with a trailing slash.
+func (s *fileServer) redirectToDir(w http.ResponseWriter, r *http.Request) {
+	u := canonicalURL(r.URL, r.URL.Path+"/")
+	redirect(w, r, u, http.StatusMovedPermanently)
+}
+
+// notFound serves the SPAFallback file, if any, or calls the handler for
+// requests no pattern matches.
+func (s *fileServer) notFound(w http.ResponseWriter, r *http.Request) {
+	if s.fallback != "" {
+		info, err := fs.Stat(s.fsys, s.fallback)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		s.serveFile(w, r, s.fallback, info)
+		return
+	}
+	t := s.mux.load()
+	if h, ok := t.mounted(r, nil, true); ok {
+		h(w, r)
+		return
+	}
+	t.notFound(w, r)
+}
@@ -0,0 +1,206 @@
+package mux_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestStatic(t *testing.T) {
+	modTime := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"index.html":      {Data: []byte("<h1>home</h1>"), ModTime: modTime},
+		"css/app.css":     {Data: []byte("body{}")},
+		"docs/index.html": {Data: []byte("<h1>docs</h1>")},
+		"img/logo.png":    {Data: []byte("png"), ModTime: modTime},
+	}
+
+	m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found")))
+	m.Static("/assets", fsys, mux.StaticRouteOptions(mux.CacheControl("max-age=60")))
+	m.HandleFunc("/assets/version", handlerFactory(http.StatusTeapot, "1.0.0"))
+	m.NotFoundUnder("/assets/img", handlerFactory(http.StatusNotFound, "no image"))
+
+	cases := []struct {
+		path         string
+		statusCode   int
+		body         string
+		location     string
+		cacheControl string
+	}{
+		{"/assets/", http.StatusOK, "<h1>home</h1>", "", "max-age=60"},
+		{"/assets", http.StatusMovedPermanently, "", "/assets/", "max-age=60"},
+		{"/assets/css/app.css", http.StatusOK, "body{}", "", "max-age=60"},
+		{"/assets/docs/", http.StatusOK, "<h1>docs</h1>", "", "max-age=60"},
+		{"/assets/docs", http.StatusMovedPermanently, "", "/assets/docs/", "max-age=60"},
+		{"/assets/docs/index.html", http.StatusOK, "<h1>docs</h1>", "", "max-age=60"},
+		{"/assets/version", http.StatusTeapot, "1.0.0", "", ""},
+		{"/assets/css/", http.StatusNotFound, "not found", "", "max-age=60"},
+		{"/assets/css", http.StatusNotFound, "not found", "", "max-age=60"},
+		{"/assets/missing.js", http.StatusNotFound, "not found", "", "max-age=60"},
+		{"/assets/img/missing.png", http.StatusNotFound, "no image", "", "max-age=60"},
+		{"/assets/../index.html", http.StatusNotFound, "not found", "", "max-age=60"},
+		{"/index.html", http.StatusNotFound, "not found", "", ""},
+	}
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.URL.Path = c.path
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+			resp := rec.Result()
+
+			if resp.StatusCode != c.statusCode {
+				t.Errorf("got StatusCode %d, want %d", resp.StatusCode, c.statusCode)
+			}
+
+			if c.location != "" {
+				location := resp.Header.Get("Location")
+				if location != c.location {
+					t.Errorf("got Location %q, want %q", location, c.location)
+				}
+				return
+			}
+
+			cacheControl := resp.Header.Get("Cache-Control")
+			if cacheControl != c.cacheControl {
+				t.Errorf("got Cache-Control %q, want %q", cacheControl, c.cacheControl)
+			}
+
+			b, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body := string(b)
+			if body != c.body {
+				t.Errorf("got body %q, want %q", body, c.body)
+			}
+		})
+	}
+
+	t.Run("conditional", func(t *testing.T) {
+		for _, path := range []string{"/assets/css/app.css", "/assets/img/logo.png"} {
+			t.Run(path, func(t *testing.T) {
+				rec := httptest.NewRecorder()
+				m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+				etag := rec.Result().Header.Get("ETag")
+				if etag == "" {
+					t.Fatal("got no ETag")
+				}
+
+				r := httptest.NewRequest(http.MethodGet, path, nil)
+				r.Header.Set("If-None-Match", etag)
+				rec = httptest.NewRecorder()
+				m.ServeHTTP(rec, r)
+				if rec.Code != http.StatusNotModified {
+					t.Errorf("got StatusCode %d, want %d", rec.Code, http.StatusNotModified)
+				}
+			})
+		}
+	})
+
+	t.Run("root", func(t *testing.T) {
+		m := mux.New()
+		m.Static("", fsys)
+
+		for path, body := range map[string]string{"/": "<h1>home</h1>", "/css/app.css": "body{}"} {
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+			if got := rec.Body.String(); got != body {
+				t.Errorf("%s: got body %q, want %q", path, got, body)
+			}
+		}
+	})
+
+	t.Run("SPAFallback", func(t *testing.T) {
+		m := mux.New()
+		m.Static("/app", fsys, mux.SPAFallback("index.html"))
+
+		cases := []struct {
+			path string
+			body string
+		}{
+			{"/app/", "<h1>home</h1>"},
+			{"/app/users/12", "<h1>home</h1>"},
+			{"/app/css/", "<h1>home</h1>"},
+			{"/app/css/app.css", "body{}"},
+			{"/app/docs/", "<h1>docs</h1>"},
+		}
+		for _, c := range cases {
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, c.path, nil))
+			if rec.Code != http.StatusOK {
+				t.Errorf("%s: got StatusCode %d, want %d", c.path, rec.Code, http.StatusOK)
+			}
+			if got := rec.Body.String(); got != c.body {
+				t.Errorf("%s: got body %q, want %q", c.path, got, c.body)
+			}
+		}
+	})
+
+	t.Run("red", func(t *testing.T) {
+		fallbacks := []string{"missing.html", "docs"}
+		for _, fallback := range fallbacks {
+			t.Run(fallback, func(t *testing.T) {
+				defer func() {
+					if recover() == nil {
+						t.Error("got no panic, want panic")
+					}
+				}()
+
+				mux.New().Static("/app", fsys, mux.SPAFallback(fallback))
+			})
+		}
+
+		prefixes := []string{"assets", "/assets/", "/"}
+		for _, prefix := range prefixes {
+			t.Run(prefix, func(t *testing.T) {
+				defer func() {
+					if recover() == nil {
+						t.Error("got no panic, want panic")
+					}
+				}()
+
+				mux.New().Static(prefix, fsys)
+			})
+		}
+	})
+}
+
+func TestStaticRedirectLowercase(t *testing.T) {
+	fsys := fstest.MapFS{
+		"Logo.PNG": {Data: []byte("png")},
+	}
+
+	m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found")), mux.RedirectLowercase())
+	m.Static("/assets", fsys)
+	m.HandleFunc("/about", handlerFactory(http.StatusTeapot, "about"))
+
+	cases := []struct {
+		path       string
+		statusCode int
+		location   string
+	}{
+		{"/assets/Logo.PNG", http.StatusOK, ""},
+		{"/assets/logo.png", http.StatusNotFound, ""},
+		{"/About", http.StatusPermanentRedirect, "/about"},
+	}
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, c.path, nil))
+
+			if rec.Code != c.statusCode {
+				t.Errorf("got StatusCode %d, want %d", rec.Code, c.statusCode)
+			}
+			if got := rec.Header().Get("Location"); got != c.location {
+				t.Errorf("got Location %q, want %q", got, c.location)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package mux
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// Formats makes the route also match paths with one of the given file
+// extensions, without the leading ".", appended to their last segment, so
+// that browser-facing endpoints can be served in several formats by their
+// path rather than by the Accept header:
+//
+//	m.Get("/reports/{id}", report, mux.Formats("json", "csv"))
+//
+// A request for "/reports/7.csv" is routed as one for "/reports/7" whose
+// "format" parameter, read with Param, is "csv", so that the "id" parameter
+// is "7", while one for "/reports/7" has no format. The handler is passed a
+// request whose URL path has the extension removed and whose Accept header,
+// if the extension has a known media type, names only that type, so that
+// among routes of the pattern with Produces the one producing it is chosen.
+//
+// Patterns matching the path with the extension, like "/reports/7.csv",
+// take precedence. Formats applies only to patterns that are not regexps.
+//
+// Panics if no formats are given or a format is empty or contains "." or "/".
+func Formats(formats ...string) RouteOption {
+	if len(formats) == 0 {
+		panic("mux: no formats")
+	}
+	for _, f := range formats {
+		if f == "" || strings.ContainsAny(f, "./") {
+			panic("mux: invalid format " + f)
+		}
+	}
+	formats = append([]string(nil), formats...)
+
+	return func(mux *Mux, e *muxEntry) {
+		if e.regexp {
+			panic("mux: Formats on regexp pattern")
+		}
+		e.formats = append(e.formats, formats...)
+	}
+}
+
+// hasFormat reports whether e matches paths with the format as extension.
+func (e muxEntry) hasFormat(format string) bool {
+	for _, f := range e.formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// formatMatches reports whether e may match the path being routed: a path
+// with its format removed only if e has the format, and otherwise only if the
+// path does not end in a format of e, as such paths are routed without it.
+func (rt *routing) formatMatches(e muxEntry) bool {
+	if rt.format != "" {
+		return e.hasFormat(rt.format)
+	}
+	return len(e.formats) == 0 || !e.hasFormat(extension(rt.r.URL.Path))
+}
+
+// extension returns the extension of the last segment of path, without the
+// leading ".", or "" if it has none. Segments beginning with "." have none.
+func extension(path string) string {
+	i := strings.LastIndexByte(path, '.')
+	if i <= strings.LastIndexByte(path, '/')+1 {
+		return ""
+	}
+	return path[i+1:]
+}
+
+// addFormats adds the formats to those of the routes of t. The set is
+// replaced, not modified, as it is shared with the tables in service.
+func (t *table) addFormats(formats []string) {
+	set := make(map[string]bool, len(t.formats)+len(formats))
+	for f := range t.formats {
+		set[f] = true
+	}
+	for _, f := range formats {
+		set[f] = true
+	}
+	t.formats = set
+}
+
+// formatted routes r, whose path matches no other pattern, as a request for its
+// path without the extension of its last segment to a route with that
+// extension among its formats, recording the decision in ex unless ex is nil.
+// It returns the methods of routes matching all but the method if there is
+// no handler.
+func (t *table) formatted(r *http.Request, ex *Explanation) (http.HandlerFunc, []string) {
+	format := extension(r.URL.Path)
+	if !t.formats[format] {
+		return nil, nil
+	}
+
+	r = withFormat(r, format)
+	rt := routing{r: r, ex: ex, t: t, slash: t.slash, code: t.redirectStatus(), format: format}
+	if ex != nil {
+		rt.seen = make(map[string]bool)
+	}
+	t.tree.lookup(r.URL.Path, func(pattern string) bool {
+		return rt.try(pattern, t.m[pattern])
+	})
+	if rt.h == nil {
+		return nil, rt.allowed
+	}
+	// The request served may carry more than r, as routers like Version
+	// pass on a copy of it, so its path is stripped of the format as well.
+	h, pattern := rt.h, requestPattern(r)
+	return func(w http.ResponseWriter, r *http.Request) {
+		r = withFormat(r, format)
+		withPattern(r, pattern, h)(w, r)
+	}, nil
+}
+
+// withFormat returns a shallow copy of r for its path without the extension
+// of the given format, with the format as parameter and, if the format has a
+// known media type, an Accept header naming it.
+func withFormat(r *http.Request, format string) *http.Request {
+	ext := "." + format
+	u := *r.URL
+	u.Path = strings.TrimSuffix(u.Path, ext)
+	if strings.HasSuffix(u.RawPath, ext) {
+		u.RawPath = strings.TrimSuffix(u.RawPath, ext)
+	} else {
+		u.RawPath = ""
+	}
+
+	r = withParams(r, []string{"format"}, []string{format}, false)
+	r.URL = &u
+	if mediatype, _, err := mime.ParseMediaType(mime.TypeByExtension(ext)); err == nil {
+		h := r.Header.Clone()
+		if h == nil {
+			h = make(http.Header)
+		}
+		h.Set("Accept", mediatype)
+		r.Header = h
+	}
+	return r
+}
@@ -0,0 +1,109 @@
+package mux_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestFormats(t *testing.T) {
+	report := func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "report "+mux.Param(r, "id")+" "+mux.Param(r, "format")+" "+r.URL.Path)
+	}
+
+	m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found")))
+	m.Get("/reports/{id}", report, mux.Formats("json", "txt"))
+	m.Get("/reports/7.txt", handlerFactory(http.StatusOK, "static"))
+	m.Get("/files/{path...}", report, mux.Formats("json"))
+	m.Get("/users", handlerFactory(http.StatusOK, "json"), mux.Produces("application/json"), mux.Formats("json"))
+	m.Get("/users", handlerFactory(http.StatusOK, "xml"), mux.Produces("text/xml"), mux.Formats("xml"))
+	m.Get("/plain", handlerFactory(http.StatusOK, "plain"))
+
+	cases := []struct {
+		method string
+		path   string
+		code   int
+		body   string
+	}{
+		{http.MethodGet, "/reports/7", http.StatusOK, "report 7  /reports/7"},
+		{http.MethodGet, "/reports/7.json", http.StatusOK, "report 7 json /reports/7"},
+		{http.MethodGet, "/reports/8.txt", http.StatusOK, "report 8 txt /reports/8"},
+		{http.MethodGet, "/reports/7.txt", http.StatusOK, "static"},
+		{http.MethodGet, "/reports/7.csv", http.StatusOK, "report 7.csv  /reports/7.csv"},
+		{http.MethodGet, "/reports/.json", http.StatusOK, "report .json  /reports/.json"},
+		{http.MethodPost, "/reports/7.json", http.StatusMethodNotAllowed, ""},
+		{http.MethodGet, "/files/a/b.json", http.StatusOK, "report  json /files/a/b"},
+		{http.MethodGet, "/users", http.StatusOK, "json"},
+		{http.MethodGet, "/users.json", http.StatusOK, "json"},
+		{http.MethodGet, "/users.xml", http.StatusOK, "xml"},
+		{http.MethodGet, "/plain.json", http.StatusNotFound, "not found"},
+	}
+	for _, c := range cases {
+		t.Run(c.method+" "+c.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, httptest.NewRequest(c.method, c.path, nil))
+			if rec.Code != c.code {
+				t.Errorf("got StatusCode %d, want %d", rec.Code, c.code)
+			}
+			if c.body != "" && rec.Body.String() != c.body {
+				t.Errorf("got body %q, want %q", rec.Body.String(), c.body)
+			}
+		})
+	}
+
+	t.Run("Content-Type", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/users.xml", nil)
+		r.Header.Set("Accept", "application/json")
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, r)
+		if ct := rec.Header().Get("Content-Type"); ct != "text/xml" {
+			t.Errorf("got Content-Type %q, want %q", ct, "text/xml")
+		}
+	})
+
+	t.Run("Routes", func(t *testing.T) {
+		routes := m.Routes()
+		if got, want := routes[0].Formats, []string{"json", "txt"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got Formats %q, want %q", got, want)
+		}
+	})
+
+	for _, f := range []func(){
+		func() { mux.Formats() },
+		func() { mux.Formats("") },
+		func() { mux.Formats(".json") },
+		func() { m.RegexpHandleFunc("^/x$", handlerFactory(http.StatusOK, ""), mux.Formats("json")) },
+	} {
+		t.Run("invalid", func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("got no panic, want panic")
+				}
+			}()
+			f()
+		})
+	}
+}
+
+func TestFormatsVersion(t *testing.T) {
+	v2 := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found")))
+	v2.Get("/reports/{id}", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, mux.APIVersion(r)+" "+mux.Param(r, "id")+" "+mux.Param(r, "format")+" "+mux.MatchedPattern(r))
+	}, mux.Formats("json"))
+
+	m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found")))
+	m.Version("v2", v2)
+	m.DefaultVersion("v2")
+
+	for _, path := range []string{"/v2/reports/7.json", "/reports/7.json"} {
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if want := "v2 7 json /reports/{id}"; rec.Body.String() != want {
+			t.Errorf("%s: got body %q, want %q", path, rec.Body.String(), want)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package mux
+
+// Freeze makes mux immutable and precomputes the routing of paths equal to a
+// pattern without parameters, which are then found with a single map lookup
+// instead of a walk down the tree of patterns. Any later change to mux, such
+// as registering a pattern or adding middleware, panics. Call it once all
+// routes are registered at startup:
+//
+//	m := mux.New()
+//	m.HandleFunc("/", index)
+//	// ...
+//	m.Freeze()
+//	http.ListenAndServe(":8080", m)
+//
+// Muxes mounted with MountLive or set as fallback are not frozen with mux.
+func (mux *Mux) Freeze() {
+	mux.lock()
+	defer mux.unlock()
+
+	mux.frozen = true
+	mux.static = make(map[string]string)
+	for _, pattern := range mux.patterns {
+		if e := mux.m[pattern]; !e.regexp && e.segments == nil {
+			mux.static[pattern] = pattern
+		}
+	}
+}
+
+// Frozen reports whether mux was frozen with Freeze.
+func (mux *Mux) Frozen() bool {
+	return mux.load().frozen
+}
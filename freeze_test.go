@@ -0,0 +1,95 @@
+package mux_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestFreeze(t *testing.T) {
+	m := mux.New()
+	m.HandleFunc("/", handlerFactory(http.StatusTeapot, "index"))
+	m.Get("/users/new", handlerFactory(http.StatusTeapot, "new"))
+	m.HandleFunc("/users/{id}", handlerFactory(http.StatusTeapot, "id"))
+	m.HandleFunc("/beta", handlerFactory(http.StatusTeapot, "beta"), mux.Headers("X-Beta", "1"))
+	m.HandleFunc("/{path...}", handlerFactory(http.StatusTeapot, "catch-all"))
+	m.Freeze()
+
+	if !m.Frozen() {
+		t.Error("got Frozen false, want true")
+	}
+
+	cases := []struct {
+		method     string
+		path       string
+		statusCode int
+		body       string
+	}{
+		{http.MethodGet, "/", http.StatusTeapot, "index"},
+		{http.MethodGet, "/users/new", http.StatusTeapot, "new"},
+		{http.MethodPost, "/users/new", http.StatusTeapot, "id"},
+		{http.MethodGet, "/users/1", http.StatusTeapot, "id"},
+		{http.MethodGet, "/beta", http.StatusTeapot, "catch-all"},
+		{http.MethodGet, "/users/new/", http.StatusTeapot, "catch-all"},
+	}
+	for _, c := range cases {
+		t.Run(c.method+" "+c.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, httptest.NewRequest(c.method, c.path, nil))
+			res := rec.Result()
+			if res.StatusCode != c.statusCode {
+				t.Errorf("got StatusCode %d, want %d", res.StatusCode, c.statusCode)
+			}
+			body, err := ioutil.ReadAll(res.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.body != "" && string(body) != c.body {
+				t.Errorf("got body %q, want %q", body, c.body)
+			}
+		})
+	}
+
+	changes := map[string]func(){
+		"HandleFunc": func() { m.HandleFunc("/new", handlerFactory(http.StatusTeapot, "")) },
+		"Use":        func() { m.Use(func(h http.Handler) http.Handler { return h }) },
+		"NotFound":   func() { m.NotFound(nil) },
+		"Deregister": func() { m.Deregister("/") },
+	}
+	for name, change := range changes {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("got no panic, want panic")
+				}
+			}()
+			change()
+		})
+	}
+}
+
+func BenchmarkFrozen(b *testing.B) {
+	m := mux.New()
+	for _, p := range []string{"/", "/about", "/users", "/users/{id}", "/users/{id}/posts", "/api/v1/status", "/api/v1/users/me"} {
+		m.HandleFunc(p, handlerFactory(http.StatusOK, ""))
+	}
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/users/me", nil)
+	w := &discardWriter{header: make(http.Header)}
+
+	b.Run("unfrozen", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			m.ServeHTTP(w, r)
+		}
+	})
+	m.Freeze()
+	b.Run("frozen", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			m.ServeHTTP(w, r)
+		}
+	})
+}
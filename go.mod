@@ -1,3 +1,5 @@
 module github.com/touchmarine/mux
 
 go 1.16
+
+require golang.org/x/text v0.13.0
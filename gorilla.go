@@ -0,0 +1,238 @@
+package mux
+
+import (
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// HandleGorilla registers the handler function for the gorilla/mux style
+// pattern, to ease migrating from gorilla/mux. Variables are written as in
+// gorilla/mux, "{name}" or "{name:re}", and may make up part of a segment, as
+// in "/files/{name}.{ext}"; unconstrained variables match up to the next
+// slash, constrained ones what their regular expression matches, which may
+// include slashes. Handlers read them with Vars or Param. Like HandleFunc,
+// the pattern may begin with a method, so
+//
+//	r.HandleFunc("/articles/{id:[0-9]+}", h).Methods("GET")
+//
+// becomes
+//
+//	m.HandleGorilla("GET /articles/{id:[0-9]+}", h)
+//
+// and Host and Queries replace the matchers of the same name. Patterns whose
+// variables are all unconstrained whole segments are registered as they are;
+// others are registered as regular expressions, so they are tried after the
+// other patterns and their paths are not redirected to remove a trailing
+// slash.
+//
+// Panics if the pattern has unbalanced braces or invalid variables.
+func (mux *Mux) HandleGorilla(pattern string, handler http.HandlerFunc, opts ...RouteOption) {
+	method, pattern := splitMethod(pattern)
+	if native(pattern) {
+		mux.register(method, pattern, muxEntry{handler: handler}, opts...)
+		return
+	}
+	mux.register(method, "^"+gorillaRegexp(pattern, "[^/]+")+"$", muxEntry{handler: handler, regexp: true}, opts...)
+}
+
+// native reports whether the gorilla/mux pattern means the same as a pattern
+// of mux: its variables are unconstrained whole segments, it has no other
+// characters mux gives a meaning and it does not end in a slash.
+func native(pattern string) bool {
+	if pattern == "/" {
+		return true
+	}
+	if !strings.HasPrefix(pattern, "/") || strings.HasSuffix(pattern, "/") {
+		return false
+	}
+	for _, s := range strings.Split(pattern[1:], "/") {
+		if strings.ContainsAny(s, ":*") {
+			return false
+		}
+		if strings.ContainsAny(s, "{}") && (s[0] != '{' || strings.IndexAny(s[1:], "{}") != len(s)-2) {
+			return false
+		}
+	}
+	return true
+}
+
+// gorillaRegexp returns the regular expression, without anchors, matching the
+// gorilla/mux template tpl, its variables named submatches matching
+// defaultPattern unless constrained. Panics if the braces of tpl are
+// unbalanced or a variable is invalid.
+func gorillaRegexp(tpl, defaultPattern string) string {
+	var b strings.Builder
+	for i := 0; i < len(tpl); {
+		j := strings.IndexAny(tpl[i:], "{}")
+		if j < 0 {
+			b.WriteString(regexp.QuoteMeta(tpl[i:]))
+			break
+		}
+		if tpl[i+j] == '}' {
+			panic("mux: unbalanced braces in " + tpl)
+		}
+		b.WriteString(regexp.QuoteMeta(tpl[i : i+j]))
+		start := i + j
+
+		// Find the closing brace, constraints may have braces of their own.
+		end, depth := -1, 0
+		for k := start; k < len(tpl) && end < 0; k++ {
+			switch tpl[k] {
+			case '{':
+				depth++
+			case '}':
+				depth--
+				if depth == 0 {
+					end = k
+				}
+			}
+		}
+		if end < 0 {
+			panic("mux: unbalanced braces in " + tpl)
+		}
+		name, pattern := tpl[start+1:end], defaultPattern
+		if k := strings.IndexByte(name, ':'); k >= 0 {
+			name, pattern = name[:k], name[k+1:]
+		}
+		if name == "" || pattern == "" {
+			panic("mux: invalid variable " + tpl[start:end+1] + " in " + tpl)
+		}
+		b.WriteString("(?P<" + name + ">" + pattern + ")")
+		i = end + 1
+	}
+	return b.String()
+}
+
+// compileTemplate returns the anchored regular expression of the gorilla/mux
+// template tpl, panicking if it is invalid.
+func compileTemplate(tpl, defaultPattern string) *regexp.Regexp {
+	re, err := regexp.Compile("^" + gorillaRegexp(tpl, defaultPattern) + "$")
+	if err != nil {
+		panic("mux: invalid template " + tpl + ": " + err.Error())
+	}
+	return re
+}
+
+// Host makes the route match only requests for the host matching the
+// gorilla/mux style template, like "{subdomain}.example.com" or
+// "{subdomain:[a-z]+}.example.com", with unconstrained variables matching up
+// to the next dot, and adds its variables to the path parameters. The port of
+// the request host is ignored unless the template has one. Host is a Match
+// for migrating from gorilla/mux.
+//
+// Panics if the template is invalid.
+func Host(template string) RouteOption {
+	re := compileTemplate(template, "[^.]+")
+	withPort := hasPort(template)
+	host := func(r *http.Request) string {
+		if withPort {
+			return r.Host
+		}
+		if h, _, err := net.SplitHostPort(r.Host); err == nil {
+			return h
+		}
+		return r.Host
+	}
+
+	match := Match(func(r *http.Request) bool {
+		return re.MatchString(host(r))
+	})
+	return func(mux *Mux, e *muxEntry) {
+		match(mux, e)
+		if re.NumSubexp() > 0 {
+			e.handler = addVars(e.handler, e.stringKeys, func(r *http.Request) []string {
+				return re.FindStringSubmatch(host(r))
+			}, re.SubexpNames())
+		}
+	}
+}
+
+// Queries makes the route match only requests with the given query
+// parameters and adds their variables to the path parameters. pairs are
+// parameter name and value pairs; a value is a gorilla/mux style template
+// matching the whole value, like "{page}" or "{page:[0-9]+}", with
+// unconstrained variables matching anything, and an empty value matches any
+// request with the parameter. Queries is a Match for migrating from
+// gorilla/mux.
+//
+// Panics if pairs has an odd length or a template is invalid.
+func Queries(pairs ...string) RouteOption {
+	if len(pairs)%2 != 0 {
+		panic("mux: odd number of Queries arguments")
+	}
+	keys := make([]string, 0, len(pairs)/2)
+	res := make([]*regexp.Regexp, 0, len(pairs)/2)
+	var names []string
+	for i := 0; i < len(pairs); i += 2 {
+		re := anyValue
+		if pairs[i+1] != "" {
+			re = compileTemplate(pairs[i+1], ".*")
+		}
+		keys = append(keys, pairs[i])
+		res = append(res, re)
+		names = append(names, re.SubexpNames()[1:]...)
+	}
+
+	match := Match(func(r *http.Request) bool {
+		q := r.URL.Query()
+		for i, key := range keys {
+			values, ok := q[key]
+			if !ok || !res[i].MatchString(values[0]) {
+				return false
+			}
+		}
+		return true
+	})
+	return func(mux *Mux, e *muxEntry) {
+		match(mux, e)
+		if len(names) > 0 {
+			e.handler = addVars(e.handler, e.stringKeys, func(r *http.Request) []string {
+				q := r.URL.Query()
+				var values []string
+				for i, key := range keys {
+					values = append(values, res[i].FindStringSubmatch(q.Get(key))[1:]...)
+				}
+				return values
+			}, names)
+		}
+	}
+}
+
+// anyValue matches any query parameter value.
+var anyValue = regexp.MustCompile(`^.*$`)
+
+// hasPort reports whether the host template tpl has a port, a colon outside
+// its variables.
+func hasPort(tpl string) bool {
+	depth := 0
+	for i := 0; i < len(tpl); i++ {
+		switch tpl[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ':':
+			if depth == 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// addVars returns a handler that adds the values returned by submatches for
+// the request, by the given names, to its path parameters before calling h.
+func addVars(h http.HandlerFunc, stringKeys bool, submatches func(r *http.Request) []string, names []string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h(w, withParams(r, names, submatches(r), stringKeys))
+	}
+}
+
+// Vars returns the path parameters and named regexp submatches of r by name,
+// including the variables of Host and Queries, like Params. It eases
+// migrating from gorilla/mux, whose function of the same name it replaces.
+func Vars(r *http.Request) map[string]string {
+	return Params(r)
+}
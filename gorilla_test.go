@@ -0,0 +1,105 @@
+package mux_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestHandleGorilla(t *testing.T) {
+	vars := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			v := mux.Vars(r)
+			keys := make([]string, 0, len(v))
+			for k := range v {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			fmt.Fprint(w, name)
+			for _, k := range keys {
+				fmt.Fprintf(w, " %s=%s", k, v[k])
+			}
+		}
+	}
+
+	m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found")))
+	m.HandleGorilla("GET /articles/{category}/{id:[0-9]+}", vars("article"))
+	m.HandleGorilla("/users/{name}", vars("user"))
+	m.HandleGorilla("/files/{name}.{ext:[a-z]{2,4}}", vars("file"))
+	m.HandleGorilla("/static/{path:.*}", vars("static"))
+	m.HandleGorilla("/dir/", vars("dir"))
+	m.HandleGorilla("/hosts", vars("host"), mux.Host("{sub:[a-z]+}.example.com"))
+	m.HandleGorilla("/hosts", vars("port"), mux.Host("example.com:8080"))
+	m.HandleGorilla("/search", vars("search"), mux.Queries("q", "{q}", "page", "{page:[0-9]+}", "debug", ""))
+	m.HandleGorilla("/search", vars("plain search"))
+
+	cases := []struct {
+		method string
+		host   string
+		path   string
+		code   int
+		body   string
+	}{
+		{http.MethodGet, "", "/articles/tech/12", http.StatusOK, "article category=tech id=12"},
+		{http.MethodPost, "", "/articles/tech/12", http.StatusMethodNotAllowed, ""},
+		{http.MethodGet, "", "/articles/tech/x", http.StatusNotFound, "not found"},
+		{http.MethodGet, "", "/users/ann", http.StatusOK, "user name=ann"},
+		{http.MethodGet, "", "/files/report.pdf", http.StatusOK, "file ext=pdf name=report"},
+		{http.MethodGet, "", "/files/report.jpeg2", http.StatusNotFound, "not found"},
+		{http.MethodGet, "", "/static/css/site.css", http.StatusOK, "static path=css/site.css"},
+		{http.MethodGet, "", "/dir/", http.StatusOK, "dir"},
+		{http.MethodGet, "blog.example.com:443", "/hosts", http.StatusOK, "host sub=blog"},
+		{http.MethodGet, "example.com:8080", "/hosts", http.StatusOK, "port"},
+		{http.MethodGet, "example.com", "/hosts", http.StatusNotFound, "not found"},
+		{http.MethodGet, "", "/search?q=go&page=2&debug", http.StatusOK, "search page=2 q=go"},
+		{http.MethodGet, "", "/search?q=go&page=x&debug", http.StatusOK, "plain search"},
+		{http.MethodGet, "", "/search?q=go&page=2", http.StatusOK, "plain search"},
+	}
+	for _, c := range cases {
+		t.Run(c.method+" "+c.host+c.path, func(t *testing.T) {
+			r := httptest.NewRequest(c.method, c.path, nil)
+			if c.host != "" {
+				r.Host = c.host
+			}
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+			if rec.Code != c.code {
+				t.Errorf("got StatusCode %d, want %d", rec.Code, c.code)
+			}
+			if c.body != "" && rec.Body.String() != c.body {
+				t.Errorf("got body %q, want %q", rec.Body.String(), c.body)
+			}
+		})
+	}
+}
+
+func TestHandleGorillaPanics(t *testing.T) {
+	cases := []struct {
+		name     string
+		register func(m *mux.Mux)
+	}{
+		{"unbalanced", func(m *mux.Mux) { m.HandleGorilla("/a/{id", http.NotFound) }},
+		{"closing brace", func(m *mux.Mux) { m.HandleGorilla("/a/id}", http.NotFound) }},
+		{"empty name", func(m *mux.Mux) { m.HandleGorilla("/a/{:[0-9]+}", http.NotFound) }},
+		{"host", func(m *mux.Mux) { m.HandleGorilla("/a", http.NotFound, mux.Host("{sub.example.com")) }},
+		{"queries", func(m *mux.Mux) { m.HandleGorilla("/a", http.NotFound, mux.Queries("q")) }},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			defer func() {
+				v := recover()
+				if v == nil {
+					t.Error("got no panic, want panic")
+				} else if msg, _ := v.(string); !strings.HasPrefix(msg, "mux: ") {
+					t.Errorf("got panic %v, want mux panic", v)
+				}
+			}()
+			c.register(mux.New())
+		})
+	}
+}
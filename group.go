@@ -0,0 +1,128 @@
+package mux
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Group registers routes on a mux under a common path prefix, wrapped in
+// common middleware and given common route options, so that the routes of an
+// area, like an API or an admin section, are declared together:
+//
+//	api := m.Group("/api", requestLogger, auth.RequireToken)
+//	api.Get("/users/{id}", getUser)
+//	api.With(mux.WithMiddleware(requireAdmin)).Delete("/users/{id}", deleteUser)
+//
+// Middleware have the standard signature func(http.Handler) http.Handler, so
+// those written for chi or gorilla/mux, or any net/http middleware, plug in
+// unchanged. Like those added with WithMiddleware, they run only for requests
+// routed to the routes of the group.
+type Group struct {
+	mux    *Mux
+	prefix string
+	opts   []RouteOption // outer groups last
+}
+
+// Group returns a group registering routes on mux under prefix, which is
+// empty or begins but does not end with "/", wrapped in middleware, the first
+// middleware outermost.
+//
+// Panics if prefix is invalid or a middleware is nil.
+func (mux *Mux) Group(prefix string, middleware ...func(http.Handler) http.Handler) *Group {
+	return (&Group{mux: mux}).Group(prefix, middleware...)
+}
+
+// Group returns a group nested in g, registering routes under the prefix of
+// g followed by prefix, wrapped in the middleware of g and inside those in
+// middleware.
+//
+// Panics if prefix is invalid or a middleware is nil.
+func (g *Group) Group(prefix string, middleware ...func(http.Handler) http.Handler) *Group {
+	if prefix != "" && (prefix[0] != '/' || prefix[len(prefix)-1] == '/') {
+		panic("mux: group prefix must begin and not end with \"/\"")
+	}
+	var opts []RouteOption
+	if len(middleware) > 0 {
+		opts = append(opts, WithMiddleware(middleware...))
+	}
+	return &Group{mux: g.mux, prefix: g.prefix + prefix, opts: append(opts, g.opts...)}
+}
+
+// With returns a group like g whose routes are also given opts, like
+// WithMiddleware or Tags, before those of g.
+func (g *Group) With(opts ...RouteOption) *Group {
+	return &Group{mux: g.mux, prefix: g.prefix, opts: append(opts[:len(opts):len(opts)], g.opts...)}
+}
+
+// HandleFunc registers the handler function for the pattern under the prefix
+// of g, like the HandleFunc of Mux. The pattern "/" stands for the prefix
+// itself. The options of the route are applied before those of g, so the
+// middleware of g wrap those of the route.
+func (g *Group) HandleFunc(pattern string, handler http.HandlerFunc, opts ...RouteOption) {
+	method, pattern := splitMethod(pattern)
+	g.register(method, pattern, handler, opts)
+}
+
+// Handle registers the handler for the pattern under the prefix of g like
+// HandleFunc.
+func (g *Group) Handle(pattern string, handler http.Handler, opts ...RouteOption) {
+	if handler == nil {
+		panic("mux: nil handler")
+	}
+	g.HandleFunc(pattern, handler.ServeHTTP, opts...)
+}
+
+// Method registers the handler function for the given request method and the
+// pattern under the prefix of g, like the Method of Mux.
+//
+// Panics if method is empty or a handler already exists for method and
+// pattern.
+func (g *Group) Method(method, pattern string, handler http.HandlerFunc, opts ...RouteOption) {
+	if method == "" {
+		panic("mux: empty method")
+	}
+	g.register(method, pattern, handler, opts)
+}
+
+// Get registers the handler function for GET requests to the given pattern.
+func (g *Group) Get(pattern string, handler http.HandlerFunc, opts ...RouteOption) {
+	g.Method(http.MethodGet, pattern, handler, opts...)
+}
+
+// Post registers the handler function for POST requests to the given pattern.
+func (g *Group) Post(pattern string, handler http.HandlerFunc, opts ...RouteOption) {
+	g.Method(http.MethodPost, pattern, handler, opts...)
+}
+
+// Put registers the handler function for PUT requests to the given pattern.
+func (g *Group) Put(pattern string, handler http.HandlerFunc, opts ...RouteOption) {
+	g.Method(http.MethodPut, pattern, handler, opts...)
+}
+
+// Patch registers the handler function for PATCH requests to the given
+// pattern.
+func (g *Group) Patch(pattern string, handler http.HandlerFunc, opts ...RouteOption) {
+	g.Method(http.MethodPatch, pattern, handler, opts...)
+}
+
+// Delete registers the handler function for DELETE requests to the given
+// pattern.
+func (g *Group) Delete(pattern string, handler http.HandlerFunc, opts ...RouteOption) {
+	g.Method(http.MethodDelete, pattern, handler, opts...)
+}
+
+// register registers the handler for method and the pattern under the prefix
+// of g with the options of the route followed by those of g.
+func (g *Group) register(method, pattern string, handler http.HandlerFunc, opts []RouteOption) {
+	if g.prefix != "" {
+		if !strings.HasPrefix(pattern, "/") {
+			panic("mux: pattern must begin with \"/\"")
+		}
+		if pattern == "/" {
+			pattern = ""
+		}
+		pattern = g.prefix + pattern
+	}
+	opts = append(opts[:len(opts):len(opts)], g.opts...)
+	g.mux.register(method, pattern, muxEntry{handler: handler}, opts...)
+}
@@ -0,0 +1,65 @@
+package mux_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestGroup(t *testing.T) {
+	m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found")))
+	m.Use(tag("mux"))
+	api := m.Group("/api", tag("api1"), tag("api2"))
+	api.HandleFunc("/", handlerFactory(http.StatusTeapot, "api"))
+	api.Get("/users/{id}", handlerFactory(http.StatusTeapot, "user"), mux.WithMiddleware(tag("route")))
+	api.With(mux.WithMiddleware(tag("with"))).Delete("/users/{id}", handlerFactory(http.StatusTeapot, "delete user"))
+	admin := api.Group("/admin", tag("admin"))
+	admin.HandleFunc("GET /stats", handlerFactory(http.StatusTeapot, "stats"))
+	root := m.Group("", tag("root"))
+	root.Handle("/", handlerFactory(http.StatusTeapot, "home"))
+
+	cases := []struct {
+		method string
+		path   string
+		code   int
+		body   string
+		tags   string
+	}{
+		{http.MethodGet, "/api", http.StatusTeapot, "api", "mux api1 api2"},
+		{http.MethodGet, "/api/users/1", http.StatusTeapot, "user", "mux api1 api2 route"},
+		{http.MethodDelete, "/api/users/1", http.StatusTeapot, "delete user", "mux api1 api2 with"},
+		{http.MethodGet, "/api/admin/stats", http.StatusTeapot, "stats", "mux api1 api2 admin"},
+		{http.MethodPost, "/api/admin/stats", http.StatusMethodNotAllowed, "", "mux"},
+		{http.MethodGet, "/", http.StatusTeapot, "home", "mux root"},
+		{http.MethodGet, "/users/1", http.StatusNotFound, "not found", "mux"},
+	}
+	for _, c := range cases {
+		t.Run(c.method+" "+c.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, httptest.NewRequest(c.method, c.path, nil))
+			if rec.Code != c.code {
+				t.Errorf("got StatusCode %d, want %d", rec.Code, c.code)
+			}
+			if c.body != "" && rec.Body.String() != c.body {
+				t.Errorf("got body %q, want %q", rec.Body.String(), c.body)
+			}
+			if tags := strings.Join(rec.Header()["X-Tags"], " "); tags != c.tags {
+				t.Errorf("got X-Tags %q, want %q", tags, c.tags)
+			}
+		})
+	}
+
+	for _, prefix := range []string{"api", "/api/"} {
+		t.Run("invalid prefix "+prefix, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("got no panic, want panic")
+				}
+			}()
+			m.Group(prefix)
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package mux
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DefaultCheckTimeout is the timeout of a Check without one.
+const DefaultCheckTimeout = time.Second
+
+// Check is a named readiness check. Func reports whether the dependency it
+// checks is ready; it must return once ctx is done.
+type Check struct {
+	Name    string
+	Func    func(ctx context.Context) error
+	Timeout time.Duration // DefaultCheckTimeout if zero
+}
+
+func (c Check) timeout() time.Duration {
+	if c.Timeout <= 0 {
+		return DefaultCheckTimeout
+	}
+	return c.Timeout
+}
+
+// HandleHealth registers liveness and readiness endpoints at the given
+// patterns. Both are Quiet.
+//
+// The liveness endpoint always replies with 200 OK. The readiness endpoint
+// runs the checks concurrently, each with its own timeout, and replies with
+// 200 OK if all succeed and 503 Service Unavailable otherwise. A check still
+// running after its timeout fails, even if it ignores the cancellation of its
+// context, so the endpoint replies within the longest check timeout. The body
+// lists the result of each check:
+//
+//	{"status":"fail","checks":[
+//		{"name":"db","status":"ok","latency_ms":1.2},
+//		{"name":"cache","status":"fail","error":"timeout","latency_ms":1000}
+//	]}
+//
+// Panics if a pattern is already registered or a check has no name, no Func
+// or the name of another check.
+func (mux *Mux) HandleHealth(live, ready string, checks ...Check) {
+	names := make(map[string]bool)
+	for _, c := range checks {
+		if c.Name == "" {
+			panic("mux: check without name")
+		}
+		if c.Func == nil {
+			panic("mux: nil check func for " + c.Name)
+		}
+		if names[c.Name] {
+			panic("mux: multiple checks named " + c.Name)
+		}
+		names[c.Name] = true
+	}
+	checks = append([]Check(nil), checks...)
+
+	mux.HandleFunc(live, func(w http.ResponseWriter, r *http.Request) {
+		writeHealth(w, http.StatusOK, health{Status: "ok"})
+	}, Quiet())
+	mux.HandleFunc(ready, func(w http.ResponseWriter, r *http.Request) {
+		h := runChecks(r.Context(), checks)
+		code := http.StatusOK
+		if h.Status != "ok" {
+			code = http.StatusServiceUnavailable
+		}
+		writeHealth(w, code, h)
+	}, Quiet())
+}
+
+// health is the JSON body of the health endpoints.
+type health struct {
+	Status string        `json:"status"`
+	Checks []checkResult `json:"checks,omitempty"`
+}
+
+// checkResult is the result of a Check.
+type checkResult struct {
+	Name    string  `json:"name"`
+	Status  string  `json:"status"`
+	Error   string  `json:"error,omitempty"`
+	Latency float64 `json:"latency_ms"`
+}
+
+// runChecks runs the checks concurrently and returns their results.
+func runChecks(ctx context.Context, checks []Check) health {
+	type indexedResult struct {
+		i int
+		checkResult
+	}
+
+	results := make(chan indexedResult, len(checks))
+	for i, c := range checks {
+		go func(i int, c Check) {
+			ctx, cancel := context.WithTimeout(ctx, c.timeout())
+			defer cancel()
+
+			start := time.Now()
+			errc := make(chan error, 1) // lets checks ignoring ctx finish late
+			go func() {
+				errc <- c.Func(ctx)
+			}()
+
+			var err error
+			select {
+			case err = <-errc:
+			case <-ctx.Done():
+				err = errors.New("timeout")
+			}
+
+			res := checkResult{Name: c.Name, Status: "ok", Latency: milliseconds(time.Since(start))}
+			if err != nil {
+				res.Status, res.Error = "fail", err.Error()
+			}
+			results <- indexedResult{i, res}
+		}(i, c)
+	}
+
+	h := health{Status: "ok", Checks: make([]checkResult, len(checks))}
+	for range checks {
+		res := <-results
+		h.Checks[res.i] = res.checkResult
+		if res.Status != "ok" {
+			h.Status = "fail"
+		}
+	}
+	return h
+}
+
+// milliseconds returns d in milliseconds.
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// writeHealth replies with the given status code and h as JSON.
+func writeHealth(w http.ResponseWriter, code int, h health) {
+	b, err := json.Marshal(h)
+	if err != nil {
+		panic(err)
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Content-Length", strconv.Itoa(len(b)))
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	w.Write(b)
+}
@@ -0,0 +1,175 @@
+package mux_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestHandleHealth(t *testing.T) {
+	ok := mux.Check{Name: "ok", Func: func(ctx context.Context) error {
+		return nil
+	}}
+	failing := mux.Check{Name: "failing", Func: func(ctx context.Context) error {
+		return errors.New("down")
+	}}
+	slow := mux.Check{Name: "slow", Timeout: 10 * time.Millisecond, Func: func(ctx context.Context) error {
+		time.Sleep(time.Second) // ignores ctx
+		return nil
+	}}
+
+	type result struct {
+		Name   string `json:"name"`
+		Status string `json:"status"`
+		Error  string `json:"error"`
+	}
+	type body struct {
+		Status string   `json:"status"`
+		Checks []result `json:"checks"`
+	}
+
+	cases := []struct {
+		name   string
+		path   string
+		checks []mux.Check
+		code   int
+		body   body
+	}{
+		{
+			"live",
+			"/healthz",
+			[]mux.Check{failing},
+			http.StatusOK,
+			body{Status: "ok"},
+		},
+		{
+			"ready",
+			"/readyz",
+			[]mux.Check{ok},
+			http.StatusOK,
+			body{"ok", []result{{"ok", "ok", ""}}},
+		},
+		{
+			"failing",
+			"/readyz",
+			[]mux.Check{ok, failing},
+			http.StatusServiceUnavailable,
+			body{"fail", []result{{"ok", "ok", ""}, {"failing", "fail", "down"}}},
+		},
+		{
+			"timeout",
+			"/readyz",
+			[]mux.Check{slow, ok},
+			http.StatusServiceUnavailable,
+			body{"fail", []result{{"slow", "fail", "timeout"}, {"ok", "ok", ""}}},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			m := mux.New()
+			m.HandleHealth("/healthz", "/readyz", c.checks...)
+
+			r := httptest.NewRequest(http.MethodGet, c.path, nil)
+			rec := httptest.NewRecorder()
+			start := time.Now()
+			m.ServeHTTP(rec, r)
+			resp := rec.Result()
+
+			if d := time.Since(start); d > 500*time.Millisecond {
+				t.Errorf("took %s, want at most the check timeout", d)
+			}
+
+			if resp.StatusCode != c.code {
+				t.Errorf("got StatusCode %d, want %d", resp.StatusCode, c.code)
+			}
+
+			contentType := resp.Header.Get("Content-Type")
+			if contentType != "application/json; charset=utf-8" {
+				t.Errorf("got Content-Type %q, want application/json", contentType)
+			}
+
+			var b body
+			if err := json.NewDecoder(resp.Body).Decode(&b); err != nil {
+				t.Fatal(err)
+			}
+
+			if b.Status != c.body.Status || len(b.Checks) != len(c.body.Checks) {
+				t.Fatalf("got body %+v, want %+v", b, c.body)
+			}
+			for i := range b.Checks {
+				if b.Checks[i] != c.body.Checks[i] {
+					t.Errorf("got check %+v, want %+v", b.Checks[i], c.body.Checks[i])
+				}
+			}
+		})
+	}
+
+	t.Run("quiet", func(t *testing.T) {
+		m := mux.New()
+		m.HandleHealth("/healthz", "/readyz")
+		m.HandleFunc("/a", handlerFactory(http.StatusTeapot, "a"))
+
+		quiet := make(map[string]bool)
+		m.WrapAll(func(pattern string, h http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				quiet[pattern] = mux.IsQuiet(r)
+				h.ServeHTTP(w, r)
+			})
+		})
+
+		want := map[string]bool{"/healthz": true, "/readyz": true, "/a": false}
+		for path := range want {
+			r := httptest.NewRequest(http.MethodGet, path, nil)
+			m.ServeHTTP(httptest.NewRecorder(), r)
+		}
+
+		for path, w := range want {
+			if quiet[path] != w {
+				t.Errorf("got IsQuiet %t for %s, want %t", quiet[path], path, w)
+			}
+		}
+	})
+
+	t.Run("red", func(t *testing.T) {
+		cases := []struct {
+			name   string
+			checks []mux.Check
+		}{
+			{"no name", []mux.Check{{Func: ok.Func}}},
+			{"nil func", []mux.Check{{Name: "a"}}},
+			{"duplicate name", []mux.Check{ok, ok}},
+		}
+
+		for _, c := range cases {
+			t.Run(c.name, func(t *testing.T) {
+				defer func() {
+					if recover() == nil {
+						t.Error("got no panic, want panic")
+					}
+				}()
+
+				m := mux.New()
+				m.HandleHealth("/healthz", "/readyz", c.checks...)
+			})
+		}
+
+		t.Run("conflict", func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("got no panic, want panic")
+				}
+			}()
+
+			m := mux.New()
+			m.HandleFunc("/readyz", handlerFactory(http.StatusTeapot, ""))
+			m.HandleHealth("/healthz", "/readyz")
+		})
+	})
+}
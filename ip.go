@@ -0,0 +1,231 @@
+package mux
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ipNets are IP networks.
+type ipNets []*net.IPNet
+
+// parseIPNets parses CIDRs like "10.0.0.0/8", or single IP addresses.
+// Panics if a CIDR is invalid.
+func parseIPNets(cidrs []string) ipNets {
+	nets := make(ipNets, len(cidrs))
+	for i, cidr := range cidrs {
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				panic("mux: invalid IP address " + cidr)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			nets[i] = &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+			continue
+		}
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic("mux: invalid CIDR " + cidr)
+		}
+		nets[i] = n
+	}
+	return nets
+}
+
+// contain reports whether one of nets contains ip.
+func (nets ipNets) contain(ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// TrustProxies makes mux take the IP address of the client of requests from
+// the given proxies, by CIDR like "10.0.0.0/8" or IP address, from the
+// headers the proxies set, so that ClientIP, AllowCIDR and DenyCIDR, rate
+// limiting by ByRemoteIP and RequestInfo agree on the client:
+//
+//   - Forwarded, the "for" parameters of its elements,
+//   - X-Forwarded-For, if the request has no Forwarded header, or
+//   - X-Real-IP, if the request has neither.
+//
+// Proxies append the address of their peer to Forwarded and X-Forwarded-For,
+// so these are read from the end and the client is the last address not of
+// a trusted proxy; clients cannot pose as others by sending a header of their
+// own. Addresses before one that is not a valid IP address, like "unknown"
+// or an obfuscated identifier, are ignored. Requests from other peers are
+// taken to come from the peer.
+//
+// Panics if a CIDR is invalid.
+func TrustProxies(cidrs ...string) Option {
+	proxies := parseIPNets(cidrs)
+	return func(mux *Mux) {
+		mux.proxies = proxies
+	}
+}
+
+// clientIPKey is the context key for the IP address of the client of a
+// request from a trusted proxy.
+type clientIPKey struct{}
+
+// withClientIP returns a shallow copy of r with the IP address of its client
+// if its peer is one of proxies, and r otherwise. Requests with the address
+// already set by a mux they were passed on from keep it.
+func withClientIP(r *http.Request, proxies ipNets) *http.Request {
+	if _, ok := r.Context().Value(clientIPKey{}).(net.IP); ok {
+		return r
+	}
+	ip := remoteIP(r)
+	if ip == nil || !proxies.contain(ip) {
+		return r
+	}
+
+	if forwarded := r.Header.Values("Forwarded"); len(forwarded) > 0 {
+		ip = lastUntrusted(forwardedFor(forwarded), ip, proxies)
+	} else if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		ip = lastUntrusted(splitList(forwarded), ip, proxies)
+	} else if realIP := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); realIP != nil {
+		ip = realIP
+	}
+	return r.WithContext(context.WithValue(r.Context(), clientIPKey{}, ip))
+}
+
+// lastUntrusted returns the last of the addresses hops that is not of one of
+// proxies, or the first if all are, for a request from the trusted proxy with
+// the address peer. Addresses before an invalid one are ignored, so the last
+// address read is returned.
+func lastUntrusted(hops []string, peer net.IP, proxies ipNets) net.IP {
+	ip := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(hops[i])
+		if hop == nil {
+			return ip
+		}
+		ip = hop
+		if !proxies.contain(ip) {
+			return ip
+		}
+	}
+	return ip
+}
+
+// splitList returns the elements of the comma-separated header values.
+func splitList(values []string) []string {
+	var list []string
+	for _, v := range values {
+		for _, e := range strings.Split(v, ",") {
+			list = append(list, strings.TrimSpace(e))
+		}
+	}
+	return list
+}
+
+// forwardedFor returns the addresses of the "for" parameters of the elements
+// of the Forwarded header values, without quotes, brackets and ports, or ""
+// for elements without one.
+func forwardedFor(values []string) []string {
+	var hops []string
+	for _, element := range splitList(values) {
+		var hop string
+		for _, pair := range strings.Split(element, ";") {
+			pair = strings.TrimSpace(pair)
+			if len(pair) > 4 && strings.EqualFold(pair[:4], "for=") {
+				hop = strings.Trim(pair[4:], `"`)
+			}
+		}
+		if strings.HasPrefix(hop, "[") {
+			if i := strings.IndexByte(hop, ']'); i > 0 {
+				hop = hop[1:i]
+			}
+		} else if host, _, err := net.SplitHostPort(hop); err == nil {
+			hop = host
+		}
+		hops = append(hops, hop)
+	}
+	return hops
+}
+
+// ClientIP returns the IP address of the client of r: that of its peer or,
+// for requests from proxies trusted with TrustProxies, the address the
+// proxies forwarded. It returns "" if the address of the peer is not an IP
+// address, as for requests over a Unix socket.
+func ClientIP(r *http.Request) string {
+	ip := clientIP(r)
+	if ip == nil {
+		return ""
+	}
+	return ip.String()
+}
+
+// clientIP returns the IP address of the client of r, as ClientIP, or nil if
+// it is not known.
+func clientIP(r *http.Request) net.IP {
+	if ip, ok := r.Context().Value(clientIPKey{}).(net.IP); ok {
+		return ip
+	}
+	return remoteIP(r)
+}
+
+// remoteIP returns the IP address of the peer of r or nil if it is not
+// known.
+func remoteIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return net.ParseIP(host)
+}
+
+// AllowCIDR restricts the route to clients with IP addresses in the given
+// CIDRs, like "10.0.0.0/8", or IP addresses, such as for admin and metrics
+// endpoints. Requests from other clients, or clients whose address is not
+// known, are answered with 403 Forbidden. The address of clients behind
+// proxies is taken from the X-Forwarded-For header only if the proxies are
+// trusted with TrustProxies.
+//
+// Panics if no CIDRs are given or a CIDR is invalid.
+func AllowCIDR(cidrs ...string) RouteOption {
+	if len(cidrs) == 0 {
+		panic("mux: no CIDRs")
+	}
+	allowed := parseIPNets(cidrs)
+	return restrictIP(func(ip net.IP) bool {
+		return ip != nil && allowed.contain(ip)
+	})
+}
+
+// DenyCIDR answers requests to the route from clients with IP addresses in
+// the given CIDRs, like "203.0.113.0/24", or IP addresses with 403
+// Forbidden, as AllowCIDR does those from other clients.
+//
+// Panics if no CIDRs are given or a CIDR is invalid.
+func DenyCIDR(cidrs ...string) RouteOption {
+	if len(cidrs) == 0 {
+		panic("mux: no CIDRs")
+	}
+	denied := parseIPNets(cidrs)
+	return restrictIP(func(ip net.IP) bool {
+		return ip == nil || !denied.contain(ip)
+	})
+}
+
+// restrictIP returns a RouteOption answering requests from clients whose IP
+// address, nil if it is not known, allows does not allow with 403 Forbidden.
+func restrictIP(allows func(ip net.IP) bool) RouteOption {
+	return func(mux *Mux, e *muxEntry) {
+		next := e.handler
+		e.handler = func(w http.ResponseWriter, r *http.Request) {
+			if !allows(clientIP(r)) {
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+			next(w, r)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package mux_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestAllowCIDR(t *testing.T) {
+	m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found")), mux.TrustProxies("10.0.0.1", "192.168.0.0/16"))
+	m.Get("/metrics", handlerFactory(http.StatusOK, "metrics"), mux.AllowCIDR("10.0.0.0/8", "2001:db8::/32"))
+	m.Get("/public", handlerFactory(http.StatusOK, "public"), mux.DenyCIDR("203.0.113.0/24"))
+
+	cases := []struct {
+		name         string
+		path         string
+		remoteAddr   string
+		forwardedFor []string
+		code         int
+	}{
+		{"allowed", "/metrics", "10.1.2.3:1234", nil, http.StatusOK},
+		{"allowed IPv6", "/metrics", "[2001:db8::1]:1234", nil, http.StatusOK},
+		{"not allowed", "/metrics", "198.51.100.1:1234", nil, http.StatusForbidden},
+		{"unknown", "/metrics", "pipe", nil, http.StatusForbidden},
+		{"forged header", "/metrics", "198.51.100.1:1234", []string{"10.1.2.3"}, http.StatusForbidden},
+		{"trusted proxy", "/metrics", "10.0.0.1:1234", []string{"10.1.2.3"}, http.StatusOK},
+		{"trusted proxy outside", "/metrics", "10.0.0.1:1234", []string{"198.51.100.1"}, http.StatusForbidden},
+		{"proxy chain", "/metrics", "192.168.1.1:1234", []string{"10.1.2.3, 10.0.0.1"}, http.StatusOK},
+		{"spoofed chain", "/metrics", "10.0.0.1:1234", []string{"10.1.2.3, 198.51.100.1"}, http.StatusForbidden},
+		{"multiple headers", "/metrics", "10.0.0.1:1234", []string{"198.51.100.1", "10.1.2.3"}, http.StatusOK},
+		{"invalid hop", "/metrics", "10.0.0.1:1234", []string{"garbage"}, http.StatusOK},
+		{"denied", "/public", "203.0.113.7:1234", nil, http.StatusForbidden},
+		{"denied behind proxy", "/public", "10.0.0.1:1234", []string{"203.0.113.7"}, http.StatusForbidden},
+		{"not denied", "/public", "198.51.100.1:1234", nil, http.StatusOK},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, c.path, nil)
+			r.RemoteAddr = c.remoteAddr
+			for _, v := range c.forwardedFor {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+			if rec.Code != c.code {
+				t.Errorf("got StatusCode %d, want %d", rec.Code, c.code)
+			}
+		})
+	}
+
+	for _, f := range []func(){
+		func() { mux.AllowCIDR() },
+		func() { mux.AllowCIDR("10.0.0.0/33") },
+		func() { mux.DenyCIDR("not an IP") },
+		func() { mux.TrustProxies("10.0.0") },
+	} {
+		t.Run("invalid", func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("got no panic, want panic")
+				}
+			}()
+			f()
+		})
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	m := mux.New(mux.TrustProxies("10.0.0.0/8"))
+	m.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, mux.ClientIP(r)+" "+mux.ByRemoteIP(r))
+	})
+
+	cases := []struct {
+		name       string
+		remoteAddr string
+		header     http.Header
+		ip         string
+	}{
+		{"peer", "198.51.100.1:1234", nil, "198.51.100.1"},
+		{"untrusted peer", "198.51.100.1:1234", http.Header{"X-Forwarded-For": {"192.0.2.1"}, "X-Real-Ip": {"192.0.2.1"}}, "198.51.100.1"},
+		{"X-Forwarded-For", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"192.0.2.1, 10.0.0.2"}}, "192.0.2.1"},
+		{"X-Real-IP", "10.0.0.1:1234", http.Header{"X-Real-Ip": {"192.0.2.1"}}, "192.0.2.1"},
+		{"Forwarded", "10.0.0.1:1234", http.Header{"Forwarded": {`for=192.0.2.1;proto=https, for="10.0.0.2:8080"`}}, "192.0.2.1"},
+		{"Forwarded IPv6", "10.0.0.1:1234", http.Header{"Forwarded": {`For="[2001:db8::17]:4711"`}}, "2001:db8::17"},
+		{"Forwarded first", "10.0.0.1:1234", http.Header{"Forwarded": {"for=192.0.2.1"}, "X-Forwarded-For": {"192.0.2.2"}}, "192.0.2.1"},
+		{"Forwarded unknown", "10.0.0.1:1234", http.Header{"Forwarded": {"for=192.0.2.1, for=unknown, for=10.0.0.2"}}, "10.0.0.2"},
+		{"all trusted", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"10.0.0.3, 10.0.0.2"}}, "10.0.0.3"},
+		{"trusted peer only", "10.0.0.1:1234", nil, "10.0.0.1"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = c.remoteAddr
+			for name, values := range c.header {
+				r.Header[name] = values
+			}
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+			if want := c.ip + " " + c.ip; rec.Body.String() != want {
+				t.Errorf("got %q, want %q", rec.Body.String(), want)
+			}
+		})
+	}
+
+	t.Run("unknown", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = "@"
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, r)
+		if want := " @"; rec.Body.String() != want {
+			t.Errorf("got %q, want %q", rec.Body.String(), want)
+		}
+	})
+}
@@ -0,0 +1,594 @@
+package mux
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha512" // registers SHA-384 and SHA-512
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JWT configures the validation of JSON Web Tokens by RequireJWT. At least
+// one of Key, Keys and JWKSURL must be set.
+type JWT struct {
+	// Key verifies tokens whose key ID, if any, is not in Keys, unless
+	// JWKSURL is set: a []byte secret for the HS256, HS384 and HS512
+	// algorithms, an *rsa.PublicKey for RS256 to RS512 and PS256 to PS512
+	// or an *ecdsa.PublicKey for ES256, ES384 and ES512.
+	Key interface{}
+
+	// Keys verify tokens by the key ID in their "kid" header, as Key.
+	Keys map[string]interface{}
+
+	// JWKSURL is the URL of a JSON Web Key Set with the keys verifying
+	// tokens by key ID whose key ID is not in Keys. A set with a single key
+	// also verifies tokens without a key ID.
+	JWKSURL string
+
+	// JWKSRefresh is how often the key set is fetched again, an hour if
+	// zero. Tokens with a key ID not in the set have it fetched again at
+	// most once a minute, so that rotated keys are picked up. The keys
+	// fetched last are kept if fetching fails and verify tokens while the
+	// set is fetched again.
+	JWKSRefresh time.Duration
+
+	// Client fetches the key set. If nil, a client with a timeout of ten
+	// seconds is used.
+	Client *http.Client
+
+	// Algorithms, if not empty, restricts the algorithms of tokens to these.
+	// The algorithm must match the type of the key in any case and "none"
+	// is never accepted.
+	Algorithms []string
+
+	// Issuer and Audience, unless empty, must be the "iss" claim and one of
+	// the "aud" claim of tokens.
+	Issuer   string
+	Audience string
+
+	// Leeway is the clock skew allowed in checking the "exp" and "nbf"
+	// claims.
+	Leeway time.Duration
+
+	// Unauthorized handles requests without a valid token, with the
+	// WWW-Authenticate header set. If nil, they are answered with 401
+	// Unauthorized.
+	Unauthorized http.HandlerFunc
+}
+
+// JWTClaims are the claims of a JSON Web Token. Numbers are float64.
+type JWTClaims map[string]interface{}
+
+// jwksMinRefresh is how often at most a key set is fetched for tokens with
+// unknown key IDs.
+const jwksMinRefresh = time.Minute
+
+// RequireJWT returns a middleware, for Use, WithMiddleware or a Group,
+// requiring a JSON Web Token verified according to c in an "Authorization:
+// Bearer" header. Requests without a valid token are answered with 401
+// Unauthorized. Handlers get the claims of the token with Claims.
+//
+// It also enforces the scopes the route requires: if the route has the
+// metadata "scopes", attached with Meta as a []string or a space-separated
+// string, the token must grant all of them in its "scope" claim, a
+// space-separated string, or "scp" claim, a list, and requests with tokens
+// that do not are answered with 403 Forbidden:
+//
+//	m.Get("/reports", reports,
+//		mux.Meta("scopes", []string{"reports:read"}),
+//		mux.WithMiddleware(mux.RequireJWT(c)))
+//
+// As middleware added with Use runs before the request is routed, the scopes
+// are then enforced once it is, before the route middleware.
+//
+// Panics if no key is configured or a key is not of a supported type or is
+// empty.
+func RequireJWT(c JWT) func(http.Handler) http.Handler {
+	if c.Key == nil && len(c.Keys) == 0 && c.JWKSURL == "" {
+		panic("mux: no JWT keys")
+	}
+	if c.Key != nil {
+		checkJWTKey(c.Key)
+	}
+	for _, key := range c.Keys {
+		checkJWTKey(key)
+	}
+	v := &jwtVerifier{JWT: c}
+	if v.Unauthorized == nil {
+		v.Unauthorized = func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		}
+	}
+	if c.JWKSURL != "" {
+		v.jwks = &jwks{url: c.JWKSURL, client: c.Client, refresh: c.JWKSRefresh}
+		if v.jwks.client == nil {
+			v.jwks.client = &http.Client{Timeout: 10 * time.Second}
+		}
+		if v.jwks.refresh <= 0 {
+			v.jwks.refresh = time.Hour
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := bearerToken(r)
+			if !ok {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				v.Unauthorized(w, r)
+				return
+			}
+			claims, err := v.verify(token, time.Now())
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				v.Unauthorized(w, r)
+				return
+			}
+			if !claims.allowScopes(w, RouteMeta(r, "scopes")) {
+				return
+			}
+			next.ServeHTTP(w, withClaims(r, claims))
+		})
+	}
+}
+
+// checkJWTKey panics if key is not of a type verifying tokens or is an empty
+// secret.
+func checkJWTKey(key interface{}) {
+	switch key := key.(type) {
+	case []byte:
+		if len(key) == 0 {
+			panic("mux: empty JWT key")
+		}
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+	default:
+		panic(fmt.Sprintf("mux: unsupported JWT key type %T", key))
+	}
+}
+
+// jwtVerifier verifies tokens according to its JWT.
+type jwtVerifier struct {
+	JWT
+	jwks *jwks // key set if JWKSURL is set
+}
+
+// verify returns the claims of token if it is valid at time now.
+func (v *jwtVerifier) verify(token string, now time.Time) (JWTClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("mux: malformed JWT")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeJWTSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	if len(v.Algorithms) > 0 && !contains(v.Algorithms, header.Alg) {
+		return nil, errors.New("mux: JWT algorithm not allowed: " + header.Alg)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, err
+	}
+	key, err := v.key(header.Kid, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyJWTSignature(header.Alg, key, parts[0]+"."+parts[1], sig); err != nil {
+		return nil, err
+	}
+
+	var claims JWTClaims
+	if err := decodeJWTSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	if err := v.validate(claims, now); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// key returns the key verifying tokens with the key ID kid.
+func (v *jwtVerifier) key(kid string, now time.Time) (interface{}, error) {
+	if key, ok := v.Keys[kid]; ok && kid != "" {
+		return key, nil
+	}
+	if v.jwks != nil {
+		return v.jwks.key(kid, now)
+	}
+	if v.Key != nil {
+		return v.Key, nil
+	}
+	return nil, errors.New("mux: unknown JWT key ID " + kid)
+}
+
+// validate checks the time, issuer and audience claims at time now.
+func (v *jwtVerifier) validate(claims JWTClaims, now time.Time) error {
+	if exp, ok := claims["exp"]; ok {
+		t, ok := exp.(float64)
+		if !ok || now.Add(-v.Leeway).After(time.Unix(int64(t), 0)) {
+			return errors.New("mux: JWT expired")
+		}
+	}
+	if nbf, ok := claims["nbf"]; ok {
+		t, ok := nbf.(float64)
+		if !ok || now.Add(v.Leeway).Before(time.Unix(int64(t), 0)) {
+			return errors.New("mux: JWT not valid yet")
+		}
+	}
+	if v.Issuer != "" && claims["iss"] != v.Issuer {
+		return errors.New("mux: JWT issuer mismatch")
+	}
+	if v.Audience != "" && !contains(claims.strings("aud"), v.Audience) {
+		return errors.New("mux: JWT audience mismatch")
+	}
+	return nil
+}
+
+// strings returns the claim name as a list of strings: a string claim as a
+// list of one and a list claim without its elements that are not strings.
+func (c JWTClaims) strings(name string) []string {
+	switch v := c[name].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var list []string
+		for _, e := range v {
+			if s, ok := e.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
+
+// allowScopes reports whether c grants the scopes required, a []string or a
+// space-separated string, answering the request with 403 Forbidden if not.
+func (c JWTClaims) allowScopes(w http.ResponseWriter, required interface{}) bool {
+	missing := c.missingScopes(required)
+	if len(missing) == 0 {
+		return true
+	}
+	w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+strings.Join(missing, " ")+`"`)
+	http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+	return false
+}
+
+// missingScopes returns the scopes required, a []string or a space-separated
+// string, that c does not grant.
+func (c JWTClaims) missingScopes(required interface{}) []string {
+	var scopes []string
+	switch v := required.(type) {
+	case []string:
+		scopes = v
+	case string:
+		scopes = strings.Fields(v)
+	}
+	if len(scopes) == 0 {
+		return nil
+	}
+
+	granted := c.strings("scp")
+	if scope, ok := c["scope"].(string); ok {
+		granted = append(granted, strings.Fields(scope)...)
+	}
+	var missing []string
+	for _, scope := range scopes {
+		if !contains(granted, scope) {
+			missing = append(missing, scope)
+		}
+	}
+	return missing
+}
+
+// contains reports whether list contains s.
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeJWTSegment decodes the base64url-encoded JSON segment of a token into
+// v.
+func decodeJWTSegment(segment string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// verifyJWTSignature verifies the signature sig of the signed part of a token
+// with key by the algorithm alg, which must match the type of key.
+func verifyJWTSignature(alg string, key interface{}, signed string, sig []byte) error {
+	var hash crypto.Hash
+	switch {
+	case len(alg) != 5:
+	case strings.HasSuffix(alg, "256"):
+		hash = crypto.SHA256
+	case strings.HasSuffix(alg, "384"):
+		hash = crypto.SHA384
+	case strings.HasSuffix(alg, "512"):
+		hash = crypto.SHA512
+	}
+	if hash == 0 {
+		return errors.New("mux: unsupported JWT algorithm " + alg)
+	}
+
+	invalid := errors.New("mux: invalid JWT signature")
+	switch alg[:2] {
+	case "HS":
+		secret, ok := key.([]byte)
+		if !ok {
+			return invalid
+		}
+		mac := hmac.New(hash.New, secret)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(mac.Sum(nil), sig) {
+			return invalid
+		}
+		return nil
+	}
+
+	h := hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+	switch alg[:2] {
+	case "RS", "PS":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return invalid
+		}
+		if alg[0] == 'R' {
+			return rsa.VerifyPKCS1v15(pub, hash, digest, sig)
+		}
+		return rsa.VerifyPSS(pub, hash, digest, sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+	case "ES":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return invalid
+		}
+		params := pub.Curve.Params()
+		size := (params.BitSize + 7) / 8
+		if curveHashes[params.Name] != hash || len(sig) != 2*size {
+			return invalid
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return invalid
+		}
+		return nil
+	}
+	return errors.New("mux: unsupported JWT algorithm " + alg)
+}
+
+// curveHashes are the hashes of the ES algorithms by the name of their curve.
+var curveHashes = map[string]crypto.Hash{
+	"P-256": crypto.SHA256,
+	"P-384": crypto.SHA384,
+	"P-521": crypto.SHA512,
+}
+
+// jwks is a JSON Web Key Set fetched from a URL.
+type jwks struct {
+	url     string
+	client  *http.Client
+	refresh time.Duration
+
+	mu       sync.Mutex
+	keys     map[string]interface{} // keys by key ID
+	err      error                  // error of the last fetch
+	fetched  time.Time              // time the set was last fetched
+	fetching chan struct{}          // closed when the fetch in progress ends, nil if none
+}
+
+// key returns the key of the set with the key ID kid at time now, fetching
+// the set if it is due. The set is fetched once at a time without holding
+// the lock, so keys already known keep being returned while it is; only
+// callers needing a key that is not known wait for the new set.
+func (s *jwks) key(kid string, now time.Time) (interface{}, error) {
+	s.mu.Lock()
+	key, ok := s.lookup(kid)
+	age := now.Sub(s.fetched)
+	if !s.fetched.IsZero() && age < s.refresh && (ok || age < jwksMinRefresh) {
+		err := s.err
+		s.mu.Unlock()
+		return keyResult(key, ok, kid, err)
+	}
+	done := s.fetching
+	if done == nil {
+		done = make(chan struct{})
+		s.fetching, s.fetched = done, now
+		go s.update(done)
+	}
+	s.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	<-done
+	s.mu.Lock()
+	key, ok = s.lookup(kid)
+	err := s.err
+	s.mu.Unlock()
+	return keyResult(key, ok, kid, err)
+}
+
+// update fetches the set, keeping the keys it had if the fetch fails, and
+// closes done.
+func (s *jwks) update(done chan struct{}) {
+	keys, err := s.fetch()
+
+	s.mu.Lock()
+	if err == nil {
+		s.keys = keys
+	}
+	s.err, s.fetching = err, nil
+	s.mu.Unlock()
+	close(done)
+}
+
+// keyResult returns key if ok and otherwise an error for the key ID kid, err
+// if the set could not be fetched.
+func keyResult(key interface{}, ok bool, kid string, err error) (interface{}, error) {
+	if ok {
+		return key, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return nil, errors.New("mux: unknown JWT key ID " + kid)
+}
+
+// lookup returns the key with the key ID kid or, if kid is empty, the only
+// key of the set.
+func (s *jwks) lookup(kid string) (interface{}, bool) {
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+// fetch fetches the keys of the set. Keys that are not for signatures or of
+// unsupported types are skipped. The set is not fetched with the context of
+// the request needing it, as its keys serve other requests too.
+func (s *jwks) fetch() (map[string]interface{}, error) {
+	resp, err := s.client.Get(s.url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("mux: fetching JWKS: %s", resp.Status)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]interface{}, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		if key, err := k.publicKey(); err == nil {
+			keys[k.Kid] = key
+		}
+	}
+	return keys, nil
+}
+
+// jwk is a JSON Web Key.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+// publicKey returns the key verifying tokens k describes.
+func (k jwk) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeJWKInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeJWKInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("mux: invalid JWK exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, errors.New("mux: unsupported JWK curve " + k.Crv)
+		}
+		x, err := decodeJWKInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeJWKInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("mux: invalid JWK point")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "oct":
+		secret, err := base64.RawURLEncoding.DecodeString(k.K)
+		if err != nil {
+			return nil, err
+		}
+		if len(secret) == 0 {
+			return nil, errors.New("mux: empty JWK key")
+		}
+		return secret, nil
+	}
+	return nil, errors.New("mux: unsupported JWK type " + k.Kty)
+}
+
+// decodeJWKInt decodes the base64url-encoded big-endian integer s.
+func decodeJWKInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("mux: empty JWK integer")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// claimsKey is the context key for the claims of a request.
+type claimsKey struct{}
+
+// withClaims returns a shallow copy of r with the given claims.
+func withClaims(r *http.Request, claims JWTClaims) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), claimsKey{}, claims))
+}
+
+// Claims returns the claims of the token of a request authenticated by
+// RequireJWT or nil if there are none.
+func Claims(r *http.Request) JWTClaims {
+	claims, _ := r.Context().Value(claimsKey{}).(JWTClaims)
+	return claims
+}
@@ -0,0 +1,311 @@
+package mux_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/touchmarine/mux"
+)
+
+// signJWT returns a token with the given claims signed with key by alg, one
+// of HS256, RS256, PS256, ES256 and none.
+func signJWT(t *testing.T, alg, kid string, key interface{}, claims map[string]interface{}) string {
+	t.Helper()
+	header := map[string]string{"alg": alg, "typ": "JWT"}
+	if kid != "" {
+		header["kid"] = kid
+	}
+	encode := func(v interface{}) string {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	signed := encode(header) + "." + encode(claims)
+
+	digest := sha256.Sum256([]byte(signed))
+	var sig []byte
+	var err error
+	switch alg {
+	case "HS256":
+		mac := hmac.New(sha256.New, key.([]byte))
+		mac.Write([]byte(signed))
+		sig = mac.Sum(nil)
+	case "RS256":
+		sig, err = rsa.SignPKCS1v15(rand.Reader, key.(*rsa.PrivateKey), crypto.SHA256, digest[:])
+	case "PS256":
+		sig, err = rsa.SignPSS(rand.Reader, key.(*rsa.PrivateKey), crypto.SHA256, digest[:], &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+	case "ES256":
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, key.(*ecdsa.PrivateKey), digest[:])
+		if err == nil {
+			sig = make([]byte, 64)
+			r.FillBytes(sig[:32])
+			s.FillBytes(sig[32:])
+		}
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func claimsHandler(w http.ResponseWriter, r *http.Request) {
+	io.WriteString(w, fmt.Sprint(mux.Claims(r)["sub"]))
+}
+
+func TestRequireJWT(t *testing.T) {
+	secret := []byte("secret")
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	auth := mux.RequireJWT(mux.JWT{
+		Key:      secret,
+		Keys:     map[string]interface{}{"rsa": &rsaKey.PublicKey, "ec": &ecKey.PublicKey},
+		Issuer:   "issuer",
+		Audience: "api",
+	})
+	m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found")))
+	m.Get("/me", claimsHandler, mux.WithMiddleware(auth))
+	m.Get("/reports", claimsHandler, mux.Meta("scopes", []string{"reports:read"}), mux.WithMiddleware(auth))
+	m.Get("/admin", claimsHandler, mux.Meta("scopes", "admin reports:read"), mux.WithMiddleware(auth))
+
+	now := time.Now().Unix()
+	valid := map[string]interface{}{"sub": "alice", "iss": "issuer", "aud": []string{"web", "api"}, "exp": now + 60, "scope": "reports:read"}
+	with := func(name string, value interface{}) map[string]interface{} {
+		c := make(map[string]interface{})
+		for k, v := range valid {
+			c[k] = v
+		}
+		if value == nil {
+			delete(c, name)
+		} else {
+			c[name] = value
+		}
+		return c
+	}
+
+	cases := []struct {
+		name      string
+		path      string
+		token     string
+		code      int
+		challenge string
+	}{
+		{"HS256", "/me", signJWT(t, "HS256", "", secret, valid), http.StatusOK, ""},
+		{"RS256", "/me", signJWT(t, "RS256", "rsa", rsaKey, valid), http.StatusOK, ""},
+		{"PS256", "/me", signJWT(t, "PS256", "rsa", rsaKey, valid), http.StatusOK, ""},
+		{"ES256", "/me", signJWT(t, "ES256", "ec", ecKey, valid), http.StatusOK, ""},
+		{"no token", "/me", "", http.StatusUnauthorized, "Bearer"},
+		{"wrong secret", "/me", signJWT(t, "HS256", "", []byte("guess"), valid), http.StatusUnauthorized, `Bearer error="invalid_token"`},
+		{"wrong key", "/me", signJWT(t, "ES256", "rsa", ecKey, valid), http.StatusUnauthorized, `Bearer error="invalid_token"`},
+		{"unknown key", "/me", signJWT(t, "RS256", "other", rsaKey, valid), http.StatusUnauthorized, `Bearer error="invalid_token"`},
+		{"none", "/me", signJWT(t, "none", "", nil, valid), http.StatusUnauthorized, `Bearer error="invalid_token"`},
+		{"malformed", "/me", "a.b", http.StatusUnauthorized, `Bearer error="invalid_token"`},
+		{"expired", "/me", signJWT(t, "HS256", "", secret, with("exp", now-60)), http.StatusUnauthorized, `Bearer error="invalid_token"`},
+		{"not yet valid", "/me", signJWT(t, "HS256", "", secret, with("nbf", now+60)), http.StatusUnauthorized, `Bearer error="invalid_token"`},
+		{"wrong issuer", "/me", signJWT(t, "HS256", "", secret, with("iss", "other")), http.StatusUnauthorized, `Bearer error="invalid_token"`},
+		{"wrong audience", "/me", signJWT(t, "HS256", "", secret, with("aud", "web")), http.StatusUnauthorized, `Bearer error="invalid_token"`},
+		{"scope", "/reports", signJWT(t, "HS256", "", secret, valid), http.StatusOK, ""},
+		{"scp", "/reports", signJWT(t, "HS256", "", secret, with("scp", []string{"reports:read"})), http.StatusOK, ""},
+		{"missing scope", "/admin", signJWT(t, "HS256", "", secret, valid), http.StatusForbidden, `Bearer error="insufficient_scope", scope="admin"`},
+		{"no scope", "/reports", signJWT(t, "HS256", "", secret, with("scope", nil)), http.StatusForbidden, `Bearer error="insufficient_scope", scope="reports:read"`},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, c.path, nil)
+			if c.token != "" {
+				r.Header.Set("Authorization", "Bearer "+c.token)
+			}
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+			if rec.Code != c.code {
+				t.Errorf("got StatusCode %d, want %d", rec.Code, c.code)
+			}
+			if c.code == http.StatusOK && rec.Body.String() != "alice" {
+				t.Errorf("got body %q, want %q", rec.Body.String(), "alice")
+			}
+			if challenge := rec.Header().Get("WWW-Authenticate"); challenge != c.challenge {
+				t.Errorf("got WWW-Authenticate %q, want %q", challenge, c.challenge)
+			}
+		})
+	}
+
+	t.Run("algorithm confusion", func(t *testing.T) {
+		// An RSA public key must not be usable as an HMAC secret.
+		m := mux.New()
+		m.Get("/me", claimsHandler, mux.WithMiddleware(mux.RequireJWT(mux.JWT{Key: &rsaKey.PublicKey})))
+		r := httptest.NewRequest(http.MethodGet, "/me", nil)
+		r.Header.Set("Authorization", "Bearer "+signJWT(t, "HS256", "", rsaKey.PublicKey.N.Bytes(), valid))
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, r)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("got StatusCode %d, want %d", rec.Code, http.StatusUnauthorized)
+		}
+	})
+
+	t.Run("Use", func(t *testing.T) {
+		m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found")))
+		m.Use(auth)
+		m.Get("/reports", claimsHandler, mux.Meta("scopes", []string{"reports:read"}))
+		m.Get("/admin", claimsHandler, mux.Meta("scopes", "admin reports:read"))
+
+		for path, code := range map[string]int{"/reports": http.StatusOK, "/admin": http.StatusForbidden} {
+			r := httptest.NewRequest(http.MethodGet, path, nil)
+			r.Header.Set("Authorization", "Bearer "+signJWT(t, "HS256", "", secret, valid))
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+			if rec.Code != code {
+				t.Errorf("%s: got StatusCode %d, want %d", path, rec.Code, code)
+			}
+		}
+	})
+
+	for _, c := range []mux.JWT{{}, {Key: "secret"}, {Key: []byte{}}, {Keys: map[string]interface{}{"a": []byte(nil)}}} {
+		t.Run("invalid", func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("got no panic, want panic")
+				}
+			}()
+			mux.RequireJWT(c)
+		})
+	}
+}
+
+func TestRequireJWTJWKS(t *testing.T) {
+	key1, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key2, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b64 := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	rsaJWK := map[string]string{"kty": "RSA", "kid": "1", "use": "sig", "n": b64(key1.N.Bytes()), "e": b64(big.NewInt(int64(key1.E)).Bytes())}
+	ecJWK := map[string]string{"kty": "EC", "kid": "2", "crv": "P-256", "x": b64(key2.X.Bytes()), "y": b64(key2.Y.Bytes())}
+	emptyJWK := map[string]string{"kty": "oct", "kid": "3", "k": ""}
+
+	var rotated int32
+	var fetches int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		keys := []map[string]string{rsaJWK, emptyJWK}
+		if atomic.LoadInt32(&rotated) == 1 {
+			keys = append(keys, ecJWK)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+	}))
+	defer srv.Close()
+
+	m := mux.New()
+	m.Get("/me", claimsHandler, mux.WithMiddleware(mux.RequireJWT(mux.JWT{JWKSURL: srv.URL, JWKSRefresh: time.Nanosecond})))
+	serve := func(token string) int {
+		r := httptest.NewRequest(http.MethodGet, "/me", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, r)
+		return rec.Code
+	}
+
+	claims := map[string]interface{}{"sub": "alice"}
+	if code := serve(signJWT(t, "RS256", "1", key1, claims)); code != http.StatusOK {
+		t.Errorf("got StatusCode %d, want %d", code, http.StatusOK)
+	}
+	if code := serve(signJWT(t, "RS256", "", key1, claims)); code != http.StatusOK {
+		t.Errorf("without key ID: got StatusCode %d, want %d", code, http.StatusOK)
+	}
+	if code := serve(signJWT(t, "HS256", "3", []byte{}, claims)); code != http.StatusUnauthorized {
+		t.Errorf("empty oct key: got StatusCode %d, want %d", code, http.StatusUnauthorized)
+	}
+	if code := serve(signJWT(t, "ES256", "2", key2, claims)); code != http.StatusUnauthorized {
+		t.Errorf("before rotation: got StatusCode %d, want %d", code, http.StatusUnauthorized)
+	}
+	atomic.StoreInt32(&rotated, 1)
+	if code := serve(signJWT(t, "ES256", "2", key2, claims)); code != http.StatusOK {
+		t.Errorf("after rotation: got StatusCode %d, want %d", code, http.StatusOK)
+	}
+	if n := atomic.LoadInt32(&fetches); n < 2 {
+		t.Errorf("got %d fetches, want at least 2", n)
+	}
+}
+
+func TestRequireJWTJWKSRefresh(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b64 := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	set := map[string]interface{}{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "1", "n": b64(key.N.Bytes()), "e": b64(big.NewInt(int64(key.E)).Bytes())},
+	}}
+
+	var fetches int32
+	unblock := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&fetches, 1) > 1 {
+			<-unblock
+		}
+		json.NewEncoder(w).Encode(set)
+	}))
+	defer srv.Close()
+	defer close(unblock)
+
+	m := mux.New()
+	m.Get("/me", claimsHandler, mux.WithMiddleware(mux.RequireJWT(mux.JWT{JWKSURL: srv.URL, JWKSRefresh: time.Nanosecond})))
+	token := signJWT(t, "RS256", "1", key, map[string]interface{}{"sub": "alice"})
+
+	// The first request waits for the set; the others are served the known
+	// key while the refresh they start hangs.
+	for i := 0; i < 3; i++ {
+		done := make(chan int)
+		go func() {
+			r := httptest.NewRequest(http.MethodGet, "/me", nil)
+			r.Header.Set("Authorization", "Bearer "+token)
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+			done <- rec.Code
+		}()
+		select {
+		case code := <-done:
+			if code != http.StatusOK {
+				t.Errorf("%d: got StatusCode %d, want %d", i, code, http.StatusOK)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%d: request blocked by the refresh of the key set", i)
+		}
+	}
+	// The refresh started by the second request is still hanging, so the
+	// third did not start another.
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadInt32(&fetches) < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if n := atomic.LoadInt32(&fetches); n != 2 {
+		t.Errorf("got %d fetches, want 2", n)
+	}
+}
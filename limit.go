@@ -0,0 +1,151 @@
+package mux
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// MaxInFlight limits the number of requests the route's handler serves
+// concurrently to n. Requests over the limit are not queued but immediately
+// passed to overflow with the Retry-After header set. A nil overflow replies
+// with 429 Too Many Requests.
+//
+// Panics if n is less than 1.
+func MaxInFlight(n int, overflow http.HandlerFunc) RouteOption {
+	if n < 1 {
+		panic("mux: MaxInFlight limit must be at least 1")
+	}
+	if overflow == nil {
+		overflow = tooManyRequests
+	}
+
+	return func(mux *Mux, e *muxEntry) {
+		count := new(int64)
+		e.inFlight = append(e.inFlight, count)
+
+		next := e.handler
+		e.handler = func(w http.ResponseWriter, r *http.Request) {
+			for {
+				c := atomic.LoadInt64(count)
+				if c >= int64(n) {
+					w.Header().Set("Retry-After", "1")
+					overflow(w, r)
+					return
+				}
+				if atomic.CompareAndSwapInt64(count, c, c+1) {
+					break
+				}
+			}
+			defer atomic.AddInt64(count, -1)
+
+			next(w, r)
+		}
+	}
+}
+
+// tooManyRequests replies with 429 Too Many Requests.
+func tooManyRequests(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+}
+
+// ConcurrencyLimit configures the concurrency limit set by LimitConcurrency.
+type ConcurrencyLimit struct {
+	Max int // requests served concurrently
+
+	// Queue is the number of requests over Max waiting for a request being
+	// served to finish. Requests arriving with the queue full are rejected
+	// at once.
+	Queue int
+
+	// Timeout is how long a queued request waits before it is rejected. If
+	// zero, it waits until it is served or the client goes away.
+	Timeout time.Duration
+
+	// Overflow handles rejected requests. If nil, they are answered with
+	// 503 Service Unavailable.
+	Overflow http.HandlerFunc
+}
+
+// LimitConcurrency limits the number of requests the route's handler serves
+// concurrently to l.Max, like MaxInFlight, but lets up to l.Queue requests
+// over the limit wait for their turn. Rejected requests are passed to
+// l.Overflow with the Retry-After header set. Queued requests whose client
+// goes away are dropped without a response.
+//
+// Panics if l.Max is less than 1 or l.Queue or l.Timeout is negative.
+func LimitConcurrency(l ConcurrencyLimit) RouteOption {
+	if l.Max < 1 {
+		panic("mux: concurrency limit must be at least 1")
+	}
+	if l.Queue < 0 || l.Timeout < 0 {
+		panic("mux: negative concurrency queue or timeout")
+	}
+	if l.Overflow == nil {
+		l.Overflow = serviceUnavailable
+	}
+
+	return func(mux *Mux, e *muxEntry) {
+		count := new(int64)
+		e.inFlight = append(e.inFlight, count)
+		sem := make(chan struct{}, l.Max)
+		queued := new(int64)
+
+		next := e.handler
+		e.handler = func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case sem <- struct{}{}:
+			default:
+				if atomic.AddInt64(queued, 1) > int64(l.Queue) {
+					atomic.AddInt64(queued, -1)
+					w.Header().Set("Retry-After", "1")
+					l.Overflow(w, r)
+					return
+				}
+				var timeout <-chan time.Time
+				if l.Timeout > 0 {
+					t := time.NewTimer(l.Timeout)
+					defer t.Stop()
+					timeout = t.C
+				}
+				select {
+				case sem <- struct{}{}:
+					atomic.AddInt64(queued, -1)
+				case <-timeout:
+					atomic.AddInt64(queued, -1)
+					w.Header().Set("Retry-After", "1")
+					l.Overflow(w, r)
+					return
+				case <-r.Context().Done():
+					atomic.AddInt64(queued, -1)
+					return
+				}
+			}
+			atomic.AddInt64(count, 1)
+			defer func() {
+				atomic.AddInt64(count, -1)
+				<-sem
+			}()
+
+			next(w, r)
+		}
+	}
+}
+
+// serviceUnavailable replies with 503 Service Unavailable.
+func serviceUnavailable(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+}
+
+// InFlight returns the number of requests the handlers for pattern limited by
+// MaxInFlight or LimitConcurrency are serving, summed over all methods.
+func (mux *Mux) InFlight(pattern string) int {
+	e := mux.load().m[pattern]
+	var n int64
+	for _, v := range append([]muxEntry{e}, e.variants...) {
+		for _, count := range v.inFlight {
+			n += atomic.LoadInt64(count)
+		}
+	}
+	return int(n)
+}
@@ -0,0 +1,259 @@
+package mux_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestMaxInFlight(t *testing.T) {
+	t.Run("limit", func(t *testing.T) {
+		const limit = 4
+
+		entered := make(chan struct{})
+		release := make(chan struct{})
+		m := mux.New()
+		m.HandleFunc("/reports/heavy", func(w http.ResponseWriter, r *http.Request) {
+			entered <- struct{}{}
+			<-release
+			w.WriteHeader(http.StatusTeapot)
+		}, mux.MaxInFlight(limit, nil))
+
+		var wg sync.WaitGroup
+		codes := make(chan int, limit)
+		for i := 0; i < limit; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				r := httptest.NewRequest(http.MethodGet, "/reports/heavy", nil)
+				rec := httptest.NewRecorder()
+				m.ServeHTTP(rec, r)
+				codes <- rec.Code
+			}()
+		}
+		for i := 0; i < limit; i++ {
+			<-entered
+		}
+
+		if n := m.InFlight("/reports/heavy"); n != limit {
+			t.Errorf("got InFlight %d, want %d", n, limit)
+		}
+
+		r := httptest.NewRequest(http.MethodGet, "/reports/heavy", nil)
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, r)
+		resp := rec.Result()
+
+		if resp.StatusCode != http.StatusTooManyRequests {
+			t.Errorf("got StatusCode %d, want %d", resp.StatusCode, http.StatusTooManyRequests)
+		}
+
+		retryAfter := resp.Header.Get("Retry-After")
+		if retryAfter == "" {
+			t.Error("got no Retry-After, want Retry-After")
+		}
+
+		close(release)
+		wg.Wait()
+		close(codes)
+
+		for code := range codes {
+			if code != http.StatusTeapot {
+				t.Errorf("got StatusCode %d, want %d", code, http.StatusTeapot)
+			}
+		}
+
+		if n := m.InFlight("/reports/heavy"); n != 0 {
+			t.Errorf("got InFlight %d, want 0", n)
+		}
+	})
+
+	t.Run("overflow", func(t *testing.T) {
+		entered := make(chan struct{})
+		release := make(chan struct{})
+		m := mux.New()
+		m.HandleFunc("/a", func(w http.ResponseWriter, r *http.Request) {
+			entered <- struct{}{}
+			<-release
+		}, mux.MaxInFlight(1, handlerFactory(http.StatusServiceUnavailable, "busy")))
+
+		done := make(chan struct{})
+		go func() {
+			r := httptest.NewRequest(http.MethodGet, "/a", nil)
+			m.ServeHTTP(httptest.NewRecorder(), r)
+			close(done)
+		}()
+		<-entered
+
+		r := httptest.NewRequest(http.MethodGet, "/a", nil)
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, r)
+
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("got StatusCode %d, want %d", rec.Code, http.StatusServiceUnavailable)
+		}
+		if body := rec.Body.String(); body != "busy" {
+			t.Errorf("got body %q, want busy", body)
+		}
+
+		close(release)
+		<-done
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		m := mux.New()
+		m.HandleFunc("/a", func(w http.ResponseWriter, r *http.Request) {
+			panic("a")
+		}, mux.MaxInFlight(1, nil))
+
+		func() {
+			defer func() {
+				recover()
+			}()
+
+			r := httptest.NewRequest(http.MethodGet, "/a", nil)
+			m.ServeHTTP(httptest.NewRecorder(), r)
+		}()
+
+		if n := m.InFlight("/a"); n != 0 {
+			t.Errorf("got InFlight %d, want 0", n)
+		}
+	})
+
+	t.Run("unlimited", func(t *testing.T) {
+		m := mux.New()
+		m.HandleFunc("/a", handlerFactory(http.StatusTeapot, "a"))
+
+		if n := m.InFlight("/a"); n != 0 {
+			t.Errorf("got InFlight %d, want 0", n)
+		}
+	})
+
+	t.Run("red", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("got no panic, want panic")
+			}
+		}()
+
+		mux.MaxInFlight(0, nil)
+	})
+}
+
+func TestLimitConcurrency(t *testing.T) {
+	newMux := func(l mux.ConcurrencyLimit) (*mux.Mux, chan struct{}, chan struct{}) {
+		entered := make(chan struct{}, 2)
+		release := make(chan struct{})
+		m := mux.New()
+		m.HandleFunc("/reports", func(w http.ResponseWriter, r *http.Request) {
+			entered <- struct{}{}
+			<-release
+			w.WriteHeader(http.StatusTeapot)
+		}, mux.LimitConcurrency(l))
+		return m, entered, release
+	}
+	serve := func(m *mux.Mux, r *http.Request) int {
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, r)
+		return rec.Code
+	}
+
+	t.Run("queue", func(t *testing.T) {
+		m, entered, release := newMux(mux.ConcurrencyLimit{Max: 1, Queue: 1})
+
+		codes := make(chan int, 2)
+		for i := 0; i < 2; i++ {
+			go func() {
+				codes <- serve(m, httptest.NewRequest(http.MethodGet, "/reports", nil))
+			}()
+		}
+		<-entered
+		if n := m.InFlight("/reports"); n != 1 {
+			t.Errorf("got InFlight %d, want 1", n)
+		}
+
+		close(release)
+		for i := 0; i < 2; i++ {
+			if code := <-codes; code != http.StatusTeapot {
+				t.Errorf("got StatusCode %d, want %d", code, http.StatusTeapot)
+			}
+		}
+	})
+
+	t.Run("full", func(t *testing.T) {
+		m, entered, release := newMux(mux.ConcurrencyLimit{Max: 1})
+		defer close(release)
+
+		go serve(m, httptest.NewRequest(http.MethodGet, "/reports", nil))
+		<-entered
+
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/reports", nil))
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("got StatusCode %d, want %d", rec.Code, http.StatusServiceUnavailable)
+		}
+		if rec.Header().Get("Retry-After") == "" {
+			t.Error("got no Retry-After, want Retry-After")
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		m, entered, release := newMux(mux.ConcurrencyLimit{
+			Max:     1,
+			Queue:   1,
+			Timeout: time.Millisecond,
+			Overflow: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusTooManyRequests)
+			},
+		})
+		defer close(release)
+
+		go serve(m, httptest.NewRequest(http.MethodGet, "/reports", nil))
+		<-entered
+
+		if code := serve(m, httptest.NewRequest(http.MethodGet, "/reports", nil)); code != http.StatusTooManyRequests {
+			t.Errorf("got StatusCode %d, want %d", code, http.StatusTooManyRequests)
+		}
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		m, entered, release := newMux(mux.ConcurrencyLimit{Max: 1, Queue: 1})
+		defer close(release)
+
+		go serve(m, httptest.NewRequest(http.MethodGet, "/reports", nil))
+		<-entered
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		r := httptest.NewRequest(http.MethodGet, "/reports", nil).WithContext(ctx)
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, r)
+		if rec.Body.Len() != 0 || len(rec.Header()) != 0 {
+			t.Errorf("got response %d %v %q, want none", rec.Code, rec.Header(), rec.Body)
+		}
+	})
+
+	t.Run("red", func(t *testing.T) {
+		limits := []mux.ConcurrencyLimit{
+			{Max: 0},
+			{Max: 1, Queue: -1},
+			{Max: 1, Timeout: -time.Second},
+		}
+		for _, l := range limits {
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Errorf("%+v: got no panic, want panic", l)
+					}
+				}()
+
+				mux.LimitConcurrency(l)
+			}()
+		}
+	})
+}
@@ -0,0 +1,264 @@
+package mux
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// localeRouter routes requests with a leading locale path segment into an
+// inner mux.
+type localeRouter struct {
+	set       map[string]bool
+	locales   []string // locales in the order given
+	def       string   // default locale
+	inner     *Mux
+	redirect  bool // whether bare paths are redirected to the default locale
+	negotiate bool // whether bare paths use the locale of Accept-Language
+}
+
+// LocaleOption configures the locale routing set up by Locales.
+type LocaleOption func(*localeRouter)
+
+// ServeDefaultLocale makes the inner mux serve requests without a locale
+// prefix directly in the default locale instead of redirecting them to the
+// default-locale path.
+func ServeDefaultLocale() LocaleOption {
+	return func(l *localeRouter) {
+		l.redirect = false
+	}
+}
+
+// NegotiateLocale makes requests without a locale prefix be redirected to, or
+// with ServeDefaultLocale served in, the locale the Accept-Language header
+// of the request prefers instead of the default locale, which is used only if
+// the header accepts none of the locales. A language range names a locale if
+// it is the locale, a prefix of it, as "de" of "de-AT", or the locale is a
+// prefix of the range; ties are broken in that order, then in favor of the
+// default locale and then by the order of the locales. The response Vary
+// header includes Accept-Language.
+func NegotiateLocale() LocaleOption {
+	return func(l *localeRouter) {
+		l.negotiate = true
+	}
+}
+
+// Locales routes requests whose path begins with one of the given locales,
+// like "/de/about", into inner with the locale stripped from the path. The
+// locale is available to handlers through Locale.
+//
+// A first path segment that is not one of the given locales is not a locale,
+// so "/delivery" is matched against the patterns of mux as usual. Requests
+// without a locale prefix that no pattern of mux matches, but inner does, are
+// redirected to the defaultLocale form of the path, "/about" to "/en/about",
+// or with NegotiateLocale to the form in the locale the client prefers. The
+// redirect is a 307 Temporary Redirect unless mux has RedirectCode.
+//
+// Panics if locales is empty, defaultLocale is not one of locales, inner is
+// nil or mux already routes locales.
+func (mux *Mux) Locales(locales []string, defaultLocale string, inner *Mux, opts ...LocaleOption) {
+	mux.lock()
+	defer mux.unlock()
+
+	if len(locales) == 0 {
+		panic("mux: no locales")
+	}
+	if inner == nil {
+		panic("mux: nil inner mux")
+	}
+	if inner == mux {
+		panic("mux: inner mux must not be the mux itself")
+	}
+	if mux.locales != nil {
+		panic("mux: multiple Locales registrations")
+	}
+
+	l := &localeRouter{
+		set:      make(map[string]bool),
+		def:      defaultLocale,
+		inner:    inner,
+		redirect: true,
+	}
+	for _, locale := range locales {
+		if locale == "" || strings.Contains(locale, "/") {
+			panic("mux: invalid locale " + locale)
+		}
+		l.set[locale] = true
+		l.locales = append(l.locales, locale)
+	}
+	if !l.set[defaultLocale] {
+		panic("mux: default locale " + defaultLocale + " is not one of the locales")
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	mux.locales = l
+}
+
+// prefixed returns a handler that serves r through the inner mux if the path
+// of r begins with a locale, recording the decision in ex unless ex is nil.
+// A trailing slash after the locale is redirected like a canonical URL of t.
+func (l *localeRouter) prefixed(r *http.Request, ex *Explanation, t *table) (http.HandlerFunc, bool) {
+	locale := firstSegment(r.URL.Path)
+	if !l.set[locale] {
+		return nil, false
+	}
+
+	prefix := "/" + locale
+	if r.URL.Path == prefix+"/" {
+		u := canonicalURL(r.URL, prefix)
+		ex.redirect(u, "trailing slash")
+		return t.redirectHandler(u, t.redirectStatus(), "trailing slash"), true
+	}
+
+	if ex != nil {
+		r := stripPrefix(r, prefix)
+		inner := ex.nested(r)
+		l.inner.match(r, inner)
+		ex.locale(locale, inner)
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		l.inner.ServeHTTP(w, withLocale(stripPrefix(r, prefix), locale))
+	}, true
+}
+
+// bare returns a handler for r, whose path does not begin with a locale, if
+// the inner mux has a route for it, recording the decision in ex unless ex is
+// nil. Redirects are logged with the logger of t.
+func (l *localeRouter) bare(r *http.Request, ex *Explanation, t *table) (http.HandlerFunc, bool) {
+	inner := ex.nested(r)
+	h, ok := l.inner.match(r, inner)
+	if !ok {
+		return nil, false
+	}
+
+	locale, reason := l.def, "default locale"
+	if l.negotiate {
+		locale, reason = l.preferred(r.Header.Get("Accept-Language")), "Accept-Language"
+	}
+
+	if !l.redirect {
+		ex.locale(locale, inner)
+		return l.vary(func(w http.ResponseWriter, r *http.Request) {
+			h(w, withLocale(r, locale))
+		}), true
+	}
+
+	u := canonicalURL(r.URL, "/"+locale)
+	if r.URL.Path != "/" {
+		u.Path += r.URL.Path
+	}
+	code := http.StatusTemporaryRedirect
+	if t.redirectCode != 0 {
+		code = t.redirectStatus()
+	}
+	ex.redirect(u, reason)
+	return l.vary(t.redirectHandler(u, code, reason)), true
+}
+
+// vary returns h adding Accept-Language to the Vary header of the response if
+// the locale of bare paths is negotiated, and h otherwise.
+func (l *localeRouter) vary(h http.HandlerFunc) http.HandlerFunc {
+	if !l.negotiate {
+		return h
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		addVary(w.Header(), "Accept-Language")
+		h(w, r)
+	}
+}
+
+// preferred returns the locale the Accept-Language header prefers or the
+// default locale if it accepts none.
+func (l *localeRouter) preferred(acceptLanguage string) string {
+	best := l.def
+	bestQ, bestSpecificity := languageQuality(acceptLanguage, best)
+	for _, locale := range l.locales {
+		q, specificity := languageQuality(acceptLanguage, locale)
+		if q > bestQ || q == bestQ && q > 0 && specificity > bestSpecificity {
+			best, bestQ, bestSpecificity = locale, q, specificity
+		}
+	}
+	return best
+}
+
+// languageQuality returns the quality the Accept-Language header assigns to
+// the locale and how specifically the range it was taken from names it: 0 for
+// "*", 1 for a range the locale is a prefix of, 2 for a prefix of the locale
+// and 3 for the locale itself.
+func languageQuality(acceptLanguage, locale string) (float64, int) {
+	locale = strings.ToLower(locale)
+
+	q, specificity := 0.0, -1
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(part, ";")
+		rng := strings.ToLower(strings.TrimSpace(fields[0]))
+
+		s := -1
+		switch {
+		case rng == "*":
+			s = 0
+		case strings.HasPrefix(rng, locale+"-"):
+			s = 1
+		case strings.HasPrefix(locale, rng+"-"):
+			s = 2
+		case rng == locale:
+			s = 3
+		}
+		if s <= specificity {
+			continue
+		}
+
+		rq := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if f, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					rq = f
+				}
+			}
+		}
+		q, specificity = rq, s
+	}
+	return q, specificity
+}
+
+// firstSegment returns the first segment of path, "a" for "/a/b".
+func firstSegment(path string) string {
+	path = strings.TrimPrefix(path, "/")
+	if i := strings.IndexByte(path, '/'); i >= 0 {
+		return path[:i]
+	}
+	return path
+}
+
+// localeKey is the context key for the locale of a request.
+type localeKey struct{}
+
+// withLocale returns a shallow copy of r with the given locale.
+func withLocale(r *http.Request, locale string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), localeKey{}, locale))
+}
+
+// Locale returns the locale of a request routed by Locales or "" if there is
+// none.
+func Locale(r *http.Request) string {
+	locale, _ := r.Context().Value(localeKey{}).(string)
+	return locale
+}
+
+// LocalePath returns path with the locale of r inserted in front, "/de/about"
+// for "/about", so that generated URLs stay in the current locale. It returns
+// path unchanged if r has no locale.
+func LocalePath(r *http.Request, path string) string {
+	locale := Locale(r)
+	if locale == "" {
+		return path
+	}
+	if path == "/" {
+		return "/" + locale
+	}
+	return "/" + locale + path
+}
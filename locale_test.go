@@ -0,0 +1,268 @@
+package mux_test
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+func ExampleMux_Locales() {
+	inner := mux.New()
+	inner.HandleFunc("/about", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "about in "+mux.Locale(r))
+	})
+
+	m := mux.New()
+	m.Locales([]string{"en", "de", "fr"}, "en", inner)
+
+	http.ListenAndServe(":8080", m)
+}
+
+// localeHandler writes the locale and the path it sees.
+func localeHandler(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusTeapot)
+	io.WriteString(w, mux.Locale(r)+" "+r.URL.Path)
+}
+
+func TestLocales(t *testing.T) {
+	newMux := func(opts ...mux.LocaleOption) *mux.Mux {
+		inner := mux.New()
+		inner.HandleFunc("/", localeHandler)
+		inner.HandleFunc("/about", localeHandler)
+		inner.HandleFunc("/a/b", localeHandler)
+
+		m := mux.New()
+		m.HandleFunc("/delivery", handlerFactory(http.StatusTeapot, "delivery"))
+		m.Locales([]string{"en", "de", "fr"}, "en", inner, opts...)
+		return m
+	}
+
+	t.Run("green", func(t *testing.T) {
+		cases := []struct {
+			path string
+			body string
+		}{
+			{"/en", "en /"},
+			{"/de/about", "de /about"},
+			{"/fr/a/b", "fr /a/b"},
+			{"/delivery", "delivery"},
+		}
+
+		for _, c := range cases {
+			t.Run(c.path, func(t *testing.T) {
+				r := httptest.NewRequest(http.MethodGet, c.path, nil)
+				rec := httptest.NewRecorder()
+				newMux().ServeHTTP(rec, r)
+				resp := rec.Result()
+
+				if resp.StatusCode != http.StatusTeapot {
+					t.Errorf("got StatusCode %d, want %d", resp.StatusCode, http.StatusTeapot)
+				}
+
+				b, err := ioutil.ReadAll(resp.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				body := string(b)
+				if body != c.body {
+					t.Errorf("got body %q, want %q", body, c.body)
+				}
+			})
+		}
+	})
+
+	t.Run("yellow", func(t *testing.T) {
+		cases := []string{
+			"/es/about",
+			"/deabout",
+			"/de/missing",
+		}
+
+		for _, path := range cases {
+			t.Run(path, func(t *testing.T) {
+				r := httptest.NewRequest(http.MethodGet, path, nil)
+				rec := httptest.NewRecorder()
+				newMux().ServeHTTP(rec, r)
+				resp := rec.Result()
+
+				if resp.StatusCode != http.StatusNotFound {
+					t.Errorf("got StatusCode %d, want %d", resp.StatusCode, http.StatusNotFound)
+				}
+			})
+		}
+	})
+
+	t.Run("redirect", func(t *testing.T) {
+		cases := []struct {
+			path     string
+			code     int
+			location string
+		}{
+			{"/", http.StatusTemporaryRedirect, "/en"},
+			{"/about?q=A", http.StatusTemporaryRedirect, "/en/about?q=A"},
+			{"/de/", http.StatusPermanentRedirect, "/de"},
+			{"/de/about/", http.StatusPermanentRedirect, "/de/about"},
+		}
+
+		for _, c := range cases {
+			t.Run(c.path, func(t *testing.T) {
+				r := httptest.NewRequest(http.MethodGet, c.path, nil)
+				rec := httptest.NewRecorder()
+				newMux().ServeHTTP(rec, r)
+				resp := rec.Result()
+
+				if resp.StatusCode != c.code {
+					t.Errorf("got StatusCode %d, want %d", resp.StatusCode, c.code)
+				}
+
+				location := resp.Header.Get("Location")
+				if location != c.location {
+					t.Errorf("got Location %q, want %q", location, c.location)
+				}
+			})
+		}
+	})
+
+	t.Run("serve default", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/about", nil)
+		rec := httptest.NewRecorder()
+		newMux(mux.ServeDefaultLocale()).ServeHTTP(rec, r)
+		resp := rec.Result()
+
+		if resp.StatusCode != http.StatusTeapot {
+			t.Errorf("got StatusCode %d, want %d", resp.StatusCode, http.StatusTeapot)
+		}
+
+		b, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body := string(b)
+		if body != "en /about" {
+			t.Errorf("got body %q, want %q", body, "en /about")
+		}
+	})
+
+	t.Run("negotiate", func(t *testing.T) {
+		cases := []struct {
+			acceptLanguage string
+			location       string
+		}{
+			{"", "/en/about"},
+			{"de", "/de/about"},
+			{"de-AT, fr;q=0.8", "/de/about"},
+			{"fr, de;q=0.9", "/fr/about"},
+			{"es, fr;q=0.5, de;q=0.5", "/de/about"},
+			{"*", "/en/about"},
+			{"es", "/en/about"},
+			{"FR-ch", "/fr/about"},
+		}
+
+		m := newMux(mux.NegotiateLocale())
+		for _, c := range cases {
+			t.Run(c.acceptLanguage, func(t *testing.T) {
+				r := httptest.NewRequest(http.MethodGet, "/about", nil)
+				r.Header.Set("Accept-Language", c.acceptLanguage)
+				rec := httptest.NewRecorder()
+				m.ServeHTTP(rec, r)
+				resp := rec.Result()
+
+				if resp.StatusCode != http.StatusTemporaryRedirect {
+					t.Errorf("got StatusCode %d, want %d", resp.StatusCode, http.StatusTemporaryRedirect)
+				}
+				if location := resp.Header.Get("Location"); location != c.location {
+					t.Errorf("got Location %q, want %q", location, c.location)
+				}
+				if vary := resp.Header.Get("Vary"); vary != "Accept-Language" {
+					t.Errorf("got Vary %q, want %q", vary, "Accept-Language")
+				}
+			})
+		}
+
+		t.Run("serve", func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/about", nil)
+			r.Header.Set("Accept-Language", "de")
+			rec := httptest.NewRecorder()
+			newMux(mux.NegotiateLocale(), mux.ServeDefaultLocale()).ServeHTTP(rec, r)
+
+			if body := rec.Body.String(); body != "de /about" {
+				t.Errorf("got body %q, want %q", body, "de /about")
+			}
+			if vary := rec.Header().Get("Vary"); vary != "Accept-Language" {
+				t.Errorf("got Vary %q, want %q", vary, "Accept-Language")
+			}
+		})
+	})
+
+	t.Run("red", func(t *testing.T) {
+		cases := []struct {
+			name          string
+			locales       []string
+			defaultLocale string
+			inner         *mux.Mux
+		}{
+			{
+				"no locales",
+				nil,
+				"en",
+				mux.New(),
+			},
+			{
+				"default not in locales",
+				[]string{"de"},
+				"en",
+				mux.New(),
+			},
+			{
+				"invalid locale",
+				[]string{"en", "d/e"},
+				"en",
+				mux.New(),
+			},
+			{
+				"nil inner",
+				[]string{"en"},
+				"en",
+				nil,
+			},
+		}
+
+		for _, c := range cases {
+			t.Run(c.name, func(t *testing.T) {
+				defer func() {
+					if recover() == nil {
+						t.Error("got no panic, want panic")
+					}
+				}()
+
+				m := mux.New()
+				m.Locales(c.locales, c.defaultLocale, c.inner)
+			})
+		}
+	})
+}
+
+func TestLocalePath(t *testing.T) {
+	inner := mux.New()
+	inner.HandleFunc("/about", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, mux.LocalePath(r, "/contact")+" "+mux.LocalePath(r, "/"))
+	})
+
+	m := mux.New()
+	m.Locales([]string{"en", "de"}, "en", inner)
+
+	r := httptest.NewRequest(http.MethodGet, "/de/about", nil)
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, r)
+
+	body := rec.Body.String()
+	if body != "/de/contact /de" {
+		t.Errorf("got body %q, want %q", body, "/de/contact /de")
+	}
+}
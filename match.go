@@ -0,0 +1,132 @@
+package mux
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Match makes the route match only requests for which matcher returns true,
+// in addition to matching the path, so that a route can depend on headers,
+// query parameters, cookies or TLS attributes. A route with several matchers
+// matches only requests all of them accept. Requests a route does not match
+// go on to the other patterns as if its path did not match.
+//
+// A pattern may be registered several times with Match, for different
+// handlers, and once more without it; routes with matchers are tried in
+// registration order before the one without. So
+//
+//	m.HandleFunc("/hook", push, mux.Match(func(r *http.Request) bool {
+//		return r.Header.Get("X-Event") == "push"
+//	}))
+//	m.HandleFunc("/hook", other)
+//
+// routes push events to push and all other requests to other.
+//
+// Panics if matcher is nil.
+func Match(matcher func(r *http.Request) bool) RouteOption {
+	if matcher == nil {
+		panic("mux: nil matcher")
+	}
+	return func(mux *Mux, e *muxEntry) {
+		e.matchers = append(e.matchers, matcher)
+	}
+}
+
+// matches reports whether all matchers of e accept r.
+func (e muxEntry) matches(r *http.Request) bool {
+	for _, matcher := range e.matchers {
+		if !matcher(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// variantFor returns the entry handling r among e and its variants, which is
+// the first variant accepting r with a handler for its method, else e if it
+// has handlers, else the first variant accepting r. Of the variants with
+// Produces, the one producing the media type r prefers stands for all of
+// them; if r accepts none of their types, e handles r, varying by Accept, or
+// if it has no handler for its method, answers with 406 Not Acceptable. It
+// reports false if there is none.
+func (e muxEntry) variantFor(r *http.Request) (muxEntry, bool) {
+	var accepted *muxEntry
+	var unacceptable bool // whether r accepts no type variants produce
+	for i := range e.variants {
+		v := &e.variants[i]
+		if !v.matches(r) {
+			continue
+		}
+		if v.handlerFor(r.Method) != nil {
+			if len(v.produces) == 0 {
+				return *v, true
+			}
+			if unacceptable {
+				continue
+			}
+			if p, ok := producing(e.variants[i:], r); ok {
+				return p, true
+			}
+			unacceptable = true
+			continue
+		}
+		if accepted == nil {
+			accepted = v
+		}
+	}
+	if unacceptable {
+		if e.handlerFor(r.Method) == nil {
+			return e.notAcceptable(), true
+		}
+		return e.varyAccept(), true
+	}
+	if e.handler != nil || len(e.methods) > 0 || accepted == nil {
+		return e, e.handler != nil || len(e.methods) > 0
+	}
+	return *accepted, true
+}
+
+// Headers makes the route match only requests with the given headers, like
+// Match, so that a path can be routed to different handlers by header, as in
+// header-versioned APIs. pairs are header name and value pairs. A header
+// matches if one of the comma-separated elements of its values, without
+// parameters like ";q=0.9", equals the value, ignoring case, so
+// Headers("Accept", "application/vnd.v2+json") matches a request accepting
+// "text/html, application/vnd.v2+json;q=0.9". An empty value matches any
+// request with the header.
+//
+// Panics if pairs has an odd length.
+func Headers(pairs ...string) RouteOption {
+	if len(pairs)%2 != 0 {
+		panic("mux: odd number of Headers arguments")
+	}
+	pairs = append([]string(nil), pairs...)
+
+	return Match(func(r *http.Request) bool {
+		for i := 0; i < len(pairs); i += 2 {
+			if !hasHeader(r.Header.Values(pairs[i]), pairs[i+1]) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// hasHeader reports whether the header values have an element equal to value
+// or, if value is empty, whether there are any.
+func hasHeader(values []string, value string) bool {
+	if value == "" {
+		return len(values) > 0
+	}
+	for _, v := range values {
+		for _, elem := range strings.Split(v, ",") {
+			if i := strings.IndexByte(elem, ';'); i >= 0 {
+				elem = elem[:i]
+			}
+			if strings.EqualFold(strings.TrimSpace(elem), value) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,162 @@
+package mux_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestMatch(t *testing.T) {
+	event := func(name string) mux.RouteOption {
+		return mux.Match(func(r *http.Request) bool {
+			return r.Header.Get("X-Event") == name
+		})
+	}
+	query := mux.Match(func(r *http.Request) bool {
+		return r.URL.Query().Get("debug") == "1"
+	})
+
+	sub := mux.New()
+	sub.HandleFunc("/hook", handlerFactory(http.StatusTeapot, "sub push"), event("push"))
+
+	m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found")))
+	m.HandleFunc("/hook", handlerFactory(http.StatusTeapot, "push"), event("push"))
+	m.Post("/hook", handlerFactory(http.StatusTeapot, "release"), event("release"))
+	m.HandleFunc("/hook", handlerFactory(http.StatusTeapot, "other"))
+	m.HandleFunc("/users/{id}", handlerFactory(http.StatusTeapot, "debug user"), query, event("debug"))
+	m.HandleFunc("/users/{name}/posts", handlerFactory(http.StatusTeapot, "posts"), query)
+	m.RegexpHandleFunc(`^/users/.*$`, handlerFactory(http.StatusTeapot, "regexp user"))
+	m.Mount("/sub", sub)
+
+	cases := []struct {
+		method string
+		path   string
+		event  string
+		code   int
+		body   string
+	}{
+		{http.MethodGet, "/hook", "push", http.StatusTeapot, "push"},
+		{http.MethodPost, "/hook", "release", http.StatusTeapot, "release"},
+		{http.MethodGet, "/hook", "release", http.StatusTeapot, "other"},
+		{http.MethodGet, "/hook", "", http.StatusTeapot, "other"},
+		{http.MethodGet, "/users/1?debug=1", "debug", http.StatusTeapot, "debug user"},
+		{http.MethodGet, "/users/1?debug=1", "", http.StatusTeapot, "regexp user"},
+		{http.MethodGet, "/users/1", "debug", http.StatusTeapot, "regexp user"},
+		{http.MethodGet, "/users/1/posts?debug=1", "", http.StatusTeapot, "posts"},
+		{http.MethodGet, "/sub/hook", "push", http.StatusTeapot, "sub push"},
+		{http.MethodGet, "/sub/hook", "", http.StatusNotFound, "404 page not found\n"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.method+" "+c.path+" "+c.event, func(t *testing.T) {
+			r := httptest.NewRequest(c.method, c.path, nil)
+			r.Header.Set("X-Event", c.event)
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+			resp := rec.Result()
+
+			if resp.StatusCode != c.code {
+				t.Errorf("got StatusCode %d, want %d", resp.StatusCode, c.code)
+			}
+
+			b, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body := string(b)
+			if body != c.body {
+				t.Errorf("got body %q, want %q", body, c.body)
+			}
+		})
+	}
+
+	t.Run("method not allowed", func(t *testing.T) {
+		m := mux.New()
+		m.Post("/hook", handlerFactory(http.StatusTeapot, "push"), event("push"))
+
+		r := httptest.NewRequest(http.MethodGet, "/hook", nil)
+		r.Header.Set("X-Event", "push")
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, r)
+		resp := rec.Result()
+
+		if resp.StatusCode != http.StatusMethodNotAllowed {
+			t.Errorf("got StatusCode %d, want %d", resp.StatusCode, http.StatusMethodNotAllowed)
+		}
+		if allow := resp.Header.Get("Allow"); allow != "OPTIONS, POST" {
+			t.Errorf("got Allow %q, want %q", allow, "OPTIONS, POST")
+		}
+	})
+
+	t.Run("Explain", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/sub/hook", nil)
+		ex := m.Explain(r)
+		if !strings.Contains(ex.String(), `"/sub/hook" rejected: rejected by route matchers`) {
+			t.Errorf("got explanation\n%s\nwant /sub/hook rejected by route matchers", ex)
+		}
+	})
+}
+
+func TestHeaders(t *testing.T) {
+	m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found")))
+	m.HandleFunc("/thing", handlerFactory(http.StatusTeapot, "v2"), mux.Headers("Accept", "application/vnd.v2+json"))
+	m.HandleFunc("/thing", handlerFactory(http.StatusTeapot, "v3 beta"), mux.Headers("X-Version", "3", "X-Beta", ""))
+	m.HandleFunc("/thing", handlerFactory(http.StatusTeapot, "v1"))
+	m.HandleFunc("/v3", handlerFactory(http.StatusTeapot, "v3"), mux.Headers("X-Version", "3"))
+
+	cases := []struct {
+		path   string
+		header http.Header
+		code   int
+		body   string
+	}{
+		{"/thing", http.Header{"Accept": {"application/vnd.v2+json"}}, http.StatusTeapot, "v2"},
+		{"/thing", http.Header{"Accept": {"text/html, Application/Vnd.V2+json;q=0.9"}}, http.StatusTeapot, "v2"},
+		{"/thing", http.Header{"Accept": {"text/html", "application/vnd.v2+json"}}, http.StatusTeapot, "v2"},
+		{"/thing", http.Header{"Accept": {"application/vnd.v2+jsonx"}}, http.StatusTeapot, "v1"},
+		{"/thing", http.Header{"X-Version": {"3"}, "X-Beta": {"yes"}}, http.StatusTeapot, "v3 beta"},
+		{"/thing", http.Header{"X-Version": {"3"}}, http.StatusTeapot, "v1"},
+		{"/thing", nil, http.StatusTeapot, "v1"},
+		{"/v3", http.Header{"X-Version": {"3"}}, http.StatusTeapot, "v3"},
+		{"/v3", http.Header{"X-Version": {"2"}}, http.StatusNotFound, "not found"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.path+" "+c.body, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, c.path, nil)
+			r.Header = c.header
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+			resp := rec.Result()
+
+			if resp.StatusCode != c.code {
+				t.Errorf("got StatusCode %d, want %d", resp.StatusCode, c.code)
+			}
+
+			b, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body := string(b)
+			if body != c.body {
+				t.Errorf("got body %q, want %q", body, c.body)
+			}
+		})
+	}
+
+	t.Run("odd", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("got no panic, want panic")
+			}
+		}()
+
+		mux.Headers("Accept")
+	})
+}
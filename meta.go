@@ -0,0 +1,122 @@
+package mux
+
+import (
+	"context"
+	"net/http"
+)
+
+// metaKey is the context key for the metadata of the route a request was
+// routed to.
+type metaKey struct{}
+
+// routeMeta is the metadata of a route. It is not modified once set on an
+// entry, so entries and requests share it.
+type routeMeta struct {
+	values map[string]interface{}
+	tags   []string
+}
+
+// Meta attaches the value to the route under key, so that policies like
+// authorization scopes or audit categories can be driven by the routing
+// table. The handler and the middleware of the route read it with RouteMeta;
+// Routes, Walk and Match report it in Route.Meta. A later value for the same
+// key replaces an earlier one, also for the handlers of a pattern registered
+// separately for different methods, which share their metadata.
+//
+// Middleware added with Use run before routing; they can look up the
+// metadata of the route a request will be routed to with Match.
+func Meta(key string, value interface{}) RouteOption {
+	return func(mux *Mux, e *muxEntry) {
+		m := e.meta.clone()
+		m.values[key] = value
+		e.meta = m
+	}
+}
+
+// Tags attaches the tags to the route, like Meta, to group routes by area or
+// feature, as in generated API documentation. The handler and the middleware
+// of the route read them with RouteTags. Tags already attached are ignored.
+func Tags(tags ...string) RouteOption {
+	return func(mux *Mux, e *muxEntry) {
+		m := e.meta.clone()
+		for _, tag := range tags {
+			m.addTag(tag)
+		}
+		e.meta = m
+	}
+}
+
+// clone returns a copy of m, which may be nil, that can be modified.
+func (m *routeMeta) clone() *routeMeta {
+	c := &routeMeta{values: make(map[string]interface{})}
+	if m != nil {
+		for key, value := range m.values {
+			c.values[key] = value
+		}
+		c.tags = append(c.tags, m.tags...)
+	}
+	return c
+}
+
+// addTag adds tag to m unless m already has it.
+func (m *routeMeta) addTag(tag string) {
+	for _, t := range m.tags {
+		if t == tag {
+			return
+		}
+	}
+	m.tags = append(m.tags, tag)
+}
+
+// mergeMeta returns the metadata of both m1 and m2, those of m2 replacing
+// those of m1 under the same key.
+func mergeMeta(m1, m2 *routeMeta) *routeMeta {
+	if m2 == nil {
+		return m1
+	}
+	if m1 == nil {
+		return m2
+	}
+	m := m1.clone()
+	for key, value := range m2.values {
+		m.values[key] = value
+	}
+	for _, tag := range m2.tags {
+		m.addTag(tag)
+	}
+	return m
+}
+
+// withMeta returns a handler that calls next with the request carrying the
+// metadata m of the route it was routed to. Requests authenticated by
+// RequireJWT before they were routed, as with Use, must have a token granting
+// the scopes of the route.
+func withMeta(m *routeMeta, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if claims := Claims(r); claims != nil && !claims.allowScopes(w, m.values["scopes"]) {
+			return
+		}
+		next(w, r.WithContext(context.WithValue(r.Context(), metaKey{}, m)))
+	}
+}
+
+// RouteMeta returns the value attached with Meta under key to the route r
+// was routed to or nil if it has none.
+func RouteMeta(r *http.Request, key string) interface{} {
+	m, _ := r.Context().Value(metaKey{}).(*routeMeta)
+	if m == nil {
+		return nil
+	}
+	return m.values[key]
+}
+
+// RouteTags returns the tags attached with Tags to the route r was routed to,
+// in the order they were attached, or nil if it has none. The returned slice
+// is a copy and may be modified.
+func RouteTags(r *http.Request) []string {
+	m, _ := r.Context().Value(metaKey{}).(*routeMeta)
+	if m == nil {
+		return nil
+	}
+	return append([]string(nil), m.tags...)
+}
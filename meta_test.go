@@ -0,0 +1,99 @@
+package mux_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestMeta(t *testing.T) {
+	requireScope := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if scope, ok := mux.RouteMeta(r, "scope").(string); ok && r.Header.Get("X-Scope") != scope {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+	describe := func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%v %v", mux.RouteMeta(r, "scope"), mux.RouteTags(r))
+	}
+
+	m := mux.New()
+	m.Get("/admin", describe, mux.Meta("scope", "admin"), mux.Tags("admin", "users"), mux.WithMiddleware(requireScope))
+	m.Post("/admin", describe, mux.Meta("audit", true), mux.Tags("users", "audit"), mux.WithMiddleware(requireScope))
+	m.HandleFunc("/users/{id}", describe, mux.Meta("scope", "user"), mux.Meta("scope", "users"))
+	m.HandleFunc("/public", describe)
+
+	cases := []struct {
+		method string
+		path   string
+		scope  string
+		code   int
+		body   string
+	}{
+		{http.MethodGet, "/admin", "admin", http.StatusOK, "admin [admin users audit]"},
+		{http.MethodGet, "/admin", "", http.StatusForbidden, ""},
+		{http.MethodPost, "/admin", "admin", http.StatusOK, "admin [admin users audit]"},
+		{http.MethodGet, "/users/1", "", http.StatusOK, "users []"},
+		{http.MethodGet, "/public", "", http.StatusOK, "<nil> []"},
+	}
+	for _, c := range cases {
+		t.Run(c.method+" "+c.path+" "+c.scope, func(t *testing.T) {
+			r := httptest.NewRequest(c.method, c.path, nil)
+			r.Header.Set("X-Scope", c.scope)
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+			if rec.Code != c.code {
+				t.Errorf("got StatusCode %d, want %d", rec.Code, c.code)
+			}
+			if body := rec.Body.String(); body != c.body {
+				t.Errorf("got body %q, want %q", body, c.body)
+			}
+		})
+	}
+
+	t.Run("Match", func(t *testing.T) {
+		rt, _, ok := m.Match(http.MethodGet, "/admin")
+		if !ok {
+			t.Fatal("got no match, want match")
+		}
+		if want := map[string]interface{}{"scope": "admin", "audit": true}; !reflect.DeepEqual(rt.Meta, want) {
+			t.Errorf("got Meta %v, want %v", rt.Meta, want)
+		}
+		if want := []string{"admin", "users", "audit"}; !reflect.DeepEqual(rt.Tags, want) {
+			t.Errorf("got Tags %v, want %v", rt.Tags, want)
+		}
+
+		rt, _, _ = m.Match(http.MethodGet, "/public")
+		if rt.Meta != nil || rt.Tags != nil {
+			t.Errorf("got Meta %v and Tags %v, want none", rt.Meta, rt.Tags)
+		}
+	})
+}
+
+func TestMetaVariants(t *testing.T) {
+	describe := func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, fmt.Sprint(mux.RouteMeta(r, "version")))
+	}
+
+	m := mux.New()
+	m.HandleFunc("/api", describe, mux.Headers("Accept", "application/vnd.v2+json"), mux.Meta("version", 2))
+	m.HandleFunc("/api", describe, mux.Meta("version", 1))
+
+	for accept, want := range map[string]string{"application/vnd.v2+json": "2", "": "1"} {
+		r := httptest.NewRequest(http.MethodGet, "/api", nil)
+		r.Header.Set("Accept", accept)
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, r)
+		if body := rec.Body.String(); body != want {
+			t.Errorf("Accept %q: got body %q, want %q", accept, body, want)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package mux
+
+import (
+	"bufio"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultMetricsBuckets are the upper bounds, in seconds, of the buckets of
+// the request duration histogram of Metrics without buckets of its own.
+var DefaultMetricsBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Metrics records request metrics of a mux, labeled by request method and
+// matched pattern, and serves them in the Prometheus text format:
+//
+//	mux_requests_total{method,pattern,code}      counter
+//	mux_request_duration_seconds{method,pattern} histogram
+//	mux_requests_in_flight{method,pattern}       gauge
+//
+// The pattern label is the pattern as registered, like "/users/{id}", or ""
+// for requests routed to no pattern, which keeps the number of series low.
+// Methods other than the standard ones are labeled "OTHER". Requests to Quiet
+// routes are left out.
+//
+// Metrics is an http.Handler, so it can be registered for scraping:
+//
+//	metrics := mux.NewMetrics()
+//	m := mux.New(mux.WithMetrics(metrics))
+//	m.Handle("/metrics", metrics, mux.Quiet())
+type Metrics struct {
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[metricsKey]*metricsSeries
+}
+
+// metricsKey are the labels of the series of a method and pattern.
+type metricsKey struct {
+	method, pattern string
+}
+
+// metricsSeries are the metrics of a method and pattern.
+type metricsSeries struct {
+	codes    map[int]uint64 // requests by status code
+	buckets  []uint64       // durations by bucket, not cumulative
+	sum      float64        // sum of durations in seconds
+	count    uint64
+	inFlight int64
+}
+
+// NewMetrics returns new Metrics with a request duration histogram of the
+// given buckets, upper bounds in seconds, or DefaultMetricsBuckets if none.
+//
+// Panics if the buckets are not in increasing order.
+func NewMetrics(buckets ...float64) *Metrics {
+	if len(buckets) == 0 {
+		buckets = DefaultMetricsBuckets
+	}
+	for i := 1; i < len(buckets); i++ {
+		if buckets[i] <= buckets[i-1] {
+			panic("mux: metrics buckets must be in increasing order")
+		}
+	}
+	return &Metrics{
+		buckets: append([]float64(nil), buckets...),
+		series:  make(map[metricsKey]*metricsSeries),
+	}
+}
+
+// WithMetrics returns an Option that makes mux record the metrics of the
+// requests it serves in metrics. Several muxes may share metrics.
+func WithMetrics(metrics *Metrics) Option {
+	return func(mux *Mux) {
+		mux.metrics = metrics
+	}
+}
+
+// seriesFor returns the series of the method and pattern. m must be locked.
+func (m *Metrics) seriesFor(method, pattern string) *metricsSeries {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+	default:
+		method = "OTHER"
+	}
+	key := metricsKey{method, pattern}
+	s, ok := m.series[key]
+	if !ok {
+		s = &metricsSeries{codes: make(map[int]uint64), buckets: make([]uint64, len(m.buckets))}
+		m.series[key] = s
+	}
+	return s
+}
+
+// inFlight adds delta to the requests in flight for the method and pattern.
+func (m *Metrics) inFlight(method, pattern string, delta int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.seriesFor(method, pattern).inFlight += delta
+}
+
+// observe records a served request.
+func (m *Metrics) observe(info RequestInfo) {
+	seconds := info.Duration.Seconds()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := m.seriesFor(info.Method, info.Pattern)
+	s.codes[info.Status]++
+	if i := sort.SearchFloat64s(m.buckets, seconds); i < len(m.buckets) {
+		s.buckets[i]++
+	}
+	s.sum += seconds
+	s.count++
+}
+
+// ServeHTTP writes the metrics in the Prometheus text format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	keys := make([]metricsKey, 0, len(m.series))
+	series := make(map[metricsKey]metricsSeries, len(m.series))
+	for key, s := range m.series {
+		keys = append(keys, key)
+		c := *s
+		c.codes = make(map[int]uint64, len(s.codes))
+		for code, n := range s.codes {
+			c.codes[code] = n
+		}
+		c.buckets = append([]uint64(nil), s.buckets...)
+		series[key] = c
+	}
+	m.mu.Unlock()
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].pattern != keys[j].pattern {
+			return keys[i].pattern < keys[j].pattern
+		}
+		return keys[i].method < keys[j].method
+	})
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	b := bufio.NewWriter(w)
+	defer b.Flush()
+
+	b.WriteString("# HELP mux_requests_total Requests served by method, matched pattern and status code.\n")
+	b.WriteString("# TYPE mux_requests_total counter\n")
+	for _, key := range keys {
+		s := series[key]
+		codes := make([]int, 0, len(s.codes))
+		for code := range s.codes {
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+		for _, code := range codes {
+			writeSample(b, "mux_requests_total", key, "code", strconv.Itoa(code), strconv.FormatUint(s.codes[code], 10))
+		}
+	}
+
+	b.WriteString("# HELP mux_request_duration_seconds Request durations by method and matched pattern.\n")
+	b.WriteString("# TYPE mux_request_duration_seconds histogram\n")
+	for _, key := range keys {
+		s := series[key]
+		if s.count == 0 {
+			continue
+		}
+		var cumulative uint64
+		for i, bound := range m.buckets {
+			cumulative += s.buckets[i]
+			writeSample(b, "mux_request_duration_seconds_bucket", key, "le", formatFloat(bound), strconv.FormatUint(cumulative, 10))
+		}
+		writeSample(b, "mux_request_duration_seconds_bucket", key, "le", "+Inf", strconv.FormatUint(s.count, 10))
+		writeSample(b, "mux_request_duration_seconds_sum", key, "", "", formatFloat(s.sum))
+		writeSample(b, "mux_request_duration_seconds_count", key, "", "", strconv.FormatUint(s.count, 10))
+	}
+
+	b.WriteString("# HELP mux_requests_in_flight Requests being served by method and matched pattern.\n")
+	b.WriteString("# TYPE mux_requests_in_flight gauge\n")
+	for _, key := range keys {
+		if key.pattern != "" {
+			writeSample(b, "mux_requests_in_flight", key, "", "", strconv.FormatInt(series[key].inFlight, 10))
+		}
+	}
+}
+
+// writeSample writes a sample of the metric name with the labels of key and,
+// if label is not empty, label with the given value.
+func writeSample(b *bufio.Writer, name string, key metricsKey, label, labelValue, value string) {
+	b.WriteString(name)
+	b.WriteString(`{method="`)
+	b.WriteString(escapeLabelValue(key.method))
+	b.WriteString(`",pattern="`)
+	b.WriteString(escapeLabelValue(key.pattern))
+	b.WriteString(`"`)
+	if label != "" {
+		b.WriteString("," + label + `="`)
+		b.WriteString(escapeLabelValue(labelValue))
+		b.WriteString(`"`)
+	}
+	b.WriteString("} ")
+	b.WriteString(value)
+	b.WriteString("\n")
+}
+
+// labelValueEscaper escapes label values in the Prometheus text format.
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(s string) string {
+	return labelValueEscaper.Replace(s)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
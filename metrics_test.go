@@ -0,0 +1,85 @@
+package mux_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestMetrics(t *testing.T) {
+	metrics := mux.NewMetrics(0.5, 1)
+	m := mux.New(mux.WithMetrics(metrics))
+	m.HandleFunc("/users/{id}", handlerFactory(http.StatusOK, "user"))
+	m.HandleFunc("/health", handlerFactory(http.StatusOK, "ok"), mux.Quiet())
+	m.Handle("/metrics", metrics, mux.Quiet())
+
+	for _, req := range []struct{ method, path string }{
+		{http.MethodGet, "/users/1"},
+		{http.MethodGet, "/users/2"},
+		{"PURGE", "/users/3"},
+		{http.MethodGet, "/missing"},
+		{http.MethodGet, "/health"},
+	} {
+		m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(req.method, req.path, nil))
+	}
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	resp := w.Result()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("got Content-Type %q, want text format", ct)
+	}
+
+	for _, want := range []string{
+		`mux_requests_total{method="GET",pattern="",code="404"} 1`,
+		`mux_requests_total{method="GET",pattern="/users/{id}",code="200"} 2`,
+		`mux_requests_total{method="OTHER",pattern="/users/{id}",code="200"} 1`,
+		`mux_request_duration_seconds_bucket{method="GET",pattern="/users/{id}",le="0.5"} 2`,
+		`mux_request_duration_seconds_bucket{method="GET",pattern="/users/{id}",le="1"} 2`,
+		`mux_request_duration_seconds_bucket{method="GET",pattern="/users/{id}",le="+Inf"} 2`,
+		`mux_request_duration_seconds_count{method="GET",pattern="/users/{id}"} 2`,
+		`mux_requests_in_flight{method="GET",pattern="/users/{id}"} 0`,
+	} {
+		if !strings.Contains(string(body), want+"\n") {
+			t.Errorf("got metrics\n%s\nwant line %s", body, want)
+		}
+	}
+	for _, unwanted := range []string{"/health", "/metrics"} {
+		if strings.Contains(string(body), unwanted) {
+			t.Errorf("got metrics of Quiet route %s", unwanted)
+		}
+	}
+}
+
+func TestMetricsInFlight(t *testing.T) {
+	metrics := mux.NewMetrics()
+	m := mux.New(mux.WithMetrics(metrics))
+	var body []byte
+	m.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		w2 := httptest.NewRecorder()
+		metrics.ServeHTTP(w2, r)
+		body = w2.Body.Bytes()
+	})
+
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+	if want := `mux_requests_in_flight{method="GET",pattern="/slow"} 1`; !strings.Contains(string(body), want) {
+		t.Errorf("got metrics\n%s\nwant line %s", body, want)
+	}
+}
+
+func TestNewMetricsPanic(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("got no panic, want panic")
+		}
+	}()
+	mux.NewMetrics(1, 0.5)
+}
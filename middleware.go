@@ -0,0 +1,78 @@
+package mux
+
+import (
+	"net/http"
+	"reflect"
+	"runtime"
+)
+
+// Use appends middleware to the middleware of mux. Middleware wrap the
+// routing of every request mux serves, in the order they were added, so the
+// first middleware is the outermost one. They see all requests, including
+// those answered with a redirect, 405 Method Not Allowed or by notFound or
+// the fallback, and may change the request before it is routed.
+//
+// The middleware of a submux wrap its routes when it is mounted; middleware
+// added to the submux after Mount do not. The middleware of a fallback mux
+// wrap the handler it routes a request to.
+//
+// Panics if a middleware is nil.
+func (mux *Mux) Use(middleware ...func(http.Handler) http.Handler) {
+	mux.lock()
+	defer mux.unlock()
+
+	for _, mw := range middleware {
+		if mw == nil {
+			panic("mux: nil middleware")
+		}
+	}
+	mux.middleware = append(mux.middleware, middleware...)
+	mux.chain = chain(mux.middleware, http.HandlerFunc(mux.serve))
+}
+
+// WithMiddleware wraps the route's handler in middleware, the first
+// middleware outermost. Unlike the middleware added with Use, they run only
+// for requests routed to the handler, after routing.
+//
+// Panics if a middleware is nil.
+func WithMiddleware(middleware ...func(http.Handler) http.Handler) RouteOption {
+	for _, mw := range middleware {
+		if mw == nil {
+			panic("mux: nil middleware")
+		}
+	}
+
+	return func(mux *Mux, e *muxEntry) {
+		e.handler = chain(middleware, e.handler).ServeHTTP
+		for _, mw := range middleware {
+			e.middleware = append(e.middleware, funcName(mw))
+		}
+	}
+}
+
+// funcName returns the name of the function f, like
+// "example.com/auth.RequireUser" or "main.main.func1" for a function literal.
+func funcName(f interface{}) string {
+	if fn := runtime.FuncForPC(reflect.ValueOf(f).Pointer()); fn != nil {
+		return fn.Name()
+	}
+	return "?"
+}
+
+// chain returns h wrapped in middleware, the first middleware outermost.
+func chain(middleware []func(http.Handler) http.Handler, h http.Handler) http.Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
+	}
+	return h
+}
+
+// withMiddleware returns e with its handlers wrapped in middleware.
+func (e muxEntry) withMiddleware(middleware []func(http.Handler) http.Handler) muxEntry {
+	if len(middleware) == 0 {
+		return e
+	}
+	return e.mapHandlers(func(h http.HandlerFunc) http.HandlerFunc {
+		return chain(middleware, h).ServeHTTP
+	})
+}
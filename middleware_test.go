@@ -0,0 +1,150 @@
+package mux_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+// tag returns a middleware that appends name to the X-Tags response header.
+func tag(name string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("X-Tags", name)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestUse(t *testing.T) {
+	newMux := func() *mux.Mux {
+		sub := mux.New()
+		sub.HandleFunc("/b", handlerFactory(http.StatusTeapot, "b"))
+		sub.Use(tag("sub"))
+
+		fb := mux.New()
+		fb.HandleFunc("/old", handlerFactory(http.StatusTeapot, "old"))
+		fb.Use(tag("fallback"))
+
+		m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found")))
+		m.HandleFunc("/a", handlerFactory(http.StatusTeapot, "a"))
+		m.Get("/get", handlerFactory(http.StatusTeapot, "get"))
+		m.Mount("/sub", sub)
+		m.SetFallback(fb)
+		m.Use(tag("1"), tag("2"))
+		return m
+	}
+
+	cases := []struct {
+		method string
+		path   string
+		code   int
+		tags   string
+	}{
+		{http.MethodGet, "/a", http.StatusTeapot, "1 2"},
+		{http.MethodGet, "/a/", http.StatusPermanentRedirect, "1 2"},
+		{http.MethodPost, "/get", http.StatusMethodNotAllowed, "1 2"},
+		{http.MethodGet, "/missing", http.StatusNotFound, "1 2"},
+		{http.MethodGet, "/sub/b", http.StatusTeapot, "1 2 sub"},
+		{http.MethodGet, "/old", http.StatusTeapot, "1 2 fallback"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.method+" "+c.path, func(t *testing.T) {
+			r := httptest.NewRequest(c.method, c.path, nil)
+			rec := httptest.NewRecorder()
+			newMux().ServeHTTP(rec, r)
+			resp := rec.Result()
+
+			if resp.StatusCode != c.code {
+				t.Errorf("got StatusCode %d, want %d", resp.StatusCode, c.code)
+			}
+
+			tags := strings.Join(resp.Header["X-Tags"], " ")
+			if tags != c.tags {
+				t.Errorf("got X-Tags %q, want %q", tags, c.tags)
+			}
+		})
+	}
+
+	t.Run("rewrite", func(t *testing.T) {
+		m := mux.New()
+		m.HandleFunc("/hello", handlerFactory(http.StatusTeapot, "hello"))
+		m.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				r.URL.Path = strings.ToLower(r.URL.Path)
+				next.ServeHTTP(w, r)
+			})
+		})
+
+		r := httptest.NewRequest(http.MethodGet, "/HELLO", nil)
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, r)
+
+		b, err := ioutil.ReadAll(rec.Result().Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body := string(b)
+		if body != "hello" {
+			t.Errorf("got body %q, want hello", body)
+		}
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("got no panic, want panic")
+			}
+		}()
+
+		mux.New().Use(nil)
+	})
+}
+
+func TestWithMiddleware(t *testing.T) {
+	m := mux.New()
+	m.HandleFunc("/a", handlerFactory(http.StatusTeapot, "a"), mux.WithMiddleware(tag("1"), tag("2")))
+	m.Get("/b", handlerFactory(http.StatusTeapot, "b"), mux.WithMiddleware(tag("get")))
+	m.Post("/b", handlerFactory(http.StatusTeapot, "b"))
+	m.Use(tag("use"))
+
+	cases := []struct {
+		method string
+		path   string
+		tags   string
+	}{
+		{http.MethodGet, "/a", "use 1 2"},
+		{http.MethodGet, "/a/", "use"},
+		{http.MethodGet, "/b", "use get"},
+		{http.MethodPost, "/b", "use"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.method+" "+c.path, func(t *testing.T) {
+			r := httptest.NewRequest(c.method, c.path, nil)
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+
+			tags := strings.Join(rec.Result().Header["X-Tags"], " ")
+			if tags != c.tags {
+				t.Errorf("got X-Tags %q, want %q", tags, c.tags)
+			}
+		})
+	}
+
+	t.Run("nil", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("got no panic, want panic")
+			}
+		}()
+
+		mux.WithMiddleware(nil)
+	})
+}
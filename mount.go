@@ -0,0 +1,182 @@
+package mux
+
+import (
+	"context"
+	"net/http"
+	"sort"
+)
+
+// mount is a handler mounted at a path prefix.
+type mount struct {
+	prefix  string
+	handler http.Handler
+
+	// keepPath tells whether the handler, the notFound of a submux whose
+	// routes were copied by Mount or one set by NotFoundUnder, is called
+	// without prefix stripped.
+	keepPath bool
+
+	version string // API version of a submux mounted by Version
+}
+
+// MountHandler routes requests whose path is prefix or begins with prefix
+// followed by "/" to handler, with prefix stripped from the path like
+// http.StripPrefix does, so a file server mounted at "/static" is asked for
+// "/app.css" when "/static/app.css" is requested and for "/" when "/static"
+// is requested. The handler is called for all methods.
+//
+// Patterns of mux take precedence over mounted handlers, and a handler
+// mounted at a longer prefix over one mounted at a shorter prefix. A *Mux
+// handler is mounted as with MountLive.
+//
+// Panics if prefix does not begin with "/" or ends with "/", if a handler is
+// already mounted at prefix or if handler is nil.
+func (mux *Mux) MountHandler(prefix string, handler http.Handler) {
+	mux.lock()
+	defer mux.unlock()
+
+	if prefix == "" || prefix[0] != '/' || prefix[len(prefix)-1] == '/' {
+		panic("mux: mount prefix must begin with \"/\" and must not end with \"/\"")
+	}
+	if handler == nil {
+		panic("mux: nil handler")
+	}
+	mux.mountHandler(mount{prefix: prefix, handler: handler})
+}
+
+// mountHandler mounts the handler of m, which has a valid prefix, at its
+// prefix. mux must be locked.
+func (mux *Mux) mountHandler(m mount) {
+	prefix := m.prefix
+	for i, m := range mux.mounts {
+		if m.prefix != prefix {
+			continue
+		}
+		if !m.keepPath {
+			panic("mux: multiple mounts at " + prefix)
+		}
+		// The handler replaces the notFound of a submux mounted by Mount.
+		mux.mounts = append(mux.mounts[:i:i], mux.mounts[i+1:]...)
+		break
+	}
+
+	for _, pattern := range mux.patterns {
+		if !mux.m[pattern].regexp && hasPathPrefix(pattern, prefix) {
+			mux.logf(context.Background(), levelWarn, "mux: pattern shadows mounted handler", "pattern", pattern, "prefix", prefix)
+		}
+	}
+	mux.addMount(m)
+}
+
+// mountNotFound mounts the notFound of a submux mounted by Mount at prefix,
+// unless a handler is already mounted there. mux must be locked.
+func (mux *Mux) mountNotFound(prefix string, notFound http.HandlerFunc) {
+	for _, m := range mux.mounts {
+		if m.prefix == prefix {
+			return
+		}
+	}
+	mux.addMount(mount{prefix: prefix, handler: notFound, keepPath: true})
+}
+
+// NotFoundUnder sets the handler called instead of notFound for requests
+// whose path is prefix or begins with prefix followed by "/" that no pattern
+// matches, replacing the notFound of a submux mounted at prefix by Mount. The
+// handler is called with the path unchanged. The handler for the longest
+// matching prefix is called.
+//
+// Panics if prefix does not begin with "/" or ends with "/", if handler is nil
+// or if a handler is mounted at prefix with MountHandler or MountLive.
+func (mux *Mux) NotFoundUnder(prefix string, handler http.HandlerFunc) {
+	mux.lock()
+	defer mux.unlock()
+
+	if prefix == "" || prefix[0] != '/' || prefix[len(prefix)-1] == '/' {
+		panic("mux: prefix must begin with \"/\" and must not end with \"/\"")
+	}
+	if handler == nil {
+		panic("mux: nil handler")
+	}
+	for i, m := range mux.mounts {
+		if m.prefix != prefix {
+			continue
+		}
+		if !m.keepPath {
+			panic("mux: handler mounted at " + prefix)
+		}
+		mux.mounts[i].handler = handler
+		return
+	}
+	mux.addMount(mount{prefix: prefix, handler: handler, keepPath: true})
+}
+
+// addMount adds m to the mounts of mux, keeping them sorted longest prefix
+// first.
+func (mux *Mux) addMount(m mount) {
+	mux.mounts = append(mux.mounts, m)
+	sort.SliceStable(mux.mounts, func(i, j int) bool {
+		return len(mux.mounts[i].prefix) > len(mux.mounts[j].prefix)
+	})
+}
+
+// MountLive routes requests whose path is prefix or begins with prefix
+// followed by "/" into submux with prefix stripped from the path, like
+// MountHandler. Unlike Mount, which copies the routes of submux, submux is
+// consulted on every request, so routes registered on it after MountLive are
+// served too. Requests under prefix that neither a pattern of mux nor submux
+// route are handled by the notFound of submux. Redirects issued by submux
+// keep prefix.
+//
+// Panics if prefix does not begin with "/" or ends with "/", if a handler is
+// already mounted at prefix or if submux is nil or mux itself.
+func (mux *Mux) MountLive(prefix string, submux *Mux) {
+	if submux == nil {
+		panic("mux: nil submux")
+	}
+	if submux == mux {
+		panic("mux: submux must not be the mux itself")
+	}
+	mux.MountHandler(prefix, submux)
+}
+
+// mounted returns a handler for r if its path is under the prefix of a
+// mounted handler, recording the decision in ex unless ex is nil. Only the
+// notFound handlers mounted by Mount are considered if notFound and only the
+// others otherwise.
+func (t *table) mounted(r *http.Request, ex *Explanation, notFound bool) (http.HandlerFunc, bool) {
+	path := r.URL.Path
+	for _, m := range t.mounts {
+		if m.keepPath != notFound || !hasPathPrefix(path, m.prefix) {
+			continue
+		}
+
+		var inner *Explanation
+		if sub, ok := m.handler.(*Mux); ok && ex != nil {
+			r := stripPrefix(r, m.prefix)
+			inner = ex.nested(r)
+			sub.match(r, inner)
+		}
+
+		ex.mount(m.prefix, inner)
+		if m.keepPath {
+			return m.handler.ServeHTTP, true
+		}
+		prefix, h := m.prefix, m.handler
+		if version := m.version; version != "" {
+			return func(w http.ResponseWriter, r *http.Request) {
+				h.ServeHTTP(w, withVersion(stripPrefix(r, prefix), version))
+			}, true
+		}
+		return func(w http.ResponseWriter, r *http.Request) {
+			h.ServeHTTP(w, stripPrefix(r, prefix))
+		}, true
+	}
+	return nil, false
+}
+
+// hasPathPrefix determines whether path is prefix or begins with prefix
+// followed by "/".
+func hasPathPrefix(path, prefix string) bool {
+	return len(path) >= len(prefix) && path[:len(prefix)] == prefix &&
+		(len(path) == len(prefix) || path[len(prefix)] == '/')
+}
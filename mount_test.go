@@ -0,0 +1,313 @@
+package mux_test
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestMountHandler(t *testing.T) {
+	echo := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+			io.WriteString(w, name+" "+r.URL.Path)
+		}
+	}
+
+	newMux := func() *mux.Mux {
+		m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found")))
+		m.MountHandler("/api", echo("api"))
+		m.MountHandler("/api/v2", echo("v2"))
+		m.HandleFunc("/api/health", handlerFactory(http.StatusTeapot, "health"))
+		m.MountHandler("/static", http.FileServer(http.FS(fstest.MapFS{
+			"app.css": &fstest.MapFile{Data: []byte("css")},
+		})))
+		return m
+	}
+
+	t.Run("green", func(t *testing.T) {
+		cases := []struct {
+			path string
+			code int
+			body string
+		}{
+			{"/api", http.StatusTeapot, "api /"},
+			{"/api/", http.StatusTeapot, "api /"},
+			{"/api/users/1", http.StatusTeapot, "api /users/1"},
+			{"/api/v2/users", http.StatusTeapot, "v2 /users"},
+			{"/api/v2x", http.StatusTeapot, "api /v2x"},
+			{"/api/health", http.StatusTeapot, "health"},
+			{"/apix", http.StatusNotFound, "not found"},
+			{"/static/app.css", http.StatusOK, "css"},
+		}
+
+		for _, c := range cases {
+			t.Run(c.path, func(t *testing.T) {
+				r := httptest.NewRequest(http.MethodGet, c.path, nil)
+				rec := httptest.NewRecorder()
+				newMux().ServeHTTP(rec, r)
+				resp := rec.Result()
+
+				if resp.StatusCode != c.code {
+					t.Errorf("got StatusCode %d, want %d", resp.StatusCode, c.code)
+				}
+
+				b, err := ioutil.ReadAll(resp.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				body := string(b)
+				if body != c.body {
+					t.Errorf("got body %q, want %q", body, c.body)
+				}
+			})
+		}
+	})
+
+	t.Run("red", func(t *testing.T) {
+		cases := []struct {
+			name    string
+			prefix  string
+			handler http.Handler
+		}{
+			{"empty", "", echo("")},
+			{"no leading slash", "api", echo("")},
+			{"trailing slash", "/api/", echo("")},
+			{"duplicate", "/api", echo("")},
+			{"nil handler", "/b", nil},
+		}
+
+		for _, c := range cases {
+			t.Run(c.name, func(t *testing.T) {
+				defer func() {
+					if recover() == nil {
+						t.Error("got no panic, want panic")
+					}
+				}()
+
+				newMux().MountHandler(c.prefix, c.handler)
+			})
+		}
+	})
+}
+
+func TestMountLive(t *testing.T) {
+	echo := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		io.WriteString(w, r.URL.Path+" "+mux.Param(r, "id"))
+	}
+
+	sub := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "sub not found")))
+	sub.HandleFunc("/", echo)
+
+	m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found")))
+	m.MountLive("/blog", sub)
+	m.HandleFunc("/blog/{id}/x", handlerFactory(http.StatusTeapot, "parent"))
+
+	// Registered after mounting.
+	sub.HandleFunc("/posts/{id}", echo)
+
+	cases := []struct {
+		path     string
+		code     int
+		body     string
+		location string
+	}{
+		{"/blog", http.StatusTeapot, "/ ", ""},
+		{"/blog/posts/1", http.StatusTeapot, "/posts/1 1", ""},
+		{"/blog/posts/1/", http.StatusPermanentRedirect, "", "/blog/posts/1"},
+		{"/blog/1/x", http.StatusTeapot, "parent", ""},
+		{"/blog/missing", http.StatusNotFound, "sub not found", ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, c.path, nil)
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+			resp := rec.Result()
+
+			if resp.StatusCode != c.code {
+				t.Errorf("got StatusCode %d, want %d", resp.StatusCode, c.code)
+			}
+
+			location := resp.Header.Get("Location")
+			if location != c.location {
+				t.Errorf("got Location %q, want %q", location, c.location)
+			}
+
+			if c.location != "" {
+				return
+			}
+
+			b, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body := string(b)
+			if body != c.body {
+				t.Errorf("got body %q, want %q", body, c.body)
+			}
+		})
+	}
+
+	t.Run("itself", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("got no panic, want panic")
+			}
+		}()
+
+		m.MountLive("/m", m)
+	})
+}
+
+func TestMountNotFound(t *testing.T) {
+	sub := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "sub not found")))
+	sub.HandleFunc("/a", handlerFactory(http.StatusTeapot, "a"))
+
+	m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found")))
+	m.Mount("/sub", sub)
+	m.HandleFunc("/sub/b", handlerFactory(http.StatusTeapot, "b"))
+
+	cases := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/sub/a", http.StatusTeapot, "a"},
+		{"/sub/b", http.StatusTeapot, "b"},
+		{"/sub/c", http.StatusNotFound, "sub not found"},
+		{"/sub", http.StatusNotFound, "sub not found"},
+		{"/subx", http.StatusNotFound, "not found"},
+		{"/c", http.StatusNotFound, "not found"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, c.path, nil)
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+			resp := rec.Result()
+
+			if resp.StatusCode != c.code {
+				t.Errorf("got StatusCode %d, want %d", resp.StatusCode, c.code)
+			}
+
+			b, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body := string(b)
+			if body != c.body {
+				t.Errorf("got body %q, want %q", body, c.body)
+			}
+		})
+	}
+
+	t.Run("MountHandler", func(t *testing.T) {
+		m.MountHandler("/sub", handlerFactory(http.StatusTeapot, "handler"))
+
+		r := httptest.NewRequest(http.MethodGet, "/sub/c", nil)
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, r)
+
+		b, err := ioutil.ReadAll(rec.Result().Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body := string(b)
+		if body != "handler" {
+			t.Errorf("got body %q, want handler", body)
+		}
+	})
+}
+
+func TestNotFoundUnder(t *testing.T) {
+	sub := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "sub not found")))
+	sub.HandleFunc("/a", handlerFactory(http.StatusTeapot, "a"))
+
+	m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found")))
+	m.HandleFunc("/api/users", handlerFactory(http.StatusTeapot, "users"))
+	m.Mount("/sub", sub)
+	m.NotFoundUnder("/api", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		io.WriteString(w, "api not found "+r.URL.Path)
+	})
+	m.NotFoundUnder("/api/v2", handlerFactory(http.StatusNotFound, "v2 not found"))
+	m.NotFoundUnder("/sub", handlerFactory(http.StatusNotFound, "sub replaced"))
+
+	cases := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/api/users", http.StatusTeapot, "users"},
+		{"/api/posts", http.StatusNotFound, "api not found /api/posts"},
+		{"/api", http.StatusNotFound, "api not found /api"},
+		{"/api/v2/users", http.StatusNotFound, "v2 not found"},
+		{"/sub/a", http.StatusTeapot, "a"},
+		{"/sub/b", http.StatusNotFound, "sub replaced"},
+		{"/apix", http.StatusNotFound, "not found"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, c.path, nil)
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+			resp := rec.Result()
+
+			if resp.StatusCode != c.code {
+				t.Errorf("got StatusCode %d, want %d", resp.StatusCode, c.code)
+			}
+
+			b, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body := string(b)
+			if body != c.body {
+				t.Errorf("got body %q, want %q", body, c.body)
+			}
+		})
+	}
+
+	t.Run("red", func(t *testing.T) {
+		m := mux.New()
+		m.MountHandler("/static", handlerFactory(http.StatusTeapot, ""))
+
+		cases := []struct {
+			name    string
+			prefix  string
+			handler http.HandlerFunc
+		}{
+			{"empty prefix", "", http.NotFound},
+			{"trailing slash", "/a/", http.NotFound},
+			{"nil handler", "/a", nil},
+			{"mounted", "/static", http.NotFound},
+		}
+
+		for _, c := range cases {
+			t.Run(c.name, func(t *testing.T) {
+				defer func() {
+					if recover() == nil {
+						t.Error("got no panic, want panic")
+					}
+				}()
+
+				m.NotFoundUnder(c.prefix, c.handler)
+			})
+		}
+	})
+}
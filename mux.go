@@ -1,78 +1,376 @@
 // Package mux is a stripped-down http.ServeMux with a few select extras like
 // regular expression patterns and mounting.
 //
-// mux supports only http.HandlerFunc, http.Handler is not supported.
 // Non-regexp handler pattern must begin with a slash "/" and must not end with
-// a slash "/".
-// Requests with a trailing slash are redirected to the slash-less version.
+// a slash "/", unless SubtreePatterns makes such patterns match subtrees.
+// Requests with a trailing slash are redirected to the slash-less version
+// unless TrailingSlash sets another policy.
 package mux
 
 import (
 	"context"
+	"io"
 	"net/http"
 	"net/url"
 	"regexp"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
+
+	"golang.org/x/text/unicode/norm"
 )
 
 // Mux is an HTTP request multiplexer.
 // It matches the URL of each incoming request against a list of registered
 // patterns and calls the handler for the pattern that matches. It calls
 // notFound if pattern does not match.
+//
+// The most specific pattern matching a path wins, regardless of registration
+// order: patterns without parameters, then patterns with parameters, then
+// regexps and last patterns ending in a wildcard, the catch-alls. Among
+// patterns with parameters, a literal segment takes precedence over a
+// parameter in the same position and a constrained parameter over an
+// unconstrained one, so "/users/new" wins over "/users/{id:[0-9]+}" and that
+// over "/users/{id}". Catch-alls are ordered the same way, so "/files/{p...}"
+// wins over "/{p...}". Regexp patterns are tried in the order they were first
+// registered and the first that matches wins, so register more specific
+// regexps before overlapping general ones. A regexp matching only with the
+// trailing slash of the path removed wins like a match and redirects. Only
+// then are the other patterns tried against the path with the trailing slash
+// removed. Mounted regexp patterns are registered in the order they were
+// registered on the submux. Registering a pattern matching the same paths as
+// another, differing only in the names of its parameters, panics; Overlaps
+// reports regexps shadowed by other patterns.
+//
+// Requests are routed without locking: every change to a mux, such as
+// registering a pattern, copies its routing table and puts the copy into
+// service atomically, at a cost proportional to the number of patterns.
 type Mux struct {
-	mu       sync.RWMutex
+	mu      sync.Mutex   // guards changes to table
+	table                // table being changed
+	current atomic.Value // copy of table in service, a *table
+}
+
+// table is the routing table of a mux with its options.
+type table struct {
 	m        map[string]muxEntry
+	patterns []string // keys of m in registration order
+	tree     *node    // non-regexp patterns
+	regexps  []string // regexp patterns in registration order
+	mounts   []mount  // mounted handlers, longest prefix first
 	notFound http.HandlerFunc
+	locales  *localeRouter
+	versions *versionRouter
+	formats  map[string]bool // formats of routes, see Formats
+	fallback http.Handler
+	unicode  unicodeMode
+	slash    SlashPolicy
+
+	stringKeys bool // whether parameters are added under string context keys
+	escaped    bool // whether patterns are matched against escaped paths
+
+	lowercaseASCII bool // whether only ASCII letters are lowercased
+	exactPaths     bool // whether a route has ExactPath
+	lowercase      bool // whether paths are redirected to lowercase
+	subtree        bool // whether patterns ending in "/" match subtrees
+	negotiated     bool // whether errors are replied to as by NegotiatedErrors
+
+	redirectCode int // status code of canonical redirects, 0 for 308
+
+	names map[string]string // patterns by route name
+
+	csrf    *CSRF  // CSRF protection of routes if not nil
+	proxies ipNets // trusted proxies
+
+	middleware []func(http.Handler) http.Handler
+	chain      http.Handler // serve wrapped in middleware, nil if none
+
+	methodNotAllowed     http.HandlerFunc
+	unsupportedMediaType http.HandlerFunc
+
+	recovery  func(http.ResponseWriter, *http.Request, interface{})    // recovers from panics if not nil
+	onRequest func(RequestInfo)                                        // called for served requests if not nil
+	metrics   *Metrics                                                 // records metrics of served requests if not nil
+	trace     func(*http.Request) (context.Context, func(RequestInfo)) // starts a span per request if not nil
+	requestID bool                                                     // whether requests are given IDs
+	stats     *stats                                                   // per route stats if tracked
+
+	cleanPaths bool // whether paths are redirected to their clean form
+	rewrite    bool // whether requests that must keep their method are rewritten
+
+	frozen bool              // whether changes panic
+	static map[string]string // patterns without parameters by path if frozen
+	log    logFunc           // logs diagnostics if not nil
 }
 
 type muxEntry struct {
-	handler http.HandlerFunc
-	regexp  bool // whether pattern is an regular expression
+	handler    http.HandlerFunc            // handler for any method
+	methods    map[string]http.HandlerFunc // handlers by request method
+	regexp     bool                        // whether pattern is an regular expression
+	re         *regexp.Regexp              // compiled pattern if regexp
+	segments   []segment                   // pattern segments if it has parameters
+	matchQuery bool                        // whether re is matched against path and query
+	inFlight   []*int64                    // numbers of running handlers if limited
+	quiet      bool                        // whether left out of logging and metrics
+	csrfExempt bool                        // whether left out of CSRF protection
+	stringKeys bool                        // whether parameters are added under string keys
+	escaped    bool                        // whether matched against the escaped path
+	exactPath  bool                        // whether paths it matches are not canonicalized
+	noRedirect bool                        // whether requests are never redirected
+	slash      SlashPolicy                 // trailing slash policy, 0 for that of the mux
+
+	paramsToQuery queryMode  // whether parameters are added to the query
+	names         []string   // route names
+	middleware    []string   // names of the route middleware, outermost first
+	meta          *routeMeta // metadata attached with Meta and Tags or nil
+	produces      []string   // media types produced if chosen by Accept
+	formats       []string   // extensions of paths also matched, see Formats
+
+	matchers []func(*http.Request) bool // predicates requests must satisfy
+	variants []muxEntry                 // entries with matchers, tried in order
 }
 
-// New allocates and returns a new Mux.
-func New(notFound http.HandlerFunc) *Mux {
-	if notFound == nil {
-		panic("mux: nil notFound")
+// Option configures a Mux.
+type Option func(*Mux)
+
+// New allocates and returns a new Mux configured by the options, applied in
+// order. Requests no pattern, mounted handler or fallback handles are replied
+// to with http.NotFound unless WithNotFound sets another handler:
+//
+//	m := mux.New(
+//		mux.WithNotFound(notFound),
+//		mux.WithRecovery(recovered),
+//		mux.TrailingSlash(mux.IgnoreTrailingSlash),
+//		mux.RedirectLowercase(),
+//	)
+func New(opts ...Option) *Mux {
+	mux := new(Mux)
+	for _, opt := range opts {
+		opt(mux)
 	}
-	return &Mux{notFound: notFound}
+	if mux.notFound == nil {
+		mux.notFound = http.NotFound
+	}
+	mux.current.Store(mux.table.clone())
+	return mux
+}
+
+// WithNotFound sets the handler called for requests no pattern, mounted
+// handler or fallback handles, like NotFound. A nil handler sets
+// http.NotFound.
+func WithNotFound(handler http.HandlerFunc) Option {
+	return func(mux *Mux) {
+		if handler == nil {
+			handler = http.NotFound
+		}
+		mux.notFound = handler
+	}
+}
+
+// WithMethodNotAllowed sets the handler called when patterns match the path
+// of a request but none has a handler for its method, like MethodNotAllowed.
+func WithMethodNotAllowed(handler http.HandlerFunc) Option {
+	return func(mux *Mux) {
+		mux.methodNotAllowed = handler
+	}
+}
+
+// NotFound sets the handler called for requests no pattern, mounted handler
+// or fallback handles, replacing the one set with WithNotFound. A nil handler
+// sets http.NotFound. Use NotFoundUnder for the requests under a path prefix.
+func (mux *Mux) NotFound(handler http.HandlerFunc) {
+	mux.lock()
+	defer mux.unlock()
+
+	if handler == nil {
+		handler = http.NotFound
+	}
+	mux.notFound = handler
 }
 
-// Mount submux into mux with prefix added to submux's patterns.
+// Mount submux into mux with prefix added to submux's patterns. The pattern
+// "/" of submux becomes prefix itself, so a submux index mounted at "/blog"
+// is served at "/blog", unless it is a subtree pattern, which becomes
+// prefix + "/".
+//
+// The routes are copied, so routes registered on submux after Mount are not
+// served by mux; use MountLive for that.
+//
+// Requests under a non-empty prefix that no pattern matches are handled by
+// the notFound of submux at the time of Mount, unless NotFoundUnder sets
+// another handler for prefix.
+//
+// Panics if prefix is not empty and does not begin with "/" or ends with "/",
+// or if a pattern of submux, with prefix added, is already registered on mux
+// for the same method. With an empty prefix, this includes both muxes
+// registering "/".
 func (mux *Mux) Mount(prefix string, submux *Mux) {
-	for pattern, e := range submux.m {
+	if prefix != "" && (prefix[0] != '/' || prefix[len(prefix)-1] == '/') {
+		panic("mux: mount prefix must begin with \"/\" and must not end with \"/\"")
+	}
+
+	sub := submux.load()
+	patterns := make([]string, len(sub.patterns))
+	entries := make(map[string]muxEntry, len(sub.m))
+	for i, pattern := range sub.patterns {
 		var p string
-		if prefix != "" && pattern == "/" {
+		if prefix != "" && pattern == "/" && !sub.m[pattern].subtree() {
 			p = prefix
 		} else {
 			p = prefix + pattern
 		}
+		patterns[i] = p
+		entries[p] = sub.m[pattern].withMiddleware(sub.middleware)
+	}
+
+	mux.lock()
+	defer mux.unlock()
 
-		mux.register(p, e.handler, e.regexp)
+	for p, e := range entries {
+		if old, ok := mux.m[p]; !ok || !conflict(old, e) {
+			continue
+		}
+		if p == "/" {
+			panic("mux: Mount with empty prefix of a submux registering \"/\" on a mux registering \"/\"")
+		}
+		panic("mux: Mount of " + p + " already registered")
+	}
+	for _, p := range patterns {
+		mux.add("", p, entries[p], false)
+	}
+	if prefix != "" {
+		mux.mountNotFound(prefix, sub.notFound)
 	}
 }
 
+// RouteOption configures a route at registration. Options are applied in
+// order, so an option that wraps the handler wraps it as already wrapped by
+// the options before it.
+type RouteOption func(*Mux, *muxEntry)
+
 // HandleFunc registers the handler function for the given pattern.
-func (mux *Mux) HandleFunc(pattern string, handler http.HandlerFunc) {
-	mux.register(pattern, handler, false)
+//
+// A whole segment of the pattern of the form "{name}" or ":name" is a
+// parameter that matches any non-empty segment, so "/users/{id}" matches
+// "/users/12" but not "/users/" or "/users/12/posts". A parameter of the
+// form "{name:re}" only matches segments matching the regular expression re
+// as a whole, so "/users/{id:[0-9]+}" does not match "/users/new". The last
+// segment may be a wildcard of the form "{name...}" or "*" that matches the
+// rest of the path, so "/files/{path...}" matches "/files/", "/files/a" and
+// "/files/a/b/" but not "/files". Handlers get the values of the parameters
+// with Param like named submatches of regexp patterns; the value of "*" is
+// Param(r, "*").
+//
+// As with http.ServeMux, the pattern may begin with a method followed by
+// spaces, so HandleFunc("GET /users/{id}", h) is Method("GET", "/users/{id}",
+// h).
+func (mux *Mux) HandleFunc(pattern string, handler http.HandlerFunc, opts ...RouteOption) {
+	method, pattern := splitMethod(pattern)
+	mux.register(method, pattern, muxEntry{handler: handler}, opts...)
+}
+
+// splitMethod splits a pattern of the form "METHOD /path" into its method and
+// path. The method is empty if pattern has none. Panics if the method is not
+// a valid token.
+func splitMethod(pattern string) (method, path string) {
+	i := strings.IndexAny(pattern, " \t")
+	if i < 0 || strings.HasPrefix(pattern, "/") {
+		return "", pattern
+	}
+	method, path = pattern[:i], strings.TrimLeft(pattern[i:], " \t")
+	if strings.IndexFunc(method, func(r rune) bool {
+		return r <= ' ' || r >= 0x7f || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, r)
+	}) >= 0 {
+		panic("mux: invalid method " + method + " in pattern " + pattern)
+	}
+	return method, path
+}
+
+// Handle registers the handler for the given pattern like HandleFunc.
+func (mux *Mux) Handle(pattern string, handler http.Handler, opts ...RouteOption) {
+	if handler == nil {
+		panic("mux: nil handler")
+	}
+	mux.HandleFunc(pattern, handler.ServeHTTP, opts...)
 }
 
 // RegexpHandleFunc registers the handler function for the given regular
 // expression pattern.
-func (mux *Mux) RegexpHandleFunc(pattern string, handler http.HandlerFunc) {
-	mux.register(pattern, handler, true)
+func (mux *Mux) RegexpHandleFunc(pattern string, handler http.HandlerFunc, opts ...RouteOption) {
+	mux.register("", pattern, muxEntry{handler: handler, regexp: true}, opts...)
 }
 
-// register the handler for the given pattern.
-// Panics if a handler already exists for pattern.
-func (mux *Mux) register(pattern string, handler http.HandlerFunc, regexp bool) {
-	mux.mu.Lock()
-	defer mux.mu.Unlock()
+// Method registers the handler function for the given request method and
+// pattern. A pattern may be registered for several methods, and in addition
+// with HandleFunc for all other methods. Requests whose path matches the
+// pattern but whose method has no handler are answered with 405 Method Not
+// Allowed, with the Allow header listing the methods registered for the path
+// and OPTIONS, unless another pattern matches them. OPTIONS requests to such
+// a path are answered with 204 No Content and the same Allow header, unless
+// an OPTIONS handler is registered. HEAD requests are handled by the GET
+// handler, with the body discarded, unless a HEAD handler is registered.
+//
+// Options that change how the pattern is matched, like MatchQuery, apply to
+// the pattern for all methods.
+//
+// Panics if method is empty or a handler already exists for method and
+// pattern.
+func (mux *Mux) Method(method, pattern string, handler http.HandlerFunc, opts ...RouteOption) {
+	if method == "" {
+		panic("mux: empty method")
+	}
+	mux.register(method, pattern, muxEntry{handler: handler}, opts...)
+}
+
+// Get registers the handler function for GET requests to the given pattern.
+func (mux *Mux) Get(pattern string, handler http.HandlerFunc, opts ...RouteOption) {
+	mux.Method(http.MethodGet, pattern, handler, opts...)
+}
+
+// Post registers the handler function for POST requests to the given pattern.
+func (mux *Mux) Post(pattern string, handler http.HandlerFunc, opts ...RouteOption) {
+	mux.Method(http.MethodPost, pattern, handler, opts...)
+}
+
+// Put registers the handler function for PUT requests to the given pattern.
+func (mux *Mux) Put(pattern string, handler http.HandlerFunc, opts ...RouteOption) {
+	mux.Method(http.MethodPut, pattern, handler, opts...)
+}
+
+// Patch registers the handler function for PATCH requests to the given
+// pattern.
+func (mux *Mux) Patch(pattern string, handler http.HandlerFunc, opts ...RouteOption) {
+	mux.Method(http.MethodPatch, pattern, handler, opts...)
+}
+
+// Delete registers the handler function for DELETE requests to the given
+// pattern.
+func (mux *Mux) Delete(pattern string, handler http.HandlerFunc, opts ...RouteOption) {
+	mux.Method(http.MethodDelete, pattern, handler, opts...)
+}
 
+// register the entry for the given pattern, for the given method only unless
+// method is empty. The entry is merged into an entry already registered for
+// pattern.
+// Panics if a handler already exists for method and pattern.
+func (mux *Mux) register(method, pattern string, e muxEntry, opts ...RouteOption) {
+	mux.lock()
+	defer mux.unlock()
+
+	mux.add(method, pattern, e, false, opts...)
+}
+
+// add is register for a locked mux. If replace, the entry replaces the entry
+// registered for pattern, if any, keeping its place in the registration
+// order, instead of being merged into it.
+func (mux *Mux) add(method, pattern string, e muxEntry, replace bool, opts ...RouteOption) {
 	if pattern == "" {
 		panic("mux: invalid pattern")
 	}
-	if !regexp && pattern != "/" {
+	// A subtree pattern copied by Mount stays one on any mux.
+	subtree := !e.regexp && pattern[len(pattern)-1] == '/' && (mux.subtree || e.subtree())
+	if !e.regexp && pattern != "/" && !subtree {
 		if pattern[0] != '/' {
 			panic("mux: pattern must begin with \"/\"")
 		}
@@ -80,24 +378,362 @@ func (mux *Mux) register(pattern string, handler http.HandlerFunc, regexp bool)
 			panic("mux: pattern must not end with \"/\"")
 		}
 	}
-	if handler == nil {
+	if e.handler == nil && len(e.methods) == 0 && len(e.variants) == 0 {
 		panic("mux: nil handler")
 	}
-	if _, ok := mux.m[pattern]; ok {
-		panic("mux: multiple registrations for " + pattern)
-	}
 
 	if mux.m == nil {
 		mux.m = make(map[string]muxEntry)
 	}
 
-	e := muxEntry{handler, regexp}
+	if mux.stringKeys {
+		e.stringKeys = true
+	}
+	e.escaped = mux.escaped
+	for _, opt := range opts {
+		opt(mux, &e)
+	}
+	if !e.exactPath {
+		pattern = mux.normalizePattern(pattern, e.regexp)
+	}
+	switch {
+	case e.regexp:
+		e.re = regexp.MustCompile(pattern)
+	case subtree:
+		e.segments = parseSegments(pattern + "*")
+		e.segments[len(e.segments)-1].text = ""
+	default:
+		e.segments = parseSegments(pattern)
+	}
+	if method != "" {
+		e.methods = map[string]http.HandlerFunc{method: e.handler}
+		e.handler = nil
+	}
+
+	for _, name := range e.names {
+		if _, ok := mux.names[name]; ok {
+			panic("mux: multiple routes named " + name)
+		}
+	}
+	formats := e.formats
+	if len(e.matchers) > 0 || len(e.produces) > 0 {
+		// Register the entry as a variant of the pattern.
+		v := e
+		v.names = nil
+		e = muxEntry{
+			regexp:     v.regexp,
+			re:         v.re,
+			segments:   v.segments,
+			stringKeys: v.stringKeys,
+			names:      e.names,
+			variants:   []muxEntry{v},
+		}
+	} else if len(e.variants) > 0 {
+		// Match the variants of an entry copied by Mount against pattern.
+		variants := make([]muxEntry, len(e.variants))
+		for i, v := range e.variants {
+			v.re, v.segments = e.re, e.segments
+			variants[i] = v
+		}
+		e.variants = variants
+	}
+
+	if old, ok := mux.m[pattern]; ok && !replace {
+		if conflict(old, e) {
+			mux.logf(context.Background(), levelError, "mux: conflicting registration", "method", method, "pattern", pattern)
+			if method != "" {
+				panic("mux: multiple registrations for " + method + " " + pattern)
+			}
+			panic("mux: multiple registrations for " + pattern)
+		}
+		e = merge(old, e)
+	} else if !ok {
+		if !e.regexp {
+			if p := mux.equivalent(pattern, e.segments); p != "" {
+				mux.logf(context.Background(), levelError, "mux: conflicting registration", "method", method, "pattern", pattern, "equivalent", p)
+				panic("mux: pattern " + pattern + " matches the same paths as " + p)
+			}
+		}
+		mux.warnOverlaps(pattern, e)
+		mux.patterns = append(mux.patterns, pattern)
+		if e.regexp {
+			mux.regexps = append(mux.regexps, pattern)
+		} else {
+			for _, m := range mux.mounts {
+				if !m.keepPath && hasPathPrefix(pattern, m.prefix) {
+					mux.logf(context.Background(), levelWarn, "mux: pattern shadows mounted handler", "pattern", pattern, "prefix", m.prefix)
+				}
+			}
+			if mux.tree == nil {
+				mux.tree = new(node)
+			}
+			mux.tree.insert(pattern, e.segments)
+		}
+	}
 	mux.m[pattern] = e
+	if len(formats) > 0 {
+		mux.addFormats(formats)
+	}
+	if e.exactPath {
+		mux.exactPaths = true
+	}
+
+	for _, name := range e.names {
+		if mux.names == nil {
+			mux.names = make(map[string]string)
+		}
+		mux.names[name] = pattern
+	}
+}
+
+// normalizePattern returns the pattern as registered on mux, normalized to
+// NFC and, unless it is a regexp, lowercased if mux has these options.
+func (mux *Mux) normalizePattern(pattern string, isRegexp bool) string {
+	if mux.unicode != unicodeAsIs {
+		pattern = norm.NFC.String(pattern)
+	}
+	if mux.lowercase && !isRegexp {
+		pattern = lowercasePattern(pattern, mux.lowercaseASCII)
+	}
+	return pattern
+}
+
+// conflict determines whether the entries e1 and e2, registered for the same
+// pattern, can not be merged because they are not of the same kind or both
+// have a handler for the same method.
+func conflict(e1, e2 muxEntry) bool {
+	if e1.regexp != e2.regexp || e1.handler != nil && e2.handler != nil {
+		return true
+	}
+	for method := range e2.methods {
+		if _, ok := e1.methods[method]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// merge returns the entry with the handlers of both e1 and e2, which must not
+// conflict.
+func merge(e1, e2 muxEntry) muxEntry {
+	e := e1
+	if e.handler == nil {
+		e.handler = e2.handler
+	}
+	if len(e2.methods) > 0 {
+		e.methods = make(map[string]http.HandlerFunc, len(e1.methods)+len(e2.methods))
+		for method, h := range e1.methods {
+			e.methods[method] = h
+		}
+		for method, h := range e2.methods {
+			e.methods[method] = h
+		}
+	}
+	e.matchQuery = e1.matchQuery || e2.matchQuery
+	e.inFlight = append(e1.inFlight[:len(e1.inFlight):len(e1.inFlight)], e2.inFlight...)
+	e.quiet = e1.quiet || e2.quiet
+	e.csrfExempt = e1.csrfExempt || e2.csrfExempt
+	e.exactPath = e1.exactPath || e2.exactPath
+	e.noRedirect = e1.noRedirect || e2.noRedirect
+	e.stringKeys = e1.stringKeys || e2.stringKeys
+	if e.slash == 0 {
+		e.slash = e2.slash
+	}
+	if e2.paramsToQuery > e.paramsToQuery {
+		e.paramsToQuery = e2.paramsToQuery
+	}
+	e.names = append(e1.names[:len(e1.names):len(e1.names)], e2.names...)
+	e.middleware = append(e1.middleware[:len(e1.middleware):len(e1.middleware)], e2.middleware...)
+	e.meta = mergeMeta(e1.meta, e2.meta)
+	e.formats = append(e1.formats[:len(e1.formats):len(e1.formats)], e2.formats...)
+	e.variants = append(e1.variants[:len(e1.variants):len(e1.variants)], e2.variants...)
+	return e
+}
+
+// handlerFor returns the handler of e for the given request method or nil if
+// there is none. HEAD requests without a handler of their own are handled by
+// the GET handler, with the body discarded.
+func (e muxEntry) handlerFor(method string) http.HandlerFunc {
+	if h, ok := e.methods[method]; ok {
+		return h
+	}
+	if e.handler == nil && method == http.MethodHead {
+		if h, ok := e.methods[http.MethodGet]; ok {
+			return discardBody(h)
+		}
+	}
+	return e.handler
+}
+
+// discardBody returns a handler that calls next with the body it writes
+// discarded, after answering conditional requests if the mux uses UseETags.
+func discardBody(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h := next
+		if weak, ok := r.Context().Value(etagsKey{}).(bool); ok {
+			h = conditional(weak, next).ServeHTTP
+		}
+		h(bodylessResponseWriter{WrapResponseWriter(w)}, r)
+	}
+}
+
+// bodylessResponseWriter is a ResponseWriter that discards the body.
+type bodylessResponseWriter struct {
+	ResponseWriter
+}
+
+func (w bodylessResponseWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (w bodylessResponseWriter) ReadFrom(r io.Reader) (int64, error) {
+	return io.Copy(io.Discard, r)
+}
+
+// mapHandlers returns e with each of its handlers, including those of its
+// variants, replaced by the handler f returns for it.
+func (e muxEntry) mapHandlers(f func(h http.HandlerFunc) http.HandlerFunc) muxEntry {
+	if e.handler != nil {
+		e.handler = f(e.handler)
+	}
+	if len(e.methods) > 0 {
+		methods := make(map[string]http.HandlerFunc, len(e.methods))
+		for method, h := range e.methods {
+			methods[method] = f(h)
+		}
+		e.methods = methods
+	}
+	if len(e.variants) > 0 {
+		variants := make([]muxEntry, len(e.variants))
+		for i, v := range e.variants {
+			variants[i] = v.mapHandlers(f)
+		}
+		e.variants = variants
+	}
+	return e
+}
+
+// WrapAll replaces the handler of every route registered on mux, including
+// mounted ones, with the handler wrap returns for it. wrap may return the
+// given handler unchanged to leave a route as it is. Routes registered after
+// WrapAll returns are not wrapped.
+//
+// Panics if wrap returns nil.
+func (mux *Mux) WrapAll(wrap func(pattern string, h http.Handler) http.Handler) {
+	mux.lock()
+	defer mux.unlock()
+
+	wrapped := make(map[string]muxEntry, len(mux.m))
+	for pattern, e := range mux.m {
+		pattern := pattern
+		wrapped[pattern] = e.mapHandlers(func(h http.HandlerFunc) http.HandlerFunc {
+			return wrapHandler(wrap, pattern, h)
+		})
+	}
+	mux.m = wrapped
+}
+
+// wrapHandler returns the handler wrap returns for h.
+// Panics if wrap returns nil.
+func wrapHandler(wrap func(pattern string, h http.Handler) http.Handler, pattern string, h http.HandlerFunc) http.HandlerFunc {
+	w := wrap(pattern, h)
+	if w == nil {
+		panic("mux: nil handler from WrapAll for " + pattern)
+	}
+	return w.ServeHTTP
 }
 
 // ServeHTTP dispatches the request to the handler whose pattern most closely
 // matches the request URL.
 func (mux *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	t := mux.load()
+	if t.proxies != nil {
+		r = withClientIP(r, t.proxies)
+	}
+	if t.requestID {
+		r = withRequestID(w, r)
+	}
+	if t.onRequest != nil || t.metrics != nil || t.trace != nil || t.stats != nil {
+		var l *requestLog
+		l, r = newRequestLog(w, r, t)
+		w = l
+		defer l.done(r)
+		if t.trace != nil {
+			var ctx context.Context
+			ctx, l.end = t.trace(r)
+			r = r.WithContext(ctx)
+		}
+	}
+	if t.recovery != nil {
+		defer t.recoverPanic(w, r)
+	}
+	if t.chain != nil {
+		t.chain.ServeHTTP(w, r)
+		return
+	}
+	t.serve(w, r)
+}
+
+// serve is ServeHTTP without middleware.
+func (mux *Mux) serve(w http.ResponseWriter, r *http.Request) {
+	mux.load().serve(w, r)
+}
+
+// match is handler that records how the handler was chosen in ex unless ex is
+// nil.
+func (mux *Mux) match(r *http.Request, ex *Explanation) (http.HandlerFunc, bool) {
+	return mux.load().match(r, ex)
+}
+
+// load returns the routing table of mux in service.
+func (mux *Mux) load() *table {
+	if t, ok := mux.current.Load().(*table); ok {
+		return t
+	}
+	return &table{notFound: http.NotFound} // zero Mux
+}
+
+// lock locks mux for a change. Panics if mux is frozen.
+func (mux *Mux) lock() {
+	mux.mu.Lock()
+	if mux.frozen {
+		mux.mu.Unlock()
+		panic("mux: change to frozen mux")
+	}
+}
+
+// unlock puts a copy of the table of the locked mux into service and unlocks
+// mux.
+func (mux *Mux) unlock() {
+	mux.current.Store(mux.table.clone())
+	mux.mu.Unlock()
+}
+
+// clone returns a copy of t sharing nothing that changes to t modify, calling
+// http.NotFound if t has no notFound, as that of a zero Mux.
+func (t *table) clone() *table {
+	c := *t
+	if c.notFound == nil {
+		c.notFound = http.NotFound
+	}
+	c.m = make(map[string]muxEntry, len(t.m))
+	for pattern, e := range t.m {
+		c.m[pattern] = e
+	}
+	c.patterns = append([]string(nil), t.patterns...)
+	c.regexps = append([]string(nil), t.regexps...)
+	c.tree = newTree(c.patterns, c.m)
+	c.mounts = append([]mount(nil), t.mounts...)
+	c.names = make(map[string]string, len(t.names))
+	for name, pattern := range t.names {
+		c.names[name] = pattern
+	}
+	c.middleware = append([]func(http.Handler) http.Handler(nil), t.middleware...)
+	return &c
+}
+
+// serve is ServeHTTP without middleware.
+func (t *table) serve(w http.ResponseWriter, r *http.Request) {
 	if r.RequestURI == "*" {
 		if r.ProtoAtLeast(1, 1) {
 			w.Header().Set("Connection", "close")
@@ -106,57 +742,435 @@ func (mux *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	mux.mu.RLock()
-	defer mux.mu.RUnlock()
+	if h, ok := t.handler(r); ok {
+		h(w, r)
+		return
+	}
 
-	for pattern, e := range mux.m {
-		if u, ok := urlWithoutSlash(r.URL.Path, pattern, r.URL); ok {
-			http.Redirect(w, r, u.String(), http.StatusPermanentRedirect)
-			return
+	t.notFound(w, r)
+}
+
+// handler returns the handler to use for the given request and reports
+// whether one was found. The returned handler may be a redirect to the
+// canonical form of the request URL.
+func (t *table) handler(r *http.Request) (http.HandlerFunc, bool) {
+	return t.match(r, nil)
+}
+
+// match is handler that records how the handler was chosen in ex unless ex is
+// nil.
+func (t *table) match(r *http.Request, ex *Explanation) (http.HandlerFunc, bool) {
+	if h, ok := t.canonicalize(r, ex); ok {
+		return h, true
+	}
+	if t.unicode == unicodeNormalize {
+		if h, ok := t.normalizeUnicode(r, ex); ok {
+			return h, true
 		}
+	}
+	return t.route(r, ex)
+}
 
-		if e.regexp {
-			re := regexp.MustCompile(pattern)
-			if re.MatchString(r.URL.Path) {
-				addRegexpSubmatchesToContext(e.handler, re)(w, r)
-				return
+// route is match without canonicalization.
+func (t *table) route(r *http.Request, ex *Explanation) (http.HandlerFunc, bool) {
+	if t.escaped {
+		r = escapedRequest(r)
+	}
+	if t.locales != nil {
+		if h, ok := t.locales.prefixed(r, ex, t); ok {
+			return h, true
+		}
+	}
+
+	rt := routing{r: r, ex: ex, t: t, slash: t.slash, code: t.redirectStatus()}
+	if ex != nil {
+		rt.seen = make(map[string]bool)
+	}
+	visit := func(pattern string) bool {
+		return rt.try(pattern, t.m[pattern])
+	}
+	// Patterns ending in a wildcard are tried after the regexps.
+	var wildcards []string
+	visitExact := func(pattern string) bool {
+		e := t.m[pattern]
+		if e.wildcard() {
+			wildcards = append(wildcards, pattern)
+			return false
+		}
+		return rt.try(pattern, e)
+	}
+
+	path := r.URL.Path
+	if pattern, ok := t.static[path]; ok && ex == nil && rt.try(pattern, t.m[pattern]) {
+		return rt.h, true
+	}
+	if t.tree.lookup(path, visitExact) {
+		return rt.h, true
+	}
+	for _, pattern := range t.regexps {
+		if rt.try(pattern, t.m[pattern]) {
+			return rt.h, true
+		}
+	}
+	for _, pattern := range wildcards {
+		if visit(pattern) {
+			return rt.h, true
+		}
+	}
+	if rt.h == nil && t.formats != nil {
+		h, allowed := t.formatted(r, ex)
+		if h != nil {
+			return h, true
+		}
+		rt.allowed = append(rt.allowed, allowed...)
+	}
+	if rt.h == nil {
+		if h, ok := t.mounted(r, ex, false); ok {
+			return h, true
+		}
+	}
+	if n := len(path) - 1; n > 0 && path[n] == '/' && t.tree.lookup(path[:n], visit) {
+		return rt.h, true
+	}
+	if rt.h == nil {
+		if h, ok := t.mounted(r, ex, true); ok {
+			return h, true
+		}
+	}
+	if ex != nil {
+		// Record the patterns the tree ruled out as rejected.
+		for _, pattern := range t.patterns {
+			if e := t.m[pattern]; !e.regexp && !rt.seen[pattern] {
+				ex.candidate(pattern, e, r, false, nil, false)
 			}
-		} else {
-			if r.URL.Path == pattern {
-				e.handler(w, r)
-				return
+		}
+	}
+
+	h, allowed := rt.h, rt.allowed
+	if h != nil {
+		return h, true
+	}
+	if len(allowed) > 0 {
+		allow := allowHeader(append(allowed, http.MethodOptions))
+		if r.Method == http.MethodOptions {
+			ex.options(allow)
+			return func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Allow", allow)
+				w.WriteHeader(http.StatusNoContent)
+			}, true
+		}
+		ex.methodNotAllowed(allow)
+		next := t.methodNotAllowed
+		if next == nil {
+			next = methodNotAllowed
+		}
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Allow", allow)
+			next(w, r)
+		}, true
+	}
+
+	if t.versions != nil {
+		if h, ok := t.versions.bare(r, ex); ok {
+			return h, true
+		}
+	}
+	if t.locales != nil {
+		if h, ok := t.locales.bare(r, ex, t); ok {
+			return h, true
+		}
+	}
+
+	if fb, ok := t.fallback.(*Mux); ok {
+		ft := fb.load()
+		inner := ex.nested(r)
+		h, ok := ft.match(r, inner)
+		ex.fallback(inner, ok)
+		if ok {
+			h = chain(ft.middleware, h).ServeHTTP
+		}
+		return h, ok
+	} else if t.fallback != nil {
+		ex.fallback(nil, true)
+		return t.fallback.ServeHTTP, true
+	}
+
+	return nil, false
+}
+
+// routing is the state of routing a request through the patterns of a mux.
+type routing struct {
+	r       *http.Request
+	ex      *Explanation // records the routing unless nil
+	t       *table       // table routing the request
+	h       http.HandlerFunc
+	allowed []string        // methods of patterns matching all but the method
+	seen    map[string]bool // patterns tried if explaining
+	slash   SlashPolicy     // trailing slash policy of the mux
+	code    int             // status code of trailing slash redirects
+	format  string          // extension removed from the path, see Formats
+}
+
+// try matches the request against the pattern of e and reports whether
+// routing is done, which it is once a handler is found unless explaining.
+func (rt *routing) try(pattern string, e muxEntry) bool {
+	r := rt.r
+	e, applies := e.variantFor(r)
+	if applies && !rt.formatMatches(e) {
+		return false
+	}
+	if !applies {
+		if rt.ex != nil {
+			rt.seen[pattern] = true
+			rt.ex.candidate(pattern, e, r, false, nil, false)
+		}
+		return false
+	}
+
+	var c http.HandlerFunc
+	var wrongMethod bool
+	u, ok := urlWithoutSlash(r.URL.Path, pattern, e, r.URL)
+	var trim bool // whether the trailing slash is ignored
+	if !ok {
+		u, ok = urlWithSlash(r.URL.Path, e, r.URL)
+		if ok && e.noRedirect {
+			ok, trim = false, true
+		}
+	} else {
+		policy := e.slash
+		if policy == 0 {
+			policy = rt.slash
+		}
+		switch policy {
+		case StrictSlash:
+			ok = false
+		case IgnoreTrailingSlash:
+			ok, trim = false, true
+		}
+	}
+	if ok && rt.format != "" {
+		// The path without the format is not redirected.
+		ok = false
+	}
+	if ok && rt.t.escaped {
+		u = unescapeURL(u)
+	}
+	switch {
+	case ok:
+		c = rt.t.redirectHandler(u, rt.code, "trailing slash")
+	case trim || e.match(r.URL.Path, pattern, r.URL):
+		c = e.handlerFor(r.Method)
+		switch {
+		case c == nil:
+			wrongMethod = true
+			for method := range e.methods {
+				rt.allowed = append(rt.allowed, method)
+				if method == http.MethodGet {
+					rt.allowed = append(rt.allowed, http.MethodHead)
+				}
 			}
+		case e.regexp:
+			c = addRegexpSubmatchesToContext(e, c, trim)
+		case e.segments != nil:
+			c = addParamsToContext(e, c)
 		}
 	}
+	if c != nil && !ok && e.quiet {
+		c = markQuiet(c)
+	}
+	if c != nil && !ok && rt.t.csrf != nil && !e.csrfExempt {
+		c = rt.t.csrf.protect(c)
+	}
+	if c != nil && !ok && e.meta != nil {
+		c = withMeta(e.meta, c)
+	}
+
+	if rt.ex == nil {
+		if c != nil && !ok {
+			c = withPattern(r, pattern, c)
+			recordRoute(r, pattern, e.quiet)
+		}
+		rt.h = c
+		return c != nil
+	}
+	rt.seen[pattern] = true
+
+	if !ok {
+		u = nil
+	}
+	rt.ex.candidate(pattern, e, r, c != nil || wrongMethod, u, rt.h == nil)
+	if rt.h == nil {
+		rt.h = c
+	}
+	return false
+}
+
+// SetFallback sets the handler called for requests that match no pattern,
+// before notFound. A nil handler removes the fallback.
+//
+// If the fallback is a *Mux, only its patterns, and in turn its own fallback,
+// are consulted; its notFound is never called and the notFound of mux handles
+// requests neither matches. Any other handler handles all requests mux does
+// not match, so notFound of mux is never called.
+//
+// Panics if handler is mux itself.
+func (mux *Mux) SetFallback(handler http.Handler) {
+	mux.lock()
+	defer mux.unlock()
 
-	mux.notFound(w, r)
+	if fb, ok := handler.(*Mux); ok && fb == mux {
+		panic("mux: fallback must not be the mux itself")
+	}
+	mux.fallback = handler
 }
 
 // urlWithoutSlash determines if the given path needs removing "/" from it. If
 // the path needs removing, it creates a new URL, setting the path to
 // u.Path - "/" and returning true to indicate so.
-func urlWithoutSlash(path, pattern string, u *url.URL) (*url.URL, bool) {
-	re := regexp.MustCompile(pattern)
-	if lastIndex := len(path) - 1; path[lastIndex] == '/' && (path[:lastIndex] == pattern ||
-		re.MatchString(path[:lastIndex])) {
-		u := &url.URL{Path: path[:lastIndex], RawQuery: u.RawQuery}
-		return u, true
+func urlWithoutSlash(path, pattern string, e muxEntry, u *url.URL) (*url.URL, bool) {
+	if path == "" || e.wildcard() {
+		return u, false
+	}
+	if lastIndex := len(path) - 1; path[lastIndex] == '/' && e.match(path[:lastIndex], pattern, u) {
+		return canonicalURL(u, path[:lastIndex]), true
 	}
 	return u, false
 }
 
-// addRegexpSubmatchesToContext adds regexp submatches from the provided re to
-// r.Context().
-func addRegexpSubmatchesToContext(next http.HandlerFunc, re *regexp.Regexp) http.HandlerFunc {
+// match determines whether the given path of u matches the pattern of e.
+func (e muxEntry) match(path, pattern string, u *url.URL) bool {
+	switch {
+	case e.regexp:
+		return e.re.MatchString(e.target(path, u))
+	case e.segments != nil:
+		return matchSegments(e.segments, path)
+	}
+	return path == pattern
+}
+
+// target returns the string the regexp of e is matched against for the given
+// path of u.
+func (e muxEntry) target(path string, u *url.URL) string {
+	if e.matchQuery && u.RawQuery != "" {
+		return path + "?" + u.RawQuery
+	}
+	return path
+}
+
+// allowHeader returns the value of the Allow header listing the given
+// methods, sorted and without duplicates.
+func allowHeader(methods []string) string {
+	sort.Strings(methods)
+	var b strings.Builder
+	for i, method := range methods {
+		if i > 0 && method == methods[i-1] {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(method)
+	}
+	return b.String()
+}
+
+// MethodNotAllowed sets the handler called when patterns match the path of a
+// request but none has a handler for its method. The Allow header listing the
+// methods of the patterns is set before the handler is called. The default
+// handler, also set by a nil handler, replies with 405 Method Not Allowed.
+func (mux *Mux) MethodNotAllowed(handler http.HandlerFunc) {
+	mux.lock()
+	defer mux.unlock()
+
+	mux.methodNotAllowed = handler
+}
+
+// methodNotAllowed replies with 405 Method Not Allowed.
+func methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+}
+
+// addRegexpSubmatchesToContext adds named regexp submatches from the regexp
+// of e to the path parameters of r before calling h. The trailing slash of the
+// path is not matched if trim.
+func addRegexpSubmatchesToContext(e muxEntry, h http.HandlerFunc, trim bool) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// And named regexp submatches to request context.
-		submatches := re.FindStringSubmatch(r.URL.Path)
-		for i, name := range re.SubexpNames() {
-			if i == 0 || name == "" {
-				continue
-			}
-			r = r.WithContext(context.WithValue(r.Context(), name, submatches[i]))
+		path := e.path(r)
+		if trim {
+			path = strings.TrimSuffix(path, "/")
+		}
+		// Add named regexp submatches to the path parameters.
+		submatches := e.re.FindStringSubmatch(e.target(path, r.URL))
+		r = withParams(r, e.re.SubexpNames(), submatches, e.stringKeys)
+		if e.paramsToQuery != keepQuery {
+			r = paramsToQuery(r, e.re.SubexpNames(), submatches, e.paramsToQuery == overwriteQuery)
+		}
+		h(w, r)
+	}
+}
+
+// MatchQuery makes the regular expression of the route be matched against the
+// request path followed by "?" and the raw query, if the request has a query,
+// instead of against the path only. Named submatches from the query are path
+// parameters like those from the path.
+//
+// The query is matched as sent by the client, so the order of its parameters
+// matters: `^/page\?id=(?P<id>[0-9]+)&type=article$` does not match
+// "/page?type=article&id=12". Use it only to route legacy URLs that can not be
+// told apart by their path.
+//
+// Panics if the route is not a regexp route.
+func MatchQuery() RouteOption {
+	return func(mux *Mux, e *muxEntry) {
+		if !e.regexp {
+			panic("mux: MatchQuery on non-regexp pattern")
 		}
-		next(w, r)
+		e.matchQuery = true
+	}
+}
+
+// redirect replies to the request with a redirect to u, restoring any prefix
+// stripped from the path before the request reached mux.
+func redirect(w http.ResponseWriter, r *http.Request, u *url.URL, code int) {
+	if prefix, ok := r.Context().Value(prefixKey{}).(string); ok {
+		v := *u
+		v.Path = prefix + v.Path
+		u = &v
+	}
+	http.Redirect(w, r, u.String(), code)
+}
+
+// redirectHandler returns a handler that redirects to u with the given status
+// code.
+func redirectHandler(u *url.URL, code int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		redirect(w, r, u, code)
+	}
+}
+
+// prefixKey is the context key for the path prefix stripped by stripPrefix.
+type prefixKey struct{}
+
+// stripPrefix returns a shallow copy of r with prefix removed from the start
+// of its path. The stripped prefix is remembered so that redirects issued
+// further down still point to the full path.
+func stripPrefix(r *http.Request, prefix string) *http.Request {
+	p := strings.TrimPrefix(r.URL.Path, prefix)
+	if p == "" {
+		p = "/"
+	}
+	rp := strings.TrimPrefix(r.URL.RawPath, prefix)
+	if rp == "" && r.URL.RawPath != "" {
+		rp = "/"
+	}
+
+	if parent, ok := r.Context().Value(prefixKey{}).(string); ok {
+		prefix = parent + prefix
 	}
+	r2 := r.WithContext(context.WithValue(r.Context(), prefixKey{}, prefix))
+	u := *r.URL
+	u.Path = p
+	u.RawPath = rp
+	r2.URL = &u
+	return r2
 }
@@ -9,7 +9,9 @@ import (
 	"net/http/httptest"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"testing/fstest"
 )
 
 var handlerFactory = func(statusCode int, body string) http.HandlerFunc {
@@ -23,7 +25,7 @@ var handlerFactory = func(statusCode int, body string) http.HandlerFunc {
 }
 
 func ExampleMux() {
-	m := mux.New(http.NotFound)
+	m := mux.New()
 	m.HandleFunc("/hello", func(w http.ResponseWriter, r *http.Request) {
 		io.WriteString(w, "hello")
 	})
@@ -31,9 +33,9 @@ func ExampleMux() {
 }
 
 func ExampleMux_RegexpHandleFunc() {
-	m := mux.New(http.NotFound)
+	m := mux.New()
 	m.RegexpHandleFunc(`/users/(?P<id>[0-9]+)$`, func(w http.ResponseWriter, r *http.Request) {
-		id, err := strconv.Atoi(r.Context().Value("id").(string))
+		id, err := strconv.Atoi(mux.Param(r, "id"))
 		if err != nil {
 			w.WriteHeader(http.StatusUnprocessableEntity)
 			return
@@ -46,31 +48,59 @@ func ExampleMux_RegexpHandleFunc() {
 }
 
 func ExampleMux_Mount() {
-	mu := mux.New(http.NotFound)
+	mu := mux.New()
 	mu.HandleFunc("/report", func(w http.ResponseWriter, r *http.Request) {
 		io.WriteString(w, "user report")
 	})
 
-	m := mux.New(http.NotFound)
+	m := mux.New()
 	m.Mount("/users", mu)
 
 	http.ListenAndServe(":8080", m)
 }
 
 func TestNew(t *testing.T) {
-	t.Run("nil", func(t *testing.T) {
-		defer func() {
-			if recover() == nil {
-				t.Error("got no panic, want panic")
-			}
-		}()
+	t.Run("default", func(t *testing.T) {
+		m := mux.New()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, r)
+		resp := rec.Result()
+
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("got StatusCode %d, want %d", resp.StatusCode, http.StatusNotFound)
+		}
 
-		mux.New(nil)
+		b, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body := string(b)
+		if body != "404 page not found\n" {
+			t.Errorf("got body %q, want %q", body, "404 page not found\n")
+		}
+	})
+
+	t.Run("zero", func(t *testing.T) {
+		var m mux.Mux
+		m.HandleFunc("/a", handlerFactory(http.StatusOK, "a"))
+		var sub mux.Mux
+		sub.HandleFunc("/b", handlerFactory(http.StatusOK, "b"))
+		m.Mount("/sub", &sub)
+
+		for _, path := range []string{"/missing", "/sub/missing"} {
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+			if rec.Code != http.StatusNotFound {
+				t.Errorf("%s: got StatusCode %d, want %d", path, rec.Code, http.StatusNotFound)
+			}
+		}
 	})
 
 	t.Run("set", func(t *testing.T) {
 		h := handlerFactory(http.StatusNotFound, "a")
-		m := mux.New(h)
+		m := mux.New(mux.WithNotFound(h))
 		r := httptest.NewRequest(http.MethodGet, "/", nil)
 		rec := httptest.NewRecorder()
 		m.ServeHTTP(rec, r)
@@ -90,6 +120,213 @@ func TestNew(t *testing.T) {
 			t.Errorf("got body %q, want a", body)
 		}
 	})
+
+	t.Run("options", func(t *testing.T) {
+		m := mux.New(
+			mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found")),
+			mux.WithMethodNotAllowed(handlerFactory(http.StatusMethodNotAllowed, "not allowed")),
+		)
+		m.Get("/a", handlerFactory(http.StatusTeapot, "a"))
+
+		cases := []struct {
+			method string
+			path   string
+			code   int
+			body   string
+		}{
+			{http.MethodGet, "/b", http.StatusNotFound, "not found"},
+			{http.MethodPost, "/a", http.StatusMethodNotAllowed, "not allowed"},
+		}
+		for _, c := range cases {
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, httptest.NewRequest(c.method, c.path, nil))
+			if rec.Code != c.code {
+				t.Errorf("%s %s: got StatusCode %d, want %d", c.method, c.path, rec.Code, c.code)
+			}
+			if body := rec.Body.String(); body != c.body {
+				t.Errorf("%s %s: got body %q, want %q", c.method, c.path, body, c.body)
+			}
+		}
+	})
+}
+
+func TestNotFound(t *testing.T) {
+	m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "a")))
+	m.HandleFunc("/a", handlerFactory(http.StatusTeapot, ""))
+
+	cases := []struct {
+		handler http.HandlerFunc
+		body    string
+	}{
+		{handlerFactory(http.StatusNotFound, "b"), "b"},
+		{nil, "404 page not found\n"},
+	}
+
+	for _, c := range cases {
+		m.NotFound(c.handler)
+
+		r := httptest.NewRequest(http.MethodGet, "/b", nil)
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, r)
+
+		b, err := ioutil.ReadAll(rec.Result().Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body := string(b)
+		if body != c.body {
+			t.Errorf("got body %q, want %q", body, c.body)
+		}
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	m := mux.New()
+	m.Get("/a", handlerFactory(http.StatusTeapot, ""))
+	m.Put("/a", handlerFactory(http.StatusTeapot, ""))
+	m.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		io.WriteString(w, `{"error":"method not allowed","allow":"`+w.Header().Get("Allow")+`"}`)
+	})
+
+	cases := []struct {
+		name string
+		body string
+	}{
+		{"custom", `{"error":"method not allowed","allow":"GET, HEAD, OPTIONS, PUT"}`},
+		{"default", "Method Not Allowed\n"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if c.name == "default" {
+				m.MethodNotAllowed(nil)
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "/a", nil)
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+			resp := rec.Result()
+
+			if resp.StatusCode != http.StatusMethodNotAllowed {
+				t.Errorf("got StatusCode %d, want %d", resp.StatusCode, http.StatusMethodNotAllowed)
+			}
+
+			if allow := resp.Header.Get("Allow"); allow != "GET, HEAD, OPTIONS, PUT" {
+				t.Errorf("got Allow %q, want %q", allow, "GET, HEAD, OPTIONS, PUT")
+			}
+
+			b, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body := string(b)
+			if body != c.body {
+				t.Errorf("got body %q, want %q", body, c.body)
+			}
+		})
+	}
+}
+
+func TestOptions(t *testing.T) {
+	m := mux.New()
+	m.Get("/a", handlerFactory(http.StatusTeapot, ""))
+	m.Put("/a", handlerFactory(http.StatusTeapot, ""))
+	m.Get("/b", handlerFactory(http.StatusTeapot, ""))
+	m.Method(http.MethodOptions, "/b", handlerFactory(http.StatusTeapot, "options"))
+	m.HandleFunc("/c", handlerFactory(http.StatusTeapot, "any"))
+
+	cases := []struct {
+		path       string
+		statusCode int
+		allow      string
+		body       string
+	}{
+		{"/a", http.StatusNoContent, "GET, HEAD, OPTIONS, PUT", ""},
+		{"/b", http.StatusTeapot, "", "options"},
+		{"/c", http.StatusTeapot, "", "any"},
+		{"/d", http.StatusNotFound, "", "404 page not found\n"},
+	}
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodOptions, c.path, nil)
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+			resp := rec.Result()
+
+			if resp.StatusCode != c.statusCode {
+				t.Errorf("got StatusCode %d, want %d", resp.StatusCode, c.statusCode)
+			}
+
+			if allow := resp.Header.Get("Allow"); allow != c.allow {
+				t.Errorf("got Allow %q, want %q", allow, c.allow)
+			}
+
+			b, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body := string(b)
+			if body != c.body {
+				t.Errorf("got body %q, want %q", body, c.body)
+			}
+		})
+	}
+}
+
+func TestHead(t *testing.T) {
+	get := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Method", r.Method)
+		w.WriteHeader(http.StatusTeapot)
+		io.WriteString(w, "get")
+	}
+
+	m := mux.New()
+	m.Get("/a", get)
+	m.Get("/b", get)
+	m.Method(http.MethodHead, "/b", handlerFactory(http.StatusAccepted, "head"))
+	m.Post("/c", handlerFactory(http.StatusTeapot, "post"))
+
+	cases := []struct {
+		path       string
+		statusCode int
+		method     string
+		body       string
+	}{
+		{"/a", http.StatusTeapot, http.MethodHead, ""},
+		{"/b", http.StatusAccepted, "", "head"},
+		{"/c", http.StatusMethodNotAllowed, "", "Method Not Allowed\n"},
+	}
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodHead, c.path, nil)
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+			resp := rec.Result()
+
+			if resp.StatusCode != c.statusCode {
+				t.Errorf("got StatusCode %d, want %d", resp.StatusCode, c.statusCode)
+			}
+
+			if method := resp.Header.Get("X-Method"); method != c.method {
+				t.Errorf("got X-Method %q, want %q", method, c.method)
+			}
+
+			b, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body := string(b)
+			if body != c.body {
+				t.Errorf("got body %q, want %q", body, c.body)
+			}
+		})
+	}
 }
 
 func TestHandleFunc(t *testing.T) {
@@ -147,7 +384,7 @@ func TestHandleFunc(t *testing.T) {
 		for _, c := range cases {
 			t.Run(c.path, func(t *testing.T) {
 				h := handlerFactory(http.StatusTeapot, c.path)
-				m := mux.New(http.NotFound)
+				m := mux.New()
 				for _, pattern := range c.patterns {
 					m.HandleFunc(pattern, h)
 				}
@@ -197,7 +434,7 @@ func TestHandleFunc(t *testing.T) {
 		for _, c := range cases {
 			t.Run(c.path, func(t *testing.T) {
 				h := handlerFactory(http.StatusTeapot, c.path)
-				m := mux.New(http.NotFound)
+				m := mux.New()
 				for _, pattern := range c.patterns {
 					m.HandleFunc(pattern, h)
 				}
@@ -253,7 +490,7 @@ func TestHandleFunc(t *testing.T) {
 				}()
 
 				h := handlerFactory(http.StatusTeapot, "")
-				m := mux.New(http.NotFound)
+				m := mux.New()
 				for _, pattern := range c.patterns {
 					m.HandleFunc(pattern, h)
 				}
@@ -262,6 +499,37 @@ func TestHandleFunc(t *testing.T) {
 	})
 }
 
+func TestHandleFuncConcurrent(t *testing.T) {
+	m := mux.New()
+	m.HandleFunc("/", handlerFactory(http.StatusOK, ""))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			m.HandleFunc("/users/"+strconv.Itoa(i), handlerFactory(http.StatusTeapot, ""))
+		}(i)
+		go func() {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			if w.Code != http.StatusOK {
+				t.Errorf("got StatusCode %d, want %d", w.Code, http.StatusOK)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := 0; i < 8; i++ {
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/"+strconv.Itoa(i), nil))
+		if w.Code != http.StatusTeapot {
+			t.Errorf("/users/%d: got StatusCode %d, want %d", i, w.Code, http.StatusTeapot)
+		}
+	}
+}
+
 func TestRegexpHandleFunc(t *testing.T) {
 	t.Run("green", func(t *testing.T) {
 		cases := []struct {
@@ -304,20 +572,20 @@ func TestRegexpHandleFunc(t *testing.T) {
 			{
 				[]string{"^/a$"},
 				"/a",
-				"<nil>",
+				"",
 			},
 
 			{
 				[]string{"/A"},
 				"/A",
-				"<nil>",
+				"",
 			},
 		}
 
 		for _, c := range cases {
 			t.Run(c.path, func(t *testing.T) {
 				h := func(w http.ResponseWriter, r *http.Request) {
-					id := fmt.Sprintf("%v", r.Context().Value("id"))
+					id := mux.Param(r, "id")
 					if id != c.id {
 						t.Errorf("got parameter id %s, want %s", id, c.id)
 					}
@@ -328,7 +596,7 @@ func TestRegexpHandleFunc(t *testing.T) {
 					}
 				}
 
-				m := mux.New(http.NotFound)
+				m := mux.New()
 				for _, pattern := range c.patterns {
 					m.RegexpHandleFunc(pattern, h)
 				}
@@ -369,7 +637,7 @@ func TestRegexpHandleFunc(t *testing.T) {
 		for _, c := range cases {
 			t.Run(c.path, func(t *testing.T) {
 				h := handlerFactory(http.StatusTeapot, c.path)
-				m := mux.New(http.NotFound)
+				m := mux.New()
 				for _, pattern := range c.patterns {
 					m.RegexpHandleFunc(pattern, h)
 				}
@@ -421,7 +689,7 @@ func TestRegexpHandleFunc(t *testing.T) {
 				}()
 
 				h := handlerFactory(http.StatusTeapot, "")
-				m := mux.New(http.NotFound)
+				m := mux.New()
 				for _, pattern := range c.patterns {
 					m.RegexpHandleFunc(pattern, h)
 				}
@@ -459,11 +727,11 @@ func TestMount(t *testing.T) {
 			name := strings.Join(c.paths, ", ")
 			t.Run(name, func(t *testing.T) {
 				h1 := handlerFactory(http.StatusTeapot, c.pattern1)
-				m1 := mux.New(http.NotFound)
+				m1 := mux.New()
 				m1.HandleFunc(c.pattern1, h1)
 
 				h2 := handlerFactory(http.StatusTeapot, c.pattern2)
-				m2 := mux.New(http.NotFound)
+				m2 := mux.New()
 				m2.HandleFunc(c.pattern2, h2)
 
 				m1.Mount("", m2)
@@ -495,14 +763,14 @@ func TestMount(t *testing.T) {
 	})
 
 	t.Run("prefix", func(t *testing.T) {
-		m0 := mux.New(http.NotFound)
+		m0 := mux.New()
 
 		h1 := handlerFactory(http.StatusTeapot, "/a/a")
-		m1 := mux.New(http.NotFound)
+		m1 := mux.New()
 		m1.HandleFunc("/a", h1)
 
 		h2 := handlerFactory(http.StatusTeapot, "/b/b")
-		m2 := mux.New(http.NotFound)
+		m2 := mux.New()
 		m2.HandleFunc("/b", h2)
 
 		m0.Mount("/a", m1)
@@ -545,25 +813,25 @@ func TestMount(t *testing.T) {
 		}()
 
 		h1 := handlerFactory(http.StatusTeapot, "/a")
-		m1 := mux.New(http.NotFound)
+		m1 := mux.New()
 		m1.HandleFunc("/a", h1)
 
 		h2 := handlerFactory(http.StatusTeapot, "/a")
-		m2 := mux.New(http.NotFound)
+		m2 := mux.New()
 		m2.HandleFunc("/a", h2)
 
 		m1.Mount("", m2)
 	})
 
 	t.Run("regexp", func(t *testing.T) {
-		m0 := mux.New(http.NotFound)
+		m0 := mux.New()
 
 		h1 := handlerFactory(http.StatusTeapot, "/a/1")
-		m1 := mux.New(http.NotFound)
+		m1 := mux.New()
 		m1.RegexpHandleFunc("/(?P<id>[0-9])", h1)
 
 		h2 := handlerFactory(http.StatusTeapot, "/b/2")
-		m2 := mux.New(http.NotFound)
+		m2 := mux.New()
 		m2.RegexpHandleFunc("/(?P<id>[0-9])", h2)
 
 		m0.Mount("/a", m1)
@@ -599,10 +867,10 @@ func TestMount(t *testing.T) {
 	})
 
 	t.Run("prefix+slash", func(t *testing.T) {
-		m := mux.New(http.NotFound)
+		m := mux.New()
 
 		h1 := handlerFactory(http.StatusTeapot, "/a")
-		m1 := mux.New(http.NotFound)
+		m1 := mux.New()
 		m1.HandleFunc("/", h1)
 
 		m.Mount("/a", m1)
@@ -634,4 +902,702 @@ func TestMount(t *testing.T) {
 			})
 		}
 	})
+
+	t.Run("submux root", func(t *testing.T) {
+		cases := []struct {
+			name   string
+			prefix string
+			root   bool // whether the parent registers "/"
+			paths  map[string]string
+		}{
+			{
+				"prefix",
+				"/blog",
+				false,
+				map[string]string{"/blog": "blog index", "/blog/a": "blog a"},
+			},
+			{
+				"prefix+root",
+				"/blog",
+				true,
+				map[string]string{"/": "index", "/blog": "blog index", "/blog/a": "blog a"},
+			},
+			{
+				"empty prefix",
+				"",
+				false,
+				map[string]string{"/": "blog index", "/a": "blog a"},
+			},
+		}
+
+		for _, c := range cases {
+			t.Run(c.name, func(t *testing.T) {
+				m := mux.New()
+				if c.root {
+					m.HandleFunc("/", handlerFactory(http.StatusTeapot, "index"))
+				}
+
+				blog := mux.New()
+				blog.HandleFunc("/", handlerFactory(http.StatusTeapot, "blog index"))
+				blog.HandleFunc("/a", handlerFactory(http.StatusTeapot, "blog a"))
+				m.Mount(c.prefix, blog)
+
+				for path, want := range c.paths {
+					t.Run(path, func(t *testing.T) {
+						r := httptest.NewRequest(http.MethodGet, path, nil)
+						rec := httptest.NewRecorder()
+						m.ServeHTTP(rec, r)
+						resp := rec.Result()
+
+						if resp.StatusCode != http.StatusTeapot {
+							t.Errorf("got StatusCode %d, want %d", resp.StatusCode, http.StatusTeapot)
+						}
+
+						b, err := ioutil.ReadAll(resp.Body)
+						if err != nil {
+							t.Fatal(err)
+						}
+
+						body := string(b)
+						if body != want {
+							t.Errorf("got body %q, want %q", body, want)
+						}
+					})
+				}
+			})
+		}
+	})
+
+	t.Run("red", func(t *testing.T) {
+		cases := []struct {
+			name   string
+			prefix string
+			path   string // not registered after the panic
+		}{
+			{"empty prefix+root", "", "/a"},
+			{"slash", "/", "//a"},
+			{"trailing slash", "/blog/", "/blog//a"},
+			{"no leading slash", "blog", "/blog/a"},
+		}
+
+		for _, c := range cases {
+			t.Run(c.name, func(t *testing.T) {
+				m := mux.New()
+				m.HandleFunc("/", handlerFactory(http.StatusTeapot, "index"))
+
+				blog := mux.New()
+				blog.HandleFunc("/", handlerFactory(http.StatusTeapot, "blog index"))
+				blog.HandleFunc("/a", handlerFactory(http.StatusTeapot, "blog a"))
+
+				defer func() {
+					if recover() == nil {
+						t.Error("got no panic, want panic")
+					}
+
+					// registration is all or nothing
+					r := httptest.NewRequest(http.MethodGet, c.path, nil)
+					rec := httptest.NewRecorder()
+					m.ServeHTTP(rec, r)
+					if rec.Code == http.StatusTeapot {
+						t.Errorf("got StatusCode %d, want other", rec.Code)
+					}
+				}()
+
+				m.Mount(c.prefix, blog)
+			})
+		}
+	})
+}
+
+func TestMatchQuery(t *testing.T) {
+	newMux := func() *mux.Mux {
+		h := func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+			fmt.Fprintf(w, "%s %s", mux.Param(r, "type"), mux.Param(r, "id"))
+		}
+
+		m := mux.New()
+		m.RegexpHandleFunc(`^/page\?id=(?P<id>[0-9]+)&type=(?P<type>article|gallery)$`, h, mux.MatchQuery())
+		return m
+	}
+
+	t.Run("green", func(t *testing.T) {
+		cases := []struct {
+			path string
+			body string
+		}{
+			{"/page?id=12&type=article", "article 12"},
+			{"/page?id=3&type=gallery", "gallery 3"},
+		}
+
+		for _, c := range cases {
+			t.Run(c.path, func(t *testing.T) {
+				r := httptest.NewRequest(http.MethodGet, c.path, nil)
+				rec := httptest.NewRecorder()
+				newMux().ServeHTTP(rec, r)
+				resp := rec.Result()
+
+				if resp.StatusCode != http.StatusTeapot {
+					t.Errorf("got StatusCode %d, want %d", resp.StatusCode, http.StatusTeapot)
+				}
+
+				b, err := ioutil.ReadAll(resp.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				body := string(b)
+				if body != c.body {
+					t.Errorf("got body %q, want %q", body, c.body)
+				}
+			})
+		}
+	})
+
+	t.Run("yellow", func(t *testing.T) {
+		cases := []string{
+			"/page",
+			"/page?id=12&type=video",
+			"/page?type=article&id=12",
+		}
+
+		for _, path := range cases {
+			t.Run(path, func(t *testing.T) {
+				r := httptest.NewRequest(http.MethodGet, path, nil)
+				rec := httptest.NewRecorder()
+				newMux().ServeHTTP(rec, r)
+				resp := rec.Result()
+
+				if resp.StatusCode != http.StatusNotFound {
+					t.Errorf("got StatusCode %d, want %d", resp.StatusCode, http.StatusNotFound)
+				}
+			})
+		}
+	})
+
+	t.Run("redirect", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/page/?id=12&type=article", nil)
+		rec := httptest.NewRecorder()
+		newMux().ServeHTTP(rec, r)
+		resp := rec.Result()
+
+		if resp.StatusCode != http.StatusPermanentRedirect {
+			t.Errorf("got StatusCode %d, want %d", resp.StatusCode, http.StatusPermanentRedirect)
+		}
+
+		location := resp.Header.Get("Location")
+		if location != "/page?id=12&type=article" {
+			t.Errorf("got Location %q, want %q", location, "/page?id=12&type=article")
+		}
+	})
+
+	t.Run("non-regexp", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("got no panic, want panic")
+			}
+		}()
+
+		m := mux.New()
+		m.HandleFunc("/page", handlerFactory(http.StatusTeapot, ""), mux.MatchQuery())
+	})
+}
+
+func TestWrapAll(t *testing.T) {
+	newMux := func() *mux.Mux {
+		m := mux.New()
+		m.HandleFunc("/a", handlerFactory(http.StatusTeapot, "a"))
+		m.RegexpHandleFunc("^/b/(?P<id>[0-9]+)$", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+			fmt.Fprintf(w, "b %s", mux.Param(r, "id"))
+		})
+
+		m.WrapAll(func(pattern string, h http.Handler) http.Handler {
+			if pattern == "/a" {
+				return h
+			}
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Pattern", pattern)
+				h.ServeHTTP(w, r)
+			})
+		})
+
+		m.HandleFunc("/c", handlerFactory(http.StatusTeapot, "c"))
+		return m
+	}
+
+	cases := []struct {
+		path    string
+		body    string
+		pattern string
+	}{
+		{"/a", "a", ""},
+		{"/b/12", "b 12", "^/b/(?P<id>[0-9]+)$"},
+		{"/c", "c", ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, c.path, nil)
+			rec := httptest.NewRecorder()
+			newMux().ServeHTTP(rec, r)
+			resp := rec.Result()
+
+			if resp.StatusCode != http.StatusTeapot {
+				t.Errorf("got StatusCode %d, want %d", resp.StatusCode, http.StatusTeapot)
+			}
+
+			pattern := resp.Header.Get("X-Pattern")
+			if pattern != c.pattern {
+				t.Errorf("got X-Pattern %q, want %q", pattern, c.pattern)
+			}
+
+			b, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body := string(b)
+			if body != c.body {
+				t.Errorf("got body %q, want %q", body, c.body)
+			}
+		})
+	}
+
+	t.Run("nil", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("got no panic, want panic")
+			}
+		}()
+
+		m := mux.New()
+		m.HandleFunc("/a", handlerFactory(http.StatusTeapot, "a"))
+		m.WrapAll(func(pattern string, h http.Handler) http.Handler {
+			return nil
+		})
+	})
+}
+
+func TestSetFallback(t *testing.T) {
+	newMux := func() *mux.Mux {
+		m2 := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "m2 not found")))
+		m2.HandleFunc("/old", handlerFactory(http.StatusTeapot, "old"))
+		m2.HandleFunc("/b", handlerFactory(http.StatusTeapot, "b"))
+
+		m1 := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "m1 not found")))
+		m1.HandleFunc("/new", handlerFactory(http.StatusTeapot, "new"))
+		m1.SetFallback(m2)
+
+		return m1
+	}
+
+	cases := []struct {
+		path     string
+		code     int
+		body     string
+		location string
+	}{
+		{"/new", http.StatusTeapot, "new", ""},
+		{"/old", http.StatusTeapot, "old", ""},
+		{"/missing", http.StatusNotFound, "m1 not found", ""},
+		{"/b/", http.StatusPermanentRedirect, "", "/b"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, c.path, nil)
+			rec := httptest.NewRecorder()
+			newMux().ServeHTTP(rec, r)
+			resp := rec.Result()
+
+			if resp.StatusCode != c.code {
+				t.Errorf("got StatusCode %d, want %d", resp.StatusCode, c.code)
+			}
+
+			location := resp.Header.Get("Location")
+			if location != c.location {
+				t.Errorf("got Location %q, want %q", location, c.location)
+			}
+
+			if c.body == "" {
+				return
+			}
+
+			b, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body := string(b)
+			if body != c.body {
+				t.Errorf("got body %q, want %q", body, c.body)
+			}
+		})
+	}
+
+	t.Run("chain", func(t *testing.T) {
+		legacy := http.NewServeMux()
+		legacy.HandleFunc("/legacy", handlerFactory(http.StatusTeapot, "legacy"))
+
+		m2 := mux.New()
+		m2.HandleFunc("/old", handlerFactory(http.StatusTeapot, "old"))
+		m2.SetFallback(legacy)
+
+		m1 := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "m1 not found")))
+		m1.HandleFunc("/new", handlerFactory(http.StatusTeapot, "new"))
+		m1.SetFallback(m2)
+
+		cases := []struct {
+			path string
+			code int
+			body string
+		}{
+			{"/new", http.StatusTeapot, "new"},
+			{"/old", http.StatusTeapot, "old"},
+			{"/legacy", http.StatusTeapot, "legacy"},
+			{"/missing", http.StatusNotFound, "404 page not found\n"},
+		}
+
+		for _, c := range cases {
+			t.Run(c.path, func(t *testing.T) {
+				r := httptest.NewRequest(http.MethodGet, c.path, nil)
+				rec := httptest.NewRecorder()
+				m1.ServeHTTP(rec, r)
+				resp := rec.Result()
+
+				if resp.StatusCode != c.code {
+					t.Errorf("got StatusCode %d, want %d", resp.StatusCode, c.code)
+				}
+
+				b, err := ioutil.ReadAll(resp.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				body := string(b)
+				if body != c.body {
+					t.Errorf("got body %q, want %q", body, c.body)
+				}
+			})
+		}
+	})
+
+	t.Run("self", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("got no panic, want panic")
+			}
+		}()
+
+		m := mux.New()
+		m.SetFallback(m)
+	})
+}
+
+func TestMethod(t *testing.T) {
+	newMux := func() *mux.Mux {
+		m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found")))
+		m.Method(http.MethodGet, "/a", handlerFactory(http.StatusTeapot, "get a"))
+		m.Method(http.MethodPost, "/a", handlerFactory(http.StatusTeapot, "post a"))
+		m.Method(http.MethodGet, "/b", handlerFactory(http.StatusTeapot, "get b"))
+		m.HandleFunc("/b", handlerFactory(http.StatusTeapot, "any b"))
+		m.RegexpHandleFunc(`^/a/(?P<id>[0-9]+)$`, handlerFactory(http.StatusTeapot, "any a id"))
+		m.Method(http.MethodGet, "/a/1", handlerFactory(http.StatusTeapot, "get a 1"))
+		return m
+	}
+
+	t.Run("green", func(t *testing.T) {
+		cases := []struct {
+			method string
+			path   string
+			code   int
+			body   string
+			allow  string
+		}{
+			{http.MethodGet, "/a", http.StatusTeapot, "get a", ""},
+			{http.MethodPost, "/a", http.StatusTeapot, "post a", ""},
+			{http.MethodPut, "/a", http.StatusMethodNotAllowed, "Method Not Allowed\n", "GET, HEAD, OPTIONS, POST"},
+			{http.MethodGet, "/b", http.StatusTeapot, "get b", ""},
+			{http.MethodPut, "/b", http.StatusTeapot, "any b", ""},
+			{http.MethodDelete, "/a/1", http.StatusTeapot, "any a id", ""},
+			{http.MethodGet, "/x", http.StatusNotFound, "not found", ""},
+		}
+
+		for _, c := range cases {
+			t.Run(c.method+" "+c.path, func(t *testing.T) {
+				r := httptest.NewRequest(c.method, c.path, nil)
+				rec := httptest.NewRecorder()
+				newMux().ServeHTTP(rec, r)
+				resp := rec.Result()
+
+				if resp.StatusCode != c.code {
+					t.Errorf("got StatusCode %d, want %d", resp.StatusCode, c.code)
+				}
+
+				allow := resp.Header.Get("Allow")
+				if allow != c.allow {
+					t.Errorf("got Allow %q, want %q", allow, c.allow)
+				}
+
+				b, err := ioutil.ReadAll(resp.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				body := string(b)
+				if body != c.body {
+					t.Errorf("got body %q, want %q", body, c.body)
+				}
+			})
+		}
+	})
+
+	t.Run("red", func(t *testing.T) {
+		cases := []struct {
+			name     string
+			register func(m *mux.Mux)
+		}{
+			{"same method", func(m *mux.Mux) {
+				m.Method(http.MethodGet, "/a", handlerFactory(http.StatusTeapot, "a"))
+			}},
+			{"empty method", func(m *mux.Mux) {
+				m.Method("", "/d", handlerFactory(http.StatusTeapot, "d"))
+			}},
+			{"any method", func(m *mux.Mux) {
+				m.HandleFunc("/b", handlerFactory(http.StatusTeapot, "b"))
+			}},
+			{"regexp and exact", func(m *mux.Mux) {
+				m.RegexpHandleFunc("/a", handlerFactory(http.StatusTeapot, "a"))
+			}},
+		}
+
+		for _, c := range cases {
+			t.Run(c.name, func(t *testing.T) {
+				defer func() {
+					if recover() == nil {
+						t.Error("got no panic, want panic")
+					}
+				}()
+
+				c.register(newMux())
+			})
+		}
+	})
+}
+
+func TestMethodShortcuts(t *testing.T) {
+	m := mux.New()
+	m.Get("/a", handlerFactory(http.StatusTeapot, http.MethodGet))
+	m.Post("/a", handlerFactory(http.StatusTeapot, http.MethodPost))
+	m.Put("/a", handlerFactory(http.StatusTeapot, http.MethodPut))
+	m.Patch("/a", handlerFactory(http.StatusTeapot, http.MethodPatch))
+	m.Delete("/a", handlerFactory(http.StatusTeapot, http.MethodDelete))
+
+	methods := []string{
+		http.MethodGet,
+		http.MethodPost,
+		http.MethodPut,
+		http.MethodPatch,
+		http.MethodDelete,
+	}
+
+	for _, method := range methods {
+		t.Run(method, func(t *testing.T) {
+			r := httptest.NewRequest(method, "/a", nil)
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+			resp := rec.Result()
+
+			if resp.StatusCode != http.StatusTeapot {
+				t.Errorf("got StatusCode %d, want %d", resp.StatusCode, http.StatusTeapot)
+			}
+
+			b, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body := string(b)
+			if body != method {
+				t.Errorf("got body %q, want %q", body, method)
+			}
+		})
+	}
+}
+
+func TestMethodPattern(t *testing.T) {
+	t.Run("green", func(t *testing.T) {
+		m := mux.New()
+		m.HandleFunc("GET /users/{id}", handlerFactory(http.StatusTeapot, "get"))
+		m.HandleFunc("DELETE\t /users/{id}", handlerFactory(http.StatusTeapot, "delete"))
+		m.Handle("POST /users", handlerFactory(http.StatusTeapot, "post"))
+		m.HandleFunc("/users", handlerFactory(http.StatusTeapot, "any"))
+
+		cases := []struct {
+			method     string
+			path       string
+			statusCode int
+			body       string
+		}{
+			{http.MethodGet, "/users/1", http.StatusTeapot, "get"},
+			{http.MethodDelete, "/users/1", http.StatusTeapot, "delete"},
+			{http.MethodPut, "/users/1", http.StatusMethodNotAllowed, "Method Not Allowed\n"},
+			{http.MethodPost, "/users", http.StatusTeapot, "post"},
+			{http.MethodGet, "/users", http.StatusTeapot, "any"},
+		}
+		for _, c := range cases {
+			t.Run(c.method+" "+c.path, func(t *testing.T) {
+				rec := httptest.NewRecorder()
+				m.ServeHTTP(rec, httptest.NewRequest(c.method, c.path, nil))
+				resp := rec.Result()
+
+				if resp.StatusCode != c.statusCode {
+					t.Errorf("got StatusCode %d, want %d", resp.StatusCode, c.statusCode)
+				}
+
+				b, err := ioutil.ReadAll(resp.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				body := string(b)
+				if body != c.body {
+					t.Errorf("got body %q, want %q", body, c.body)
+				}
+			})
+		}
+	})
+
+	t.Run("red", func(t *testing.T) {
+		patterns := []string{
+			"GET",
+			"GET ",
+			"GET users",
+			"GE/T /users",
+			"GET /users/",
+		}
+		for _, pattern := range patterns {
+			t.Run(pattern, func(t *testing.T) {
+				defer func() {
+					if recover() == nil {
+						t.Error("got no panic, want panic")
+					}
+				}()
+
+				m := mux.New()
+				m.HandleFunc(pattern, handlerFactory(http.StatusTeapot, ""))
+			})
+		}
+	})
+}
+
+func TestMatchOrder(t *testing.T) {
+	m := mux.New()
+	m.HandleFunc("/users/new", handlerFactory(http.StatusTeapot, "new"))
+	m.HandleFunc("/users/{id}", handlerFactory(http.StatusTeapot, "id"))
+	m.RegexpHandleFunc("^/b/1$", handlerFactory(http.StatusTeapot, "b1"))
+	m.RegexpHandleFunc("^/b/[0-9]+$", handlerFactory(http.StatusTeapot, "b"))
+	m.RegexpHandleFunc("^/c/[0-9]+$", handlerFactory(http.StatusTeapot, "c"))
+	m.RegexpHandleFunc("^/c/1$", handlerFactory(http.StatusTeapot, "c1"))
+	m.RegexpHandleFunc("^/d/.*$", handlerFactory(http.StatusTeapot, "d regexp"))
+	m.HandleFunc("/d/1", handlerFactory(http.StatusTeapot, "d1"))
+	m.HandleFunc("/{path...}", handlerFactory(http.StatusTeapot, "catch-all"))
+	m.HandleFunc("/e/{path...}", handlerFactory(http.StatusTeapot, "e catch-all"))
+	m.RegexpHandleFunc("^/e/[0-9]+$", handlerFactory(http.StatusTeapot, "e regexp"))
+	m.RegexpHandleFunc("^/users/[0-9]+$", handlerFactory(http.StatusTeapot, "users regexp"))
+
+	cases := []struct {
+		path string
+		body string
+	}{
+		{"/users/new", "new"},
+		{"/users/12", "id"},
+		{"/e/1", "e regexp"},
+		{"/e/a", "e catch-all"},
+		{"/f", "catch-all"},
+		{"/users/12/a", "catch-all"},
+		{"/b/1", "b1"},
+		{"/b/2", "b"},
+		{"/c/1", "c"},
+		{"/d/1", "d1"},
+		{"/d/2", "d regexp"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			// Repeat so that random order would show.
+			for i := 0; i < 20; i++ {
+				r := httptest.NewRequest(http.MethodGet, c.path, nil)
+				rec := httptest.NewRecorder()
+				m.ServeHTTP(rec, r)
+
+				b, err := ioutil.ReadAll(rec.Result().Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				body := string(b)
+				if body != c.body {
+					t.Fatalf("got body %q, want %q", body, c.body)
+				}
+			}
+		})
+	}
+}
+
+func TestHandle(t *testing.T) {
+	m := mux.New()
+	m.Handle("/files/a.txt", http.FileServer(http.FS(fstest.MapFS{
+		"files/a.txt": &fstest.MapFile{Data: []byte("a")},
+	})))
+	m.Handle("/b", handlerFactory(http.StatusTeapot, "b"), mux.WithMiddleware(tag("b")))
+
+	cases := []struct {
+		path string
+		code int
+		body string
+		tags string
+	}{
+		{"/files/a.txt", http.StatusOK, "a", ""},
+		{"/b", http.StatusTeapot, "b", "b"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, c.path, nil)
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+			resp := rec.Result()
+
+			if resp.StatusCode != c.code {
+				t.Errorf("got StatusCode %d, want %d", resp.StatusCode, c.code)
+			}
+
+			tags := strings.Join(resp.Header["X-Tags"], " ")
+			if tags != c.tags {
+				t.Errorf("got X-Tags %q, want %q", tags, c.tags)
+			}
+
+			b, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body := string(b)
+			if body != c.body {
+				t.Errorf("got body %q, want %q", body, c.body)
+			}
+		})
+	}
+
+	t.Run("nil", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("got no panic, want panic")
+			}
+		}()
+
+		mux.New().Handle("/a", nil)
+	})
 }
@@ -0,0 +1,165 @@
+package mux
+
+import (
+	"net/http"
+	"strings"
+)
+
+// OpenAPIDocument is an OpenAPI 3 document describing the routes of a mux, as
+// returned by OpenAPI. It encodes to the JSON form of the document with
+// encoding/json and may be completed, such as with responses, before.
+type OpenAPIDocument struct {
+	OpenAPI string                     `json:"openapi"`
+	Info    OpenAPIInfo                `json:"info"`
+	Paths   map[string]OpenAPIPathItem `json:"paths"`
+}
+
+// OpenAPIInfo is the metadata of an API.
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// OpenAPIPathItem are the operations of a path by lowercase method, like
+// "get".
+type OpenAPIPathItem map[string]*OpenAPIOperation
+
+// OpenAPIOperation describes the handling of a path for a method.
+type OpenAPIOperation struct {
+	Summary     string                     `json:"summary,omitempty"`
+	Description string                     `json:"description,omitempty"`
+	Tags        []string                   `json:"tags,omitempty"`
+	Deprecated  bool                       `json:"deprecated,omitempty"`
+	Parameters  []OpenAPIParameter         `json:"parameters,omitempty"`
+	Responses   map[string]OpenAPIResponse `json:"responses"`
+}
+
+// OpenAPIParameter describes a parameter of an operation.
+type OpenAPIParameter struct {
+	Name     string        `json:"name"`
+	In       string        `json:"in"`
+	Required bool          `json:"required"`
+	Schema   OpenAPISchema `json:"schema"`
+}
+
+// OpenAPISchema describes the values of a parameter.
+type OpenAPISchema struct {
+	Type    string `json:"type"`
+	Pattern string `json:"pattern,omitempty"`
+}
+
+// OpenAPIResponse describes a response of an operation.
+type OpenAPIResponse struct {
+	Description string `json:"description"`
+}
+
+// openAPIMethods are the methods OpenAPI has operations for.
+var openAPIMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodPut:     true,
+	http.MethodPost:    true,
+	http.MethodDelete:  true,
+	http.MethodOptions: true,
+	http.MethodHead:    true,
+	http.MethodPatch:   true,
+	http.MethodTrace:   true,
+}
+
+// OpenAPI returns an OpenAPI 3 document describing the routes of mux and of
+// the muxes mounted with MountLive or MountHandler, as visited by Walk, so
+// that the description of an API is derived from its router rather than kept
+// in sync with it by hand:
+//
+//	doc := m.OpenAPI(mux.OpenAPIInfo{Title: "Users", Version: "1.0"})
+//	b, err := json.Marshal(doc)
+//
+// Each method a route has a handler for is an operation; a route for any
+// method is described as a GET operation. Path parameters are required string
+// parameters, with their constraint as pattern. Tags attached with Tags are
+// the tags of the operations and the string values attached with Meta under
+// "summary" and "description" and the bool value under "deprecated" set the
+// corresponding fields. As the router knows nothing of responses, each
+// operation has only a default response.
+//
+// Regexp patterns, subtree patterns and patterns ending in a wildcard match
+// paths OpenAPI can not describe and are left out, as are routes with
+// methods OpenAPI has no operations for.
+func (mux *Mux) OpenAPI(info OpenAPIInfo) OpenAPIDocument {
+	doc := OpenAPIDocument{OpenAPI: "3.0.3", Info: info, Paths: make(map[string]OpenAPIPathItem)}
+	mux.Walk(func(rt Route) error {
+		pattern := rt.Pattern
+		if rt.Regexp || pattern != "/" && strings.HasSuffix(pattern, "/") {
+			return nil
+		}
+		path, params, ok := openAPIPath(rt.Prefix + pattern)
+		if !ok {
+			return nil
+		}
+
+		methods := rt.Methods
+		if rt.Handler != nil && rt.methods[http.MethodGet] == nil {
+			methods = append(methods[:len(methods):len(methods)], http.MethodGet)
+		}
+		for _, method := range methods {
+			if !openAPIMethods[method] {
+				continue
+			}
+			item := doc.Paths[path]
+			if item == nil {
+				item = make(OpenAPIPathItem)
+				doc.Paths[path] = item
+			}
+			key := strings.ToLower(method)
+			if item[key] != nil {
+				continue // a conditional route of the same pattern
+			}
+			item[key] = openAPIOperation(rt, params)
+		}
+		return nil
+	})
+	return doc
+}
+
+// openAPIPath returns the OpenAPI path template of the non-regexp pattern and
+// its path parameters. It reports false if the pattern ends in a wildcard.
+func openAPIPath(pattern string) (string, []OpenAPIParameter, bool) {
+	segments := parseSegments(pattern)
+	if segments == nil {
+		return pattern, nil, true
+	}
+
+	var b strings.Builder
+	var params []OpenAPIParameter
+	for _, s := range segments {
+		b.WriteByte('/')
+		if !s.param {
+			b.WriteString(s.text)
+			continue
+		}
+		if s.wildcard {
+			return "", nil, false
+		}
+		b.WriteString("{" + s.text + "}")
+		p := OpenAPIParameter{Name: s.text, In: "path", Required: true, Schema: OpenAPISchema{Type: "string"}}
+		if s.re != nil {
+			p.Schema.Pattern = s.re.String()
+		}
+		params = append(params, p)
+	}
+	return b.String(), params, true
+}
+
+// openAPIOperation returns the operation of the route rt with the given path
+// parameters.
+func openAPIOperation(rt Route, params []OpenAPIParameter) *OpenAPIOperation {
+	op := &OpenAPIOperation{
+		Tags:       rt.Tags,
+		Parameters: params,
+		Responses:  map[string]OpenAPIResponse{"default": {Description: "Default response"}},
+	}
+	op.Summary, _ = rt.Meta["summary"].(string)
+	op.Description, _ = rt.Meta["description"].(string)
+	op.Deprecated, _ = rt.Meta["deprecated"].(bool)
+	return op
+}
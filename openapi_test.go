@@ -0,0 +1,75 @@
+package mux_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestOpenAPI(t *testing.T) {
+	h := handlerFactory(http.StatusTeapot, "")
+
+	api := mux.New()
+	api.Get("/users/{id:[0-9]+}", h, mux.Tags("users"), mux.Meta("summary", "Get a user"))
+	api.Delete("/users/{id:[0-9]+}", h, mux.Meta("deprecated", true))
+	api.Post("/users", h)
+
+	m := mux.New()
+	m.HandleFunc("/", h)
+	m.HandleFunc("/about", h)
+	m.HandleFunc("/files/{path...}", h)
+	m.RegexpHandleFunc("^/[a-z]+\\.txt$", h)
+	m.Method("PURGE", "/cache", h)
+	m.MountLive("/api", api)
+
+	doc := m.OpenAPI(mux.OpenAPIInfo{Title: "Test", Version: "1.0"})
+
+	if doc.OpenAPI != "3.0.3" {
+		t.Errorf("got OpenAPI %q, want %q", doc.OpenAPI, "3.0.3")
+	}
+
+	var paths []string
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	if want := []string{"/", "/about", "/api/users", "/api/users/{id}"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("got paths %q, want %q", paths, want)
+	}
+
+	user := doc.Paths["/api/users/{id}"]
+	get, del := user["get"], user["delete"]
+	if get == nil || del == nil || len(user) != 2 {
+		t.Fatalf("got operations %v, want get and delete", user)
+	}
+	param := mux.OpenAPIParameter{Name: "id", In: "path", Required: true, Schema: mux.OpenAPISchema{Type: "string", Pattern: "^(?:[0-9]+)$"}}
+	if !reflect.DeepEqual(get.Parameters, []mux.OpenAPIParameter{param}) {
+		t.Errorf("got parameters %+v, want %+v", get.Parameters, param)
+	}
+	if get.Summary != "Get a user" || !reflect.DeepEqual(get.Tags, []string{"users"}) || !get.Deprecated {
+		t.Errorf("got operation %+v, want summary, tags and deprecated from the metadata", get)
+	}
+	if _, ok := doc.Paths["/about"]["get"]; !ok {
+		t.Error("got no get operation for a route for any method, want one")
+	}
+
+	b, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v struct {
+		Paths map[string]map[string]struct {
+			Responses map[string]interface{} `json:"responses"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		t.Fatal(err)
+	}
+	if len(v.Paths["/api/users"]["post"].Responses) == 0 {
+		t.Errorf("got no responses in %s, want default response", b)
+	}
+}
@@ -0,0 +1,114 @@
+package mux
+
+import (
+	"context"
+	"strings"
+)
+
+// Overlap describes two patterns matching the same path, of which only one
+// handles it, as detected by Overlaps.
+type Overlap struct {
+	Pattern  string // pattern handling the path
+	Shadowed string // pattern never routed the path
+	Path     string // path matched by both
+}
+
+// Overlaps returns the overlapping patterns of mux that can be detected,
+// in registration order of the shadowed pattern: regexps matching a pattern
+// without parameters, which takes precedence, and regexps matching a path
+// without special characters that a regexp registered before also matches.
+// Whether other patterns, like a regexp and a pattern with parameters,
+// overlap is not decided.
+//
+// A mux with a logger set by WithSlog also logs them as warnings when they
+// are registered. Tests can assert that a mux has none:
+//
+//	if overlaps := m.Overlaps(); len(overlaps) > 0 {
+//		t.Errorf("overlapping patterns: %+v", overlaps)
+//	}
+func (mux *Mux) Overlaps() []Overlap {
+	t := mux.load()
+	var overlaps []Overlap
+	for i, pattern := range t.patterns {
+		overlaps = append(overlaps, t.overlaps(pattern, t.m[pattern], t.patterns[:i])...)
+	}
+	return overlaps
+}
+
+// overlaps returns the overlaps of the pattern of e with the patterns
+// registered before it.
+func (t *table) overlaps(pattern string, e muxEntry, before []string) []Overlap {
+	var overlaps []Overlap
+	if !e.regexp {
+		if e.segments != nil {
+			return nil
+		}
+		for _, p := range before {
+			if other := t.m[p]; other.regexp && other.re.MatchString(pattern) {
+				overlaps = append(overlaps, Overlap{Pattern: pattern, Shadowed: p, Path: pattern})
+			}
+		}
+		return overlaps
+	}
+
+	for _, p := range before {
+		other := t.m[p]
+		switch {
+		case !other.regexp && other.segments == nil && e.re.MatchString(p):
+			overlaps = append(overlaps, Overlap{Pattern: p, Shadowed: pattern, Path: p})
+		case other.regexp:
+			if path, complete := e.re.LiteralPrefix(); complete && other.re.MatchString(path) {
+				overlaps = append(overlaps, Overlap{Pattern: p, Shadowed: pattern, Path: path})
+			}
+		}
+	}
+	return overlaps
+}
+
+// warnOverlaps logs the overlaps of the pattern of e, being registered, with
+// the patterns registered before it.
+func (t *table) warnOverlaps(pattern string, e muxEntry) {
+	if t.log == nil {
+		return
+	}
+	for _, o := range t.overlaps(pattern, e, t.patterns) {
+		t.logf(context.Background(), levelWarn, "mux: overlapping patterns", "pattern", o.Pattern, "shadowed", o.Shadowed, "path", o.Path)
+	}
+}
+
+// shape returns a string identifying the paths the pattern of e with the
+// given segments matches, equal for patterns differing only in the names of
+// their parameters, like "/users/{id}" and "/users/:name".
+func shape(segments []segment) string {
+	var b strings.Builder
+	for _, s := range segments {
+		b.WriteByte('/')
+		switch {
+		case s.wildcard:
+			b.WriteString("*")
+		case s.param && s.re != nil:
+			b.WriteString("{:" + s.re.String() + "}")
+		case s.param:
+			b.WriteString("{}")
+		default:
+			b.WriteString(s.text)
+		}
+	}
+	return b.String()
+}
+
+// equivalent returns the pattern registered on mux that matches the same
+// paths as the non-regexp pattern with the given segments, being registered,
+// or "" if there is none.
+func (t *table) equivalent(pattern string, segments []segment) string {
+	if segments == nil {
+		return ""
+	}
+	s := shape(segments)
+	for _, p := range t.patterns {
+		if e := t.m[p]; p != pattern && !e.regexp && e.segments != nil && shape(e.segments) == s {
+			return p
+		}
+	}
+	return ""
+}
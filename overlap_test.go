@@ -0,0 +1,60 @@
+package mux_test
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestOverlaps(t *testing.T) {
+	m := mux.New()
+	m.RegexpHandleFunc(`^/users/[a-z]+$`, handlerFactory(http.StatusTeapot, ""))
+	m.HandleFunc("/users/new", handlerFactory(http.StatusTeapot, ""))
+	m.HandleFunc("/users/{id}", handlerFactory(http.StatusTeapot, ""))
+	m.HandleFunc("/about", handlerFactory(http.StatusTeapot, ""))
+	m.RegexpHandleFunc(`^/ab`, handlerFactory(http.StatusTeapot, ""))
+	m.RegexpHandleFunc(`^/users/admin$`, handlerFactory(http.StatusTeapot, ""))
+	m.RegexpHandleFunc(`^/posts/[0-9]+$`, handlerFactory(http.StatusTeapot, ""))
+
+	got := m.Overlaps()
+	want := []mux.Overlap{
+		{Pattern: "/users/new", Shadowed: `^/users/[a-z]+$`, Path: "/users/new"},
+		{Pattern: "/about", Shadowed: `^/ab`, Path: "/about"},
+		{Pattern: `^/users/[a-z]+$`, Shadowed: `^/users/admin$`, Path: "/users/admin"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestEquivalentPatterns(t *testing.T) {
+	cases := []struct {
+		first, second string
+		panics        bool
+	}{
+		{"/users/{id}", "/users/{name}", true},
+		{"/users/{id}", "/users/:name", true},
+		{"/users/{id:[0-9]+}", "/users/{n:[0-9]+}", true},
+		{"/files/{path...}", "/files/*", true},
+		{"/users/{id}", "/users/{id:[0-9]+}", false},
+		{"/users/{id}", "/users/new", false},
+		{"/users/{id}/posts", "/users/{name}", false},
+	}
+	for _, c := range cases {
+		t.Run(c.first+" "+c.second, func(t *testing.T) {
+			m := mux.New()
+			m.HandleFunc(c.first, handlerFactory(http.StatusTeapot, ""))
+
+			defer func() {
+				if err := recover(); err == nil && c.panics {
+					t.Error("got no panic, want panic")
+				} else if err != nil && !c.panics {
+					t.Errorf("got panic %v, want none", err)
+				}
+			}()
+			m.HandleFunc(c.second, handlerFactory(http.StatusTeapot, ""))
+		})
+	}
+}
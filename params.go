@@ -0,0 +1,342 @@
+package mux
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// segment is a path segment of a pattern with parameters.
+type segment struct {
+	text     string // literal text or, if param, parameter name
+	param    bool
+	wildcard bool           // whether the param matches the rest of the path
+	re       *regexp.Regexp // constraint of the param or nil
+}
+
+// parseSegments returns the segments of the non-regexp pattern or nil if the
+// pattern has no parameters. A parameter is a whole segment of the form
+// "{name}" or ":name", or "{name:re}" if constrained to segments matching
+// the regular expression re as a whole. The last segment may be a wildcard of
+// the form
+// "{name...}", or "*" for a wildcard named "*", matching the rest of the path.
+// Panics if a parameter or constraint is invalid, a name is used more than
+// once or a wildcard is not the last segment.
+func parseSegments(pattern string) []segment {
+	if !strings.ContainsAny(pattern, "{}:*") {
+		return nil
+	}
+
+	var segments []segment
+	var hasParam bool
+	names := make(map[string]bool)
+	parts := strings.Split(pattern[1:], "/")
+	for i, s := range parts {
+		var name, constraint string
+		var wildcard bool
+		switch {
+		case s == "*":
+			name, wildcard = s, true
+		case strings.HasPrefix(s, "{") && strings.HasSuffix(s, "...}"):
+			name, wildcard = s[1:len(s)-4], true
+		case strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}"):
+			name = s[1 : len(s)-1]
+			if i := strings.IndexByte(name, ':'); i >= 0 {
+				name, constraint = name[:i], name[i+1:]
+				if constraint == "" {
+					panic("mux: invalid parameter " + s + " in pattern " + pattern)
+				}
+			}
+		case strings.HasPrefix(s, ":"):
+			name = s[1:]
+		case strings.ContainsAny(s, "{}"):
+			panic("mux: invalid parameter " + s + " in pattern " + pattern)
+		default:
+			segments = append(segments, segment{text: s})
+			continue
+		}
+		if name == "" || name != "*" && strings.ContainsAny(name, "{}:*") {
+			panic("mux: invalid parameter " + s + " in pattern " + pattern)
+		}
+		if wildcard && i < len(parts)-1 {
+			panic("mux: wildcard " + s + " must be the last segment of pattern " + pattern)
+		}
+		if names[name] {
+			panic("mux: duplicate parameter " + name + " in pattern " + pattern)
+		}
+		var re *regexp.Regexp
+		if constraint != "" {
+			var err error
+			re, err = regexp.Compile("^(?:" + constraint + ")$")
+			if err != nil {
+				panic("mux: invalid constraint " + s + " in pattern " + pattern + ": " + err.Error())
+			}
+		}
+		names[name] = true
+		hasParam = true
+		segments = append(segments, segment{text: name, param: true, wildcard: wildcard, re: re})
+	}
+	if !hasParam {
+		return nil
+	}
+	return segments
+}
+
+// matchSegments determines whether path matches the segments of a pattern.
+// Parameters match any non-empty segment satisfying their constraint and
+// wildcards the rest of the path, which may be empty.
+func matchSegments(segments []segment, path string) bool {
+	if path == "" || path[0] != '/' {
+		return false
+	}
+	rest := path[1:]
+	for i, s := range segments {
+		if s.wildcard {
+			return true
+		}
+		seg := rest
+		j := strings.IndexByte(rest, '/')
+		if j >= 0 {
+			seg, rest = rest[:j], rest[j+1:]
+		}
+		if last := i == len(segments)-1; last != (j < 0) {
+			return false
+		}
+		if s.param && !s.accepts(seg) || !s.param && seg != s.text {
+			return false
+		}
+	}
+	return true
+}
+
+// accepts reports whether the param s matches the path segment seg.
+func (s segment) accepts(seg string) bool {
+	return seg != "" && (s.re == nil || s.re.MatchString(seg))
+}
+
+// addParamsToContext adds the parameters of the pattern of e to r.Context()
+// before calling h.
+func addParamsToContext(e muxEntry, h http.HandlerFunc) http.HandlerFunc {
+	names := make([]string, len(e.segments))
+	for i, s := range e.segments {
+		if s.param {
+			names[i] = s.text
+		}
+	}
+	wildcard := e.wildcard()
+	return func(w http.ResponseWriter, r *http.Request) {
+		var values []string
+		path := e.path(r)
+		if wildcard {
+			values = strings.SplitN(path[1:], "/", len(names))
+		} else {
+			values = strings.Split(path[1:], "/")
+		}
+		r = withParams(r, names, values, e.stringKeys)
+		if e.paramsToQuery != keepQuery {
+			r = paramsToQuery(r, names, values, e.paramsToQuery == overwriteQuery)
+		}
+		h(w, r)
+	}
+}
+
+// paramMap returns the path parameters or named regexp submatches of the
+// pattern of e for r, which must match it, by name, or nil if it has none.
+// Unlike the handler of e, it does not add them to r.
+func (e muxEntry) paramMap(r *http.Request) map[string]string {
+	var names, values []string
+	switch {
+	case e.regexp:
+		names = e.re.SubexpNames()
+		values = e.re.FindStringSubmatch(e.target(r.URL.Path, r.URL))
+	case e.segments != nil:
+		names = make([]string, len(e.segments))
+		for i, s := range e.segments {
+			if s.param {
+				names[i] = s.text
+			}
+		}
+		if e.wildcard() {
+			values = strings.SplitN(r.URL.Path[1:], "/", len(names))
+		} else {
+			values = strings.Split(r.URL.Path[1:], "/")
+		}
+	}
+
+	var params map[string]string
+	for i, name := range names {
+		if name == "" || i >= len(values) {
+			continue
+		}
+		if params == nil {
+			params = make(map[string]string)
+		}
+		params[name] = values[i]
+	}
+	return params
+}
+
+// wildcard reports whether the pattern of e ends in a wildcard.
+func (e muxEntry) wildcard() bool {
+	return len(e.segments) > 0 && e.segments[len(e.segments)-1].wildcard
+}
+
+// queryMode tells whether and how path parameters are added to the query.
+type queryMode int
+
+const (
+	keepQuery      queryMode = iota // parameters are not added
+	addQuery                        // parameters are added unless in the query
+	overwriteQuery                  // parameters replace those in the query
+)
+
+// ParamsToQuery makes the route add its path parameters to the query of the
+// request, so that handlers reading them with r.FormValue or r.URL.Query
+// keep working. A parameter is not added if the query already has a
+// parameter of the same name.
+//
+// The handler is passed a clone of the request; requests to routes without
+// this option are left untouched.
+func ParamsToQuery() RouteOption {
+	return func(mux *Mux, e *muxEntry) {
+		e.paramsToQuery = addQuery
+	}
+}
+
+// ParamsToQueryOverwrite is ParamsToQuery except that path parameters replace
+// query parameters of the same name.
+func ParamsToQueryOverwrite() RouteOption {
+	return func(mux *Mux, e *muxEntry) {
+		e.paramsToQuery = overwriteQuery
+	}
+}
+
+// paramsToQuery returns a clone of r with the named values added to the
+// query. Values with an empty name are skipped.
+func paramsToQuery(r *http.Request, names, values []string, overwrite bool) *http.Request {
+	r = r.Clone(r.Context())
+	r.Form = nil // parsed again from the new query
+
+	if overwrite {
+		q := r.URL.Query()
+		for i, name := range names {
+			if name != "" {
+				q.Set(name, values[i])
+			}
+		}
+		r.URL.RawQuery = q.Encode()
+		return r
+	}
+
+	q := r.URL.Query()
+	for i, name := range names {
+		if name == "" {
+			continue
+		}
+		if _, ok := q[name]; ok {
+			continue
+		}
+		if r.URL.RawQuery != "" {
+			r.URL.RawQuery += "&"
+		}
+		r.URL.RawQuery += url.QueryEscape(name) + "=" + url.QueryEscape(values[i])
+	}
+	return r
+}
+
+// paramsKey is the context key for the path parameters of a request.
+type paramsKey struct{}
+
+// param is a path parameter or named regexp submatch.
+type param struct {
+	name, value string
+}
+
+// params are the path parameters of a request in the order they were added.
+// A later parameter shadows an earlier one of the same name.
+type params struct {
+	list []param
+	buf  [4]param // backing array of list for requests with few parameters
+}
+
+// withParams returns a shallow copy of r with the named values added to its
+// path parameters and, if stringKeys, to its context under their names.
+// Values with an empty name are skipped.
+//
+// All parameters of r, including those added by muxes r was routed through
+// before, are kept in a single params under one context key, so adding them
+// allocates one context and, for up to four parameters, one params. params
+// are not pooled as the context may outlive the request.
+func withParams(r *http.Request, names, values []string, stringKeys bool) *http.Request {
+	ctx := r.Context()
+	p := new(params)
+	if parent, ok := ctx.Value(paramsKey{}).(*params); ok {
+		p.list = append(p.buf[:0], parent.list...)
+	} else {
+		p.list = p.buf[:0]
+	}
+	for i, name := range names {
+		if name == "" {
+			continue
+		}
+		p.list = append(p.list, param{name, values[i]})
+		if stringKeys {
+			ctx = context.WithValue(ctx, name, values[i])
+		}
+	}
+	r = r.WithContext(context.WithValue(ctx, paramsKey{}, p))
+	setPathValues(r, p)
+	return r
+}
+
+// get returns the value of the parameter with the given name or "" if p has
+// none.
+func (p *params) get(name string) string {
+	if p == nil {
+		return ""
+	}
+	for i := len(p.list) - 1; i >= 0; i-- {
+		if p.list[i].name == name {
+			return p.list[i].value
+		}
+	}
+	return ""
+}
+
+// StringContextKeys makes mux also add path parameters and named regexp
+// submatches to the request context under their names as plain string keys,
+// so that handlers written for older versions of mux reading them with
+// r.Context().Value("id") keep working. It applies to the routes registered
+// after it, so pass it to New.
+//
+// Deprecated: Plain string context keys may collide with those of other
+// packages. Use Param or Params instead.
+func StringContextKeys() Option {
+	return func(mux *Mux) {
+		mux.stringKeys = true
+	}
+}
+
+// Param returns the value of the path parameter or named regexp submatch
+// with the given name or "" if r has none. Built with Go 1.22 or later, mux
+// also sets them as path values, so r.PathValue(name) returns the same.
+func Param(r *http.Request, name string) string {
+	p, _ := r.Context().Value(paramsKey{}).(*params)
+	return p.get(name)
+}
+
+// Params returns the path parameters and named regexp submatches of r by
+// name. The returned map is a copy and may be modified.
+func Params(r *http.Request) map[string]string {
+	p, _ := r.Context().Value(paramsKey{}).(*params)
+	if p == nil {
+		return make(map[string]string)
+	}
+	m := make(map[string]string, len(p.list))
+	for _, param := range p.list {
+		m[param.name] = param.value
+	}
+	return m
+}
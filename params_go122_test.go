@@ -0,0 +1,62 @@
+//go:build go1.22
+// +build go1.22
+
+package mux_test
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestPathValue(t *testing.T) {
+	pathValues := func(names ...string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			for i, name := range names {
+				if i > 0 {
+					io.WriteString(w, " ")
+				}
+				io.WriteString(w, name+"="+r.PathValue(name))
+			}
+		}
+	}
+
+	sub := mux.New()
+	sub.HandleFunc("/posts/{post}", pathValues("user", "post"))
+
+	m := mux.New()
+	m.HandleFunc("/users/{id}/posts/:post", pathValues("id", "post"))
+	m.HandleFunc("/posts/{id:[0-9]+}", pathValues("id"))
+	m.HandleFunc("/files/{path...}", pathValues("path"))
+	m.RegexpHandleFunc(`^/archive/(?P<year>[0-9]{4})$`, pathValues("year"))
+	m.Mount("/people/{user}", sub)
+
+	cases := []struct {
+		path string
+		body string
+	}{
+		{"/users/1/posts/2", "id=1 post=2"},
+		{"/posts/12", "id=12"},
+		{"/files/a/b", "path=a/b"},
+		{"/archive/2021", "year=2021"},
+		{"/people/ann/posts/3", "user=ann post=3"},
+	}
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, c.path, nil))
+
+			body, err := ioutil.ReadAll(w.Result().Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(body) != c.body {
+				t.Errorf("got body %q, want %q", body, c.body)
+			}
+		})
+	}
+}
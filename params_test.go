@@ -0,0 +1,445 @@
+package mux_test
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestParamsToQuery(t *testing.T) {
+	legacy := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		io.WriteString(w, r.FormValue("id")+" "+r.URL.Query().Get("name")+" "+r.URL.RawQuery)
+	}
+
+	cases := []struct {
+		name   string
+		option mux.RouteOption
+		path   string
+		body   string
+	}{
+		{
+			"add",
+			mux.ParamsToQuery(),
+			"/users/12/a",
+			"12 a id=12&name=a",
+		},
+		{
+			"existing query",
+			mux.ParamsToQuery(),
+			"/users/12/a?x=1&id=7",
+			"7 a x=1&id=7&name=a",
+		},
+		{
+			"overwrite",
+			mux.ParamsToQueryOverwrite(),
+			"/users/12/a?x=1&id=7",
+			"12 a id=12&name=a&x=1",
+		},
+		{
+			"special characters",
+			mux.ParamsToQuery(),
+			"/users/12/a%26b%3Dc%20d",
+			"12 a&b=c d id=12&name=a%26b%3Dc+d",
+		},
+		{
+			"off",
+			nil,
+			"/users/12/a?x=1",
+			"  x=1",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			m := mux.New()
+			if c.option != nil {
+				m.RegexpHandleFunc("^/users/(?P<id>[0-9]+)/(?P<name>.+)$", legacy, c.option)
+			} else {
+				m.RegexpHandleFunc("^/users/(?P<id>[0-9]+)/(?P<name>.+)$", legacy)
+			}
+
+			r := httptest.NewRequest(http.MethodGet, c.path, nil)
+			rawQuery := r.URL.RawQuery
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+			resp := rec.Result()
+
+			if resp.StatusCode != http.StatusTeapot {
+				t.Errorf("got StatusCode %d, want %d", resp.StatusCode, http.StatusTeapot)
+			}
+
+			b, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body := string(b)
+			if body != c.body {
+				t.Errorf("got body %q, want %q", body, c.body)
+			}
+
+			if r.URL.RawQuery != rawQuery {
+				t.Errorf("got original RawQuery %q, want %q", r.URL.RawQuery, rawQuery)
+			}
+		})
+	}
+}
+
+func TestPathParams(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		io.WriteString(w, mux.Param(r, "id")+" "+mux.Param(r, "post"))
+	}
+
+	newMux := func() *mux.Mux {
+		m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "")))
+		m.HandleFunc("/users/{id}", h)
+		m.HandleFunc("/users/:id/posts/{post}", h)
+		m.Get("/files/{id}", h)
+		return m
+	}
+
+	t.Run("green", func(t *testing.T) {
+		cases := []struct {
+			method   string
+			path     string
+			code     int
+			body     string
+			location string
+		}{
+			{http.MethodGet, "/users/12", http.StatusTeapot, "12 ", ""},
+			{http.MethodGet, "/users/12/posts/7", http.StatusTeapot, "12 7", ""},
+			{http.MethodGet, "/files/1", http.StatusTeapot, "1 ", ""},
+			{http.MethodGet, "/users/12/", http.StatusPermanentRedirect, "", "/users/12"},
+			{http.MethodGet, "/users/", http.StatusNotFound, "", ""},
+			{http.MethodGet, "/users", http.StatusNotFound, "", ""},
+			{http.MethodGet, "/users/12/posts", http.StatusNotFound, "", ""},
+			{http.MethodGet, "/users/12/posts//", http.StatusNotFound, "", ""},
+			{http.MethodPost, "/files/1", http.StatusMethodNotAllowed, "Method Not Allowed\n", ""},
+		}
+
+		for _, c := range cases {
+			t.Run(c.method+" "+c.path, func(t *testing.T) {
+				r := httptest.NewRequest(c.method, c.path, nil)
+				rec := httptest.NewRecorder()
+				newMux().ServeHTTP(rec, r)
+				resp := rec.Result()
+
+				if resp.StatusCode != c.code {
+					t.Errorf("got StatusCode %d, want %d", resp.StatusCode, c.code)
+				}
+
+				location := resp.Header.Get("Location")
+				if location != c.location {
+					t.Errorf("got Location %q, want %q", location, c.location)
+				}
+
+				if c.location != "" {
+					return
+				}
+
+				b, err := ioutil.ReadAll(resp.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				body := string(b)
+				if body != c.body {
+					t.Errorf("got body %q, want %q", body, c.body)
+				}
+			})
+		}
+	})
+
+	t.Run("ParamsToQuery", func(t *testing.T) {
+		legacy := func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, r.URL.RawQuery)
+		}
+
+		m := mux.New()
+		m.HandleFunc("/users/{id}/{name}", legacy, mux.ParamsToQuery())
+
+		r := httptest.NewRequest(http.MethodGet, "/users/12/a?x=1", nil)
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, r)
+
+		b, err := ioutil.ReadAll(rec.Result().Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body := string(b)
+		if body != "x=1&id=12&name=a" {
+			t.Errorf("got body %q, want %q", body, "x=1&id=12&name=a")
+		}
+	})
+
+	t.Run("red", func(t *testing.T) {
+		patterns := []string{
+			"/users/{}",
+			"/users/:",
+			"/users/{id",
+			"/users/a{id}",
+			"/users/{id}/{id}",
+			"/users/{a:}",
+			"/users/{a:[}",
+			"/users/{a:b:c}x",
+		}
+
+		for _, pattern := range patterns {
+			t.Run(pattern, func(t *testing.T) {
+				defer func() {
+					if recover() == nil {
+						t.Error("got no panic, want panic")
+					}
+				}()
+
+				m := mux.New()
+				m.HandleFunc(pattern, handlerFactory(http.StatusTeapot, ""))
+			})
+		}
+	})
+}
+
+func TestParamConstraints(t *testing.T) {
+	h := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+			io.WriteString(w, name+" "+mux.Param(r, "id"))
+		}
+	}
+
+	m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "")))
+	m.HandleFunc("/users/{id}", h("any"))
+	m.HandleFunc("/users/{id:[0-9]+}", h("number"))
+	m.HandleFunc("/users/{id:[a-z]{2}}", h("code"))
+	m.HandleFunc("/users/new", h("new"))
+	m.HandleFunc("/posts/{id:[0-9]+}/edit", h("edit"))
+	m.HandleFunc("/posts/{id}/{action}", h("action"))
+	m.HandleFunc("/tags/{id:a|b}", h("tag"))
+
+	cases := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/users/12", http.StatusTeapot, "number 12"},
+		{"/users/ab", http.StatusTeapot, "code ab"},
+		{"/users/abc", http.StatusTeapot, "any abc"},
+		{"/users/12a", http.StatusTeapot, "any 12a"},
+		{"/users/new", http.StatusTeapot, "new "},
+		{"/posts/12/edit", http.StatusTeapot, "edit 12"},
+		{"/posts/a/edit", http.StatusTeapot, "action a"},
+		{"/tags/a", http.StatusTeapot, "tag a"},
+		{"/tags/ab", http.StatusNotFound, ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, c.path, nil)
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+			resp := rec.Result()
+
+			if resp.StatusCode != c.code {
+				t.Errorf("got StatusCode %d, want %d", resp.StatusCode, c.code)
+			}
+
+			b, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body := string(b)
+			if body != c.body {
+				t.Errorf("got body %q, want %q", body, c.body)
+			}
+		})
+	}
+}
+
+func TestWildcard(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		io.WriteString(w, mux.Param(r, "id")+" "+mux.Param(r, "path")+" "+mux.Param(r, "*"))
+	}
+
+	newMux := func() *mux.Mux {
+		m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "")))
+		m.HandleFunc("/files/{path...}", h)
+		m.HandleFunc("/files/readme", handlerFactory(http.StatusTeapot, "readme"))
+		m.HandleFunc("/users/{id}/*", h)
+		m.HandleFunc("/static/*", h, mux.RouteTrailingSlash(mux.StrictSlash))
+		return m
+	}
+
+	t.Run("green", func(t *testing.T) {
+		cases := []struct {
+			path string
+			code int
+			body string
+		}{
+			{"/files/a", http.StatusTeapot, " a "},
+			{"/files/a/b/c.txt", http.StatusTeapot, " a/b/c.txt "},
+			{"/files/a/", http.StatusTeapot, " a/ "},
+			{"/files/", http.StatusTeapot, "  "},
+			{"/files/readme", http.StatusTeapot, "readme"},
+			{"/files/readme/1", http.StatusTeapot, " readme/1 "},
+			{"/files", http.StatusNotFound, ""},
+			{"/users/12/posts/7", http.StatusTeapot, "12  posts/7"},
+			{"/users/12/", http.StatusTeapot, "12  "},
+			{"/users/12", http.StatusNotFound, ""},
+			{"/users//posts", http.StatusNotFound, ""},
+			{"/static/css/a.css", http.StatusTeapot, "  css/a.css"},
+		}
+
+		for _, c := range cases {
+			t.Run(c.path, func(t *testing.T) {
+				r := httptest.NewRequest(http.MethodGet, c.path, nil)
+				rec := httptest.NewRecorder()
+				newMux().ServeHTTP(rec, r)
+				resp := rec.Result()
+
+				if resp.StatusCode != c.code {
+					t.Errorf("got StatusCode %d, want %d", resp.StatusCode, c.code)
+				}
+
+				b, err := ioutil.ReadAll(resp.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				body := string(b)
+				if body != c.body {
+					t.Errorf("got body %q, want %q", body, c.body)
+				}
+			})
+		}
+	})
+
+	t.Run("red", func(t *testing.T) {
+		patterns := []string{
+			"/files/*/a",
+			"/files/{path...}/a",
+			"/files/{...}",
+			"/files/{a*}",
+		}
+
+		for _, pattern := range patterns {
+			t.Run(pattern, func(t *testing.T) {
+				defer func() {
+					if recover() == nil {
+						t.Error("got no panic, want panic")
+					}
+				}()
+
+				m := mux.New()
+				m.HandleFunc(pattern, handlerFactory(http.StatusTeapot, ""))
+			})
+		}
+	})
+}
+
+func TestParam(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {
+		params := mux.Params(r)
+		params["x"] = "1" // must not change the parameters of r
+		fmt.Fprintf(w, "%s %s %s %d", mux.Param(r, "id"), mux.Param(r, "name"), mux.Param(r, "x"), len(params))
+	}
+
+	m := mux.New()
+	m.HandleFunc("/users/{id}/{name}", h)
+	m.RegexpHandleFunc(`^/posts/(?P<id>[0-9]+)/([a-z]+)$`, h)
+	m.HandleFunc("/", h)
+
+	cases := []struct {
+		path string
+		body string
+	}{
+		{"/users/12/a", "12 a  3"},
+		{"/posts/7/b", "7   2"},
+		{"/", "   1"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, c.path, nil)
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+
+			b, err := ioutil.ReadAll(rec.Result().Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body := string(b)
+			if body != c.body {
+				t.Errorf("got body %q, want %q", body, c.body)
+			}
+		})
+	}
+}
+
+func TestStringContextKeys(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%v %v %s", r.Context().Value("id"), r.Context().Value("name"), mux.Param(r, "id"))
+	}
+
+	cases := []struct {
+		name string
+		opts []mux.Option
+		path string
+		body string
+	}{
+		{"default", nil, "/users/12", "<nil> <nil> 12"},
+		{"default regexp", nil, "/posts/12/a", "<nil> <nil> 12"},
+		{"string keys", []mux.Option{mux.StringContextKeys()}, "/users/12", "12 <nil> 12"},
+		{"string keys regexp", []mux.Option{mux.StringContextKeys()}, "/posts/12/a", "12 a 12"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			m := mux.New(c.opts...)
+			m.HandleFunc("/users/{id}", h)
+			m.RegexpHandleFunc(`^/posts/(?P<id>[0-9]+)/(?P<name>[a-z]+)$`, h)
+
+			r := httptest.NewRequest(http.MethodGet, c.path, nil)
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+
+			b, err := ioutil.ReadAll(rec.Result().Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body := string(b)
+			if body != c.body {
+				t.Errorf("got body %q, want %q", body, c.body)
+			}
+		})
+	}
+}
+
+func BenchmarkParams(b *testing.B) {
+	m := mux.New()
+	m.HandleFunc("/users/{user}/posts/{post}", func(w http.ResponseWriter, r *http.Request) {})
+	m.RegexpHandleFunc(`^/archive/(?P<year>[0-9]{4})/(?P<month>[0-9]{2})$`, func(w http.ResponseWriter, r *http.Request) {})
+
+	for _, path := range []string{"/users/1/posts/2", "/archive/2021/04"} {
+		b.Run(path, func(b *testing.B) {
+			r := httptest.NewRequest(http.MethodGet, path, nil)
+			w := httptest.NewRecorder()
+
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				m.ServeHTTP(w, r)
+			}
+		})
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !go1.22
+// +build !go1.22
+
+package mux
+
+import "net/http"
+
+// setPathValues does nothing as requests have no path values before Go 1.22.
+func setPathValues(r *http.Request, p *params) {}
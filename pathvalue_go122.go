@@ -0,0 +1,14 @@
+//go:build go1.22
+// +build go1.22
+
+package mux
+
+import "net/http"
+
+// setPathValues sets the parameters in p as path values of r, so that
+// handlers can also read them with r.PathValue.
+func setPathValues(r *http.Request, p *params) {
+	for _, param := range p.list {
+		r.SetPathValue(param.name, param.value)
+	}
+}
@@ -0,0 +1,18 @@
+package mux
+
+import "net/http"
+
+// MatchedPattern returns the pattern r was routed to, like "/users/{id}"
+// rather than "/users/12", or "" if r was not routed to a pattern. It gives
+// logging and metrics a route label of low cardinality. Patterns copied by
+// Mount include the mount prefix; requests passed to a handler mounted with
+// MountHandler or MountLive have the pattern of the mounted mux, if any.
+//
+// With Go 1.23 or later, mux sets r.Pattern too, on the request it is given,
+// so middleware added with Use can read the pattern after calling the next
+// handler. With earlier versions, the pattern is only in the context of the
+// request passed to the route, so only route handlers and their middleware
+// see it.
+func MatchedPattern(r *http.Request) string {
+	return requestPattern(r)
+}
@@ -0,0 +1,26 @@
+//go:build !go1.23
+// +build !go1.23
+
+package mux
+
+import (
+	"context"
+	"net/http"
+)
+
+// patternKey is the context key for the pattern a request was routed to.
+type patternKey struct{}
+
+// withPattern returns a handler that calls next with the request marked as
+// routed to pattern.
+func withPattern(r *http.Request, pattern string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		next(w, r.WithContext(context.WithValue(r.Context(), patternKey{}, pattern)))
+	}
+}
+
+// requestPattern returns the pattern r was routed to.
+func requestPattern(r *http.Request) string {
+	pattern, _ := r.Context().Value(patternKey{}).(string)
+	return pattern
+}
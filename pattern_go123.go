@@ -0,0 +1,17 @@
+//go:build go1.23
+// +build go1.23
+
+package mux
+
+import "net/http"
+
+// withPattern returns next for r routed to pattern, setting r.Pattern.
+func withPattern(r *http.Request, pattern string, next http.HandlerFunc) http.HandlerFunc {
+	r.Pattern = pattern
+	return next
+}
+
+// requestPattern returns the pattern r was routed to.
+func requestPattern(r *http.Request) string {
+	return r.Pattern
+}
@@ -0,0 +1,34 @@
+//go:build go1.23
+// +build go1.23
+
+package mux_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestMatchedPatternUse(t *testing.T) {
+	var pattern string
+	m := mux.New()
+	m.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r)
+			pattern = mux.MatchedPattern(r)
+		})
+	})
+	m.HandleFunc("/users/{id}", handlerFactory(http.StatusTeapot, ""))
+
+	r := httptest.NewRequest(http.MethodGet, "/users/12", nil)
+	m.ServeHTTP(httptest.NewRecorder(), r)
+
+	if pattern != "/users/{id}" {
+		t.Errorf("got pattern %q, want %q", pattern, "/users/{id}")
+	}
+	if r.Pattern != "/users/{id}" {
+		t.Errorf("got Pattern %q, want %q", r.Pattern, "/users/{id}")
+	}
+}
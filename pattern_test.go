@@ -0,0 +1,65 @@
+package mux_test
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestMatchedPattern(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, mux.MatchedPattern(r))
+	}
+
+	sub := mux.New()
+	sub.HandleFunc("/{id}", h)
+
+	live := mux.New()
+	live.HandleFunc("/report", h)
+
+	m := mux.New(mux.WithNotFound(h))
+	m.HandleFunc("/users/{id}", h)
+	m.HandleFunc("/about", h, mux.WithMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, mux.MatchedPattern(r)+" ")
+			next.ServeHTTP(w, r)
+		})
+	}))
+	m.RegexpHandleFunc(`^/posts/[0-9]+$`, h)
+	m.Mount("/sub", sub)
+	m.MountLive("/live", live)
+
+	cases := []struct {
+		path string
+		body string
+	}{
+		{"/users/12", "/users/{id}"},
+		{"/about", "/about /about"},
+		{"/posts/7", "^/posts/[0-9]+$"},
+		{"/sub/1", "/sub/{id}"},
+		{"/live/report", "/report"},
+		{"/other", ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, c.path, nil)
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+
+			b, err := ioutil.ReadAll(rec.Result().Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body := string(b)
+			if body != c.body {
+				t.Errorf("got body %q, want %q", body, c.body)
+			}
+		})
+	}
+}
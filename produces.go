@@ -0,0 +1,134 @@
+package mux
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// Produces makes the route one of the handlers of its pattern chosen by the
+// Accept header of the request, for routes producing the given media types,
+// so that a path can be served in several formats by separate handlers:
+//
+//	m.Get("/report", reportJSON, mux.Produces("application/json"))
+//	m.Get("/report", reportCSV, mux.Produces("text/csv"))
+//
+// Among the routes of a pattern with Produces that match the request, the one
+// producing the media type the Accept header gives the highest quality is
+// chosen; ties are broken by how specifically the header names a type and
+// then by registration order, and requests without an Accept header go to the
+// first. If the header accepts none of the types, the route of the pattern
+// without Produces handles the request if there is one, and otherwise the
+// request is answered with 406 Not Acceptable listing the types produced.
+//
+// The Content-Type of the response is set to the chosen type, unless the
+// handler sets another, and Vary includes Accept.
+//
+// Panics if no types are given or a type is not a valid media type.
+func Produces(types ...string) RouteOption {
+	if len(types) == 0 {
+		panic("mux: no media types")
+	}
+	produced := make([]string, len(types))
+	for i, t := range types {
+		mediatype, _, err := mime.ParseMediaType(t)
+		if err != nil || !strings.Contains(mediatype, "/") {
+			panic("mux: invalid media type " + t)
+		}
+		produced[i] = mediatype
+	}
+
+	return func(mux *Mux, e *muxEntry) {
+		e.produces = append(e.produces, produced...)
+		types := e.produces
+		next := e.handler
+		e.handler = func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			addVary(h, "Accept")
+			if h.Get("Content-Type") == "" {
+				h.Set("Content-Type", negotiate(r.Header.Get("Accept"), types...))
+			}
+			next(w, r)
+		}
+	}
+}
+
+// quality returns the highest quality the accept header gives a media type
+// e produces and the specificity it was given with, as acceptQuality does.
+func (e muxEntry) quality(accept string) (float64, int) {
+	if accept == "" {
+		return 1, 0
+	}
+	q, specificity := 0.0, -1
+	for _, t := range e.produces {
+		tq, ts := acceptQuality(accept, t)
+		if tq > q || tq == q && ts > specificity {
+			q, specificity = tq, ts
+		}
+	}
+	return q, specificity
+}
+
+// producing returns the variant among variants with Produces, which must
+// include the first, that accepts r, has a handler for its method and
+// produces the media type r prefers. It reports false if r accepts none of
+// the media types produced.
+func producing(variants []muxEntry, r *http.Request) (muxEntry, bool) {
+	accept := r.Header.Get("Accept")
+	best, bestQ, bestSpecificity := -1, 0.0, -1
+	for i, v := range variants {
+		if len(v.produces) == 0 || !v.matches(r) || v.handlerFor(r.Method) == nil {
+			continue
+		}
+		q, specificity := v.quality(accept)
+		if q > bestQ || q == bestQ && q > 0 && specificity > bestSpecificity {
+			best, bestQ, bestSpecificity = i, q, specificity
+		}
+	}
+	if best < 0 {
+		return muxEntry{}, false
+	}
+	return variants[best], true
+}
+
+// notAcceptable returns the variant of e answering r with 406 Not
+// Acceptable, listing the media types the variants of e produce.
+func (e muxEntry) notAcceptable() muxEntry {
+	var types []string
+	for _, v := range e.variants {
+		types = append(types, v.produces...)
+	}
+	list := strings.Join(types, ", ")
+
+	v := e
+	v.variants, v.methods = nil, nil
+	v.handler = func(w http.ResponseWriter, r *http.Request) {
+		addVary(w.Header(), "Accept")
+		http.Error(w, http.StatusText(http.StatusNotAcceptable)+", available: "+list, http.StatusNotAcceptable)
+	}
+	return v
+}
+
+// varyAccept returns e with its handlers adding Accept to the Vary header of
+// the response, for e handling requests that variants with Produces do not.
+func (e muxEntry) varyAccept() muxEntry {
+	e.variants = nil
+	return e.mapHandlers(func(h http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			addVary(w.Header(), "Accept")
+			h(w, r)
+		}
+	})
+}
+
+// addVary adds the header name to the Vary header of h unless it is there.
+func addVary(h http.Header, name string) {
+	for _, v := range h.Values("Vary") {
+		for _, field := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(field), name) {
+				return
+			}
+		}
+	}
+	h.Add("Vary", name)
+}
@@ -0,0 +1,77 @@
+package mux_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestProduces(t *testing.T) {
+	m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found")))
+	m.Get("/report", handlerFactory(http.StatusOK, "json"), mux.Produces("application/json"))
+	m.Get("/report", handlerFactory(http.StatusOK, "csv"), mux.Produces("text/csv"))
+	m.Get("/page", handlerFactory(http.StatusOK, "json page"), mux.Produces("application/json"))
+	m.Get("/page", handlerFactory(http.StatusOK, "html page"))
+	m.Get("/typed", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		io.WriteString(w, "typed")
+	}, mux.Produces("text/plain"))
+
+	cases := []struct {
+		path        string
+		accept      string
+		code        int
+		body        string
+		contentType string
+	}{
+		{"/report", "", http.StatusOK, "json", "application/json"},
+		{"/report", "text/csv", http.StatusOK, "csv", "text/csv"},
+		{"/report", "application/json;q=0.5, text/csv;q=0.8", http.StatusOK, "csv", "text/csv"},
+		{"/report", "text/*;q=0.5, application/json;q=0.5", http.StatusOK, "json", "application/json"},
+		{"/report", "*/*", http.StatusOK, "json", "application/json"},
+		{"/report", "text/html", http.StatusNotAcceptable, "Not Acceptable, available: application/json, text/csv\n", "text/plain; charset=utf-8"},
+		{"/page", "application/json", http.StatusOK, "json page", "application/json"},
+		{"/page", "text/html", http.StatusOK, "html page", ""},
+		{"/typed", "text/plain", http.StatusOK, "typed", "text/plain; charset=utf-8"},
+	}
+	for _, c := range cases {
+		t.Run(c.path+" "+c.accept, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, c.path, nil)
+			r.Header.Set("Accept", c.accept)
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+			if rec.Code != c.code {
+				t.Errorf("got StatusCode %d, want %d", rec.Code, c.code)
+			}
+			if body := rec.Body.String(); body != c.body {
+				t.Errorf("got body %q, want %q", body, c.body)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != c.contentType {
+				t.Errorf("got Content-Type %q, want %q", ct, c.contentType)
+			}
+			if c.path != "/typed" && rec.Header().Get("Vary") != "Accept" {
+				t.Errorf("got Vary %q, want %q", rec.Header().Get("Vary"), "Accept")
+			}
+		})
+	}
+
+	t.Run("POST", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/report", nil))
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("got StatusCode %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("got no panic, want panic")
+			}
+		}()
+		mux.Produces("json")
+	})
+}
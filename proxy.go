@@ -0,0 +1,126 @@
+package mux
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+)
+
+// ProxyOption configures the reverse proxy set up by Proxy.
+type ProxyOption func(*proxy)
+
+// PreserveHost makes the proxy send the Host header of the incoming request
+// to the target instead of the host of the target URL.
+func PreserveHost() ProxyOption {
+	return func(p *proxy) {
+		p.preserveHost = true
+	}
+}
+
+// ProxyHeader makes the proxy set the request header name to value before
+// passing requests to the target, or remove it if value is empty.
+func ProxyHeader(name, value string) ProxyOption {
+	return func(p *proxy) {
+		p.headers = append(p.headers, [2]string{name, value})
+	}
+}
+
+// ProxyErrorHandler sets the handler called when the target can not be
+// reached or its response can not be read. The default handler logs the
+// error, with the logger set by WithSlog if any, and replies with 502 Bad
+// Gateway, in the format preferred by the client if the mux uses
+// NegotiatedErrors.
+func ProxyErrorHandler(handler func(w http.ResponseWriter, r *http.Request, err error)) ProxyOption {
+	return func(p *proxy) {
+		p.errorHandler = handler
+	}
+}
+
+// proxy is the configuration of a reverse proxy set up by Proxy.
+type proxy struct {
+	preserveHost bool
+	headers      [][2]string // request headers set, or removed if empty, in order
+	errorHandler func(http.ResponseWriter, *http.Request, error)
+}
+
+// Proxy routes requests whose path is prefix or begins with prefix followed
+// by "/" to the target URL through an httputil.ReverseProxy, with prefix
+// stripped from the path and the path of target prepended, so that for
+// prefix "/api" and target "http://backend:8080/v1" a request for
+// "/api/users" is passed on as "/v1/users". Prefix is mounted like with
+// MountHandler, so patterns of mux take precedence.
+//
+// The proxy sets the X-Forwarded-For, X-Forwarded-Host and X-Forwarded-Proto
+// headers, and X-Forwarded-Prefix to the prefix stripped from the path.
+//
+// Panics if prefix does not begin with "/" or ends with "/", if a handler is
+// already mounted at prefix or if target is not an absolute URL.
+func (mux *Mux) Proxy(prefix string, target *url.URL, opts ...ProxyOption) {
+	if target == nil || target.Scheme == "" || target.Host == "" {
+		panic("mux: proxy target must be an absolute URL")
+	}
+
+	p := new(proxy)
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	rp := httputil.NewSingleHostReverseProxy(target)
+	director := rp.Director
+	rp.Director = func(r *http.Request) {
+		host := r.Host
+		prefix, _ := r.Context().Value(prefixKey{}).(string)
+		director(r)
+		if !p.preserveHost {
+			r.Host = target.Host
+		}
+		proto := "http"
+		if r.TLS != nil {
+			proto = "https"
+		}
+		r.Header.Set("X-Forwarded-Host", host)
+		r.Header.Set("X-Forwarded-Proto", proto)
+		r.Header.Set("X-Forwarded-Prefix", prefix)
+		for _, h := range p.headers {
+			if h[1] == "" {
+				r.Header.Del(h[0])
+			} else {
+				r.Header.Set(h[0], h[1])
+			}
+		}
+	}
+	rp.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		if p.errorHandler != nil {
+			p.errorHandler(w, r, err)
+			return
+		}
+		t := mux.load()
+		if !errors.Is(err, context.Canceled) {
+			if t.log != nil {
+				t.log(r.Context(), levelError, "mux: proxy error",
+					"method", r.Method,
+					"url", r.URL.String(),
+					"error", err,
+				)
+			} else {
+				log.Printf("mux: proxy error for %s %s: %v", r.Method, r.URL, err)
+			}
+		}
+		// Reply for the path as requested, not as passed on.
+		in := r.Context().Value(proxyRequestKey{}).(*http.Request)
+		prefix, _ := in.Context().Value(prefixKey{}).(string)
+		in = in.Clone(in.Context())
+		in.URL.Path, in.URL.RawPath = prefix+in.URL.Path, ""
+		t.replyError(w, in, http.StatusBadGateway)
+	}
+	mux.MountHandler(prefix, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rp.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), proxyRequestKey{}, r)))
+	}))
+}
+
+// proxyRequestKey is the context key for the request passed to the reverse
+// proxy of Proxy.
+type proxyRequestKey struct{}
@@ -0,0 +1,124 @@
+package mux_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestProxy(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %s host=%s prefix=%s key=%s cookie=%s",
+			r.Method,
+			r.URL.RequestURI(),
+			r.Host,
+			r.Header.Get("X-Forwarded-Prefix"),
+			r.Header.Get("X-Api-Key"),
+			r.Header.Get("Cookie"),
+		)
+	}))
+	defer backend.Close()
+
+	target, err := url.Parse(backend.URL + "/v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	backendHost := target.Host
+
+	m := mux.New()
+	m.Proxy("/api", target, mux.ProxyHeader("X-Api-Key", "secret"), mux.ProxyHeader("Cookie", ""))
+	m.Proxy("/host", target, mux.PreserveHost())
+	m.HandleFunc("/api/health", handlerFactory(http.StatusTeapot, "ok"))
+
+	cases := []struct {
+		method     string
+		path       string
+		statusCode int
+		body       string
+	}{
+		{http.MethodGet, "/api/users?page=2", http.StatusOK, "GET /v1/users?page=2 host=" + backendHost + " prefix=/api key=secret cookie="},
+		{http.MethodPost, "/api", http.StatusOK, "POST /v1/ host=" + backendHost + " prefix=/api key=secret cookie="},
+		{http.MethodGet, "/host/a", http.StatusOK, "GET /v1/a host=example.com prefix=/host key= cookie=a=b"},
+		{http.MethodGet, "/api/health", http.StatusTeapot, "ok"},
+	}
+	for _, c := range cases {
+		t.Run(c.method+" "+c.path, func(t *testing.T) {
+			r := httptest.NewRequest(c.method, c.path, nil)
+			r.Header.Set("Cookie", "a=b")
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+			resp := rec.Result()
+
+			if resp.StatusCode != c.statusCode {
+				t.Errorf("got StatusCode %d, want %d", resp.StatusCode, c.statusCode)
+			}
+
+			b, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body := string(b)
+			if body != c.body {
+				t.Errorf("got body %q, want %q", body, c.body)
+			}
+		})
+	}
+
+	t.Run("error", func(t *testing.T) {
+		closed := httptest.NewServer(http.NotFoundHandler())
+		closed.Close()
+		target, err := url.Parse(closed.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var gotErr error
+		m := mux.New(mux.NegotiatedErrors())
+		m.Proxy("/default", target)
+		m.Proxy("/custom", target, mux.ProxyErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+			gotErr = err
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+
+		r := httptest.NewRequest(http.MethodGet, "/default/a", nil)
+		r.Header.Set("Accept", "application/json")
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, r)
+		if rec.Code != http.StatusBadGateway {
+			t.Errorf("got StatusCode %d, want %d", rec.Code, http.StatusBadGateway)
+		}
+		if want := `{"error":"bad gateway","path":"/default/a"}`; rec.Body.String() != want {
+			t.Errorf("got body %q, want %q", rec.Body.String(), want)
+		}
+
+		rec = httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/custom/a", nil))
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("got StatusCode %d, want %d", rec.Code, http.StatusServiceUnavailable)
+		}
+		if gotErr == nil {
+			t.Error("got no error, want error")
+		}
+	})
+
+	t.Run("red", func(t *testing.T) {
+		targets := []*url.URL{nil, {Path: "/v1"}}
+		for _, target := range targets {
+			t.Run(fmt.Sprint(target), func(t *testing.T) {
+				defer func() {
+					if recover() == nil {
+						t.Error("got no panic, want panic")
+					}
+				}()
+
+				mux.New().Proxy("/api", target)
+			})
+		}
+	})
+}
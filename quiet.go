@@ -0,0 +1,32 @@
+package mux
+
+import (
+	"context"
+	"net/http"
+)
+
+// quietKey is the context key marking requests routed to Quiet routes.
+type quietKey struct{}
+
+// Quiet marks the route as one that access logging and metrics should leave
+// out, such as a health endpoint polled every few seconds.
+func Quiet() RouteOption {
+	return func(mux *Mux, e *muxEntry) {
+		e.quiet = true
+	}
+}
+
+// markQuiet returns a handler that calls next with the request marked as
+// routed to a Quiet route.
+func markQuiet(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		next(w, r.WithContext(context.WithValue(r.Context(), quietKey{}, true)))
+	}
+}
+
+// IsQuiet reports whether r was routed to a Quiet route. Logging and metrics
+// middleware wrapping route handlers can use it to skip such requests.
+func IsQuiet(r *http.Request) bool {
+	quiet, _ := r.Context().Value(quietKey{}).(bool)
+	return quiet
+}
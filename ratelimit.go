@@ -0,0 +1,159 @@
+package mux
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimit configures a token bucket rate limiter. Each client key has a
+// bucket of Burst tokens, refilled at Rate tokens per second, and every
+// request takes a token. Requests finding the bucket of their key empty are
+// passed to Exceeded with the Retry-After header set.
+type RateLimit struct {
+	Rate  float64 // tokens added per second
+	Burst int     // bucket size, the number of requests allowed at once
+
+	// Key returns the key of the client making the request, such as
+	// ByRemoteIP or ByHeader("X-Api-Key"). Requests with the same key share
+	// a bucket. If nil, all requests share one bucket.
+	Key func(r *http.Request) string
+
+	// Exceeded handles requests over the limit. If nil, they are answered
+	// with 429 Too Many Requests.
+	Exceeded http.HandlerFunc
+}
+
+// RateLimited limits the rate of requests to the route's handler, with
+// buckets of its own. Requests of each method of a route registered with
+// Method have their own buckets.
+//
+// Panics if Rate is not positive or Burst is less than 1.
+func RateLimited(l RateLimit) RouteOption {
+	l.validate()
+	return func(mux *Mux, e *muxEntry) {
+		e.handler = newLimiter(l).wrap(e.handler)
+	}
+}
+
+// UseRateLimit adds a middleware, as with Use, limiting the rate of all
+// requests mux serves, including those answered by notFound.
+//
+// Panics if Rate is not positive or Burst is less than 1.
+func (mux *Mux) UseRateLimit(l RateLimit) {
+	l.validate()
+	lim := newLimiter(l)
+	mux.Use(func(next http.Handler) http.Handler {
+		return lim.wrap(next.ServeHTTP)
+	})
+}
+
+// ByRemoteIP returns the IP address of the client, as returned by ClientIP,
+// or else r.RemoteAddr, for use as RateLimit.Key. Clients behind proxies
+// trusted with TrustProxies are told apart by the address the proxies
+// forwarded.
+func ByRemoteIP(r *http.Request) string {
+	if ip := ClientIP(r); ip != "" {
+		return ip
+	}
+	return r.RemoteAddr
+}
+
+// ByHeader returns a RateLimit.Key returning the value of the request header
+// name, such as an API key.
+func ByHeader(name string) func(r *http.Request) string {
+	return func(r *http.Request) string {
+		return r.Header.Get(name)
+	}
+}
+
+func (l RateLimit) validate() {
+	if !(l.Rate > 0) {
+		panic("mux: rate limit rate must be positive")
+	}
+	if l.Burst < 1 {
+		panic("mux: rate limit burst must be at least 1")
+	}
+}
+
+// bucketsSweepInterval is how often a limiter drops the buckets of idle
+// clients.
+const bucketsSweepInterval = time.Minute
+
+// limiter limits the rate of requests by client key.
+type limiter struct {
+	RateLimit
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time // time buckets were last swept
+}
+
+// bucket is the token bucket of a client key.
+type bucket struct {
+	tokens float64
+	last   time.Time // time tokens were last updated
+}
+
+func newLimiter(l RateLimit) *limiter {
+	if l.Exceeded == nil {
+		l.Exceeded = tooManyRequests
+	}
+	return &limiter{RateLimit: l, buckets: make(map[string]*bucket), swept: time.Now()}
+}
+
+// wrap returns a handler limiting the rate of requests to next.
+func (l *limiter) wrap(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var key string
+		if l.Key != nil {
+			key = l.Key(r)
+		}
+		if wait, ok := l.take(key, time.Now()); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			l.Exceeded(w, r)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// take takes a token from the bucket of key at time now and reports whether
+// there was one. If not, it returns how long until there is.
+func (l *limiter) take(key string, now time.Time) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.swept) >= bucketsSweepInterval {
+		// Full buckets are as good as new ones.
+		for k, b := range l.buckets {
+			if b.refill(now, l.Rate, l.Burst) >= float64(l.Burst) {
+				delete(l.buckets, k)
+			}
+		}
+		l.swept = now
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.Burst), last: now}
+		l.buckets[key] = b
+	}
+	if b.refill(now, l.Rate, l.Burst) < 1 {
+		return time.Duration((1 - b.tokens) / l.Rate * float64(time.Second)), false
+	}
+	b.tokens--
+	return 0, true
+}
+
+// refill adds the tokens accumulated since b was last updated, up to burst,
+// and returns the tokens in b.
+func (b *bucket) refill(now time.Time, rate float64, burst int) float64 {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(float64(burst), b.tokens+elapsed.Seconds()*rate)
+		b.last = now
+	}
+	return b.tokens
+}
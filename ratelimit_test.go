@@ -0,0 +1,102 @@
+package mux_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestRateLimited(t *testing.T) {
+	m := mux.New()
+	m.HandleFunc("/a", handlerFactory(http.StatusTeapot, ""), mux.RateLimited(mux.RateLimit{
+		Rate:  1,
+		Burst: 2,
+		Key:   mux.ByHeader("X-Api-Key"),
+	}))
+	m.HandleFunc("/b", handlerFactory(http.StatusTeapot, ""), mux.RateLimited(mux.RateLimit{
+		Rate:  1,
+		Burst: 1,
+		Exceeded: func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		},
+	}))
+	m.HandleFunc("/c", handlerFactory(http.StatusTeapot, ""))
+
+	cases := []struct {
+		path       string
+		key        string
+		statusCode int
+		retryAfter string
+	}{
+		{"/a", "k1", http.StatusTeapot, ""},
+		{"/a", "k1", http.StatusTeapot, ""},
+		{"/a", "k1", http.StatusTooManyRequests, "1"},
+		{"/a", "k2", http.StatusTeapot, ""},
+		{"/b", "k1", http.StatusTeapot, ""},
+		{"/b", "k2", http.StatusServiceUnavailable, "1"},
+		{"/c", "k1", http.StatusTeapot, ""},
+		{"/c", "k1", http.StatusTeapot, ""},
+		{"/c", "k1", http.StatusTeapot, ""},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest(http.MethodGet, c.path, nil)
+		r.Header.Set("X-Api-Key", c.key)
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, r)
+
+		if rec.Code != c.statusCode {
+			t.Errorf("%s %s: got StatusCode %d, want %d", c.path, c.key, rec.Code, c.statusCode)
+		}
+		if retryAfter := rec.Header().Get("Retry-After"); retryAfter != c.retryAfter {
+			t.Errorf("%s %s: got Retry-After %q, want %q", c.path, c.key, retryAfter, c.retryAfter)
+		}
+	}
+
+	t.Run("red", func(t *testing.T) {
+		limits := []mux.RateLimit{
+			{Rate: 0, Burst: 1},
+			{Rate: -1, Burst: 1},
+			{Rate: 1, Burst: 0},
+		}
+		for _, l := range limits {
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Errorf("%+v: got no panic, want panic", l)
+					}
+				}()
+
+				mux.RateLimited(l)
+			}()
+		}
+	})
+}
+
+func TestUseRateLimit(t *testing.T) {
+	m := mux.New()
+	m.UseRateLimit(mux.RateLimit{Rate: 1, Burst: 1, Key: mux.ByRemoteIP})
+	m.HandleFunc("/a", handlerFactory(http.StatusTeapot, ""))
+
+	cases := []struct {
+		path       string
+		remoteAddr string
+		statusCode int
+	}{
+		{"/a", "192.0.2.1:1234", http.StatusTeapot},
+		{"/missing", "192.0.2.1:1235", http.StatusTooManyRequests},
+		{"/missing", "192.0.2.2:1234", http.StatusNotFound},
+		{"/a", "192.0.2.2:1234", http.StatusTooManyRequests},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest(http.MethodGet, c.path, nil)
+		r.RemoteAddr = c.remoteAddr
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, r)
+
+		if rec.Code != c.statusCode {
+			t.Errorf("%s %s: got StatusCode %d, want %d", c.path, c.remoteAddr, rec.Code, c.statusCode)
+		}
+	}
+}
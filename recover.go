@@ -0,0 +1,54 @@
+package mux
+
+import (
+	"log"
+	"net/http"
+	"runtime/debug"
+)
+
+// WithRecovery returns an Option that makes mux recover from panics while
+// serving a request, in handlers as well as in middleware. The panic value
+// and stack trace are logged with the log package, or the logger set by
+// WithSlog, and handler is called to reply, unless the panic value is
+// http.ErrAbortHandler, which is repanicked to abort the response as usual. A
+// nil handler replies with 500 Internal Server Error.
+//
+// The handler can not reply if the response has already been written to,
+// in which case the client gets what was written before the panic.
+func WithRecovery(handler func(w http.ResponseWriter, r *http.Request, recovered interface{})) Option {
+	if handler == nil {
+		handler = internalServerError
+	}
+	return func(mux *Mux) {
+		mux.recovery = handler
+	}
+}
+
+// recoverPanic recovers from a panic serving r, if any, and calls the recovery
+// handler of t. It must be called directly by a deferred function call.
+func (t *table) recoverPanic(w http.ResponseWriter, r *http.Request) {
+	v := recover()
+	if v == nil {
+		return
+	}
+	if v == http.ErrAbortHandler {
+		panic(v)
+	}
+
+	if t.log != nil {
+		t.log(r.Context(), levelError, "mux: panic",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"panic", v,
+			"stack", string(debug.Stack()),
+		)
+	} else {
+		log.Printf("mux: panic serving %s %s: %v\n%s", r.Method, r.URL.Path, v, debug.Stack())
+	}
+	t.recovery(w, r, v)
+}
+
+// internalServerError replies with 500 Internal Server Error.
+func internalServerError(w http.ResponseWriter, r *http.Request, recovered interface{}) {
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
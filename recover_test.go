@@ -0,0 +1,92 @@
+package mux_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestWithRecovery(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	panicking := func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}
+	custom := func(w http.ResponseWriter, r *http.Request, recovered interface{}) {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, "recovered %v", recovered)
+	}
+
+	cases := []struct {
+		name       string
+		handler    func(http.ResponseWriter, *http.Request, interface{})
+		middleware bool
+		body       string
+	}{
+		{"default", nil, false, "Internal Server Error\n"},
+		{"custom", custom, false, "recovered boom"},
+		{"middleware", custom, true, "recovered boom"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			logs.Reset()
+			m := mux.New(mux.WithRecovery(c.handler))
+			if c.middleware {
+				m.Use(func(next http.Handler) http.Handler {
+					return http.HandlerFunc(panicking)
+				})
+				m.HandleFunc("/", handlerFactory(http.StatusTeapot, ""))
+			} else {
+				m.HandleFunc("/", panicking)
+			}
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+			resp := rec.Result()
+
+			if resp.StatusCode != http.StatusInternalServerError {
+				t.Errorf("got StatusCode %d, want %d", resp.StatusCode, http.StatusInternalServerError)
+			}
+
+			b, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body := string(b)
+			if body != c.body {
+				t.Errorf("got body %q, want %q", body, c.body)
+			}
+
+			if !strings.Contains(logs.String(), "mux: panic serving GET /: boom") {
+				t.Errorf("got log %q, want panic logged", logs.String())
+			}
+		})
+	}
+
+	t.Run("ErrAbortHandler", func(t *testing.T) {
+		defer func() {
+			if v := recover(); v != http.ErrAbortHandler {
+				t.Errorf("got panic %v, want %v", v, http.ErrAbortHandler)
+			}
+		}()
+
+		m := mux.New(mux.WithRecovery(nil))
+		m.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		})
+		m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+}
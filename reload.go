@@ -0,0 +1,92 @@
+package mux
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"time"
+)
+
+// ReloadConfig builds the routes described by the JSON configuration file
+// name, as NewFromConfig with the options opts does, and swaps them in with
+// Swap, so that requests in flight finish on the old routes. Pass the options
+// mux was created with that change how patterns are registered, like
+// RedirectLowercase. Call it on a reload signal, like SIGHUP, or from an
+// admin endpoint. If the file can not be read or its configuration is
+// invalid, mux keeps its routes and the error is returned.
+func (mux *Mux) ReloadConfig(name string, reg Registry, opts ...Option) error {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	return mux.reloadConfig(b, reg, opts)
+}
+
+// reloadConfig swaps in the routes described by the JSON configuration b.
+func (mux *Mux) reloadConfig(b []byte, reg Registry, opts []Option) error {
+	c, err := ParseConfig(bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	newMux, err := NewFromConfig(c, reg, opts...)
+	if err != nil {
+		return err
+	}
+	mux.Swap(newMux)
+	return nil
+}
+
+// WatchConfig loads the JSON configuration file name like ReloadConfig and
+// then checks it every interval, reloading it whenever its contents change,
+// until ctx is done, when it returns ctx.Err(). It returns the error of the
+// first load, so that a broken configuration stops the program from starting;
+// later errors are logged, if mux has a logger, and mux keeps the routes it
+// has until the file is fixed. Contents that failed to load are not loaded
+// again until they change.
+//
+// Panics if interval is not positive.
+func (mux *Mux) WatchConfig(ctx context.Context, name string, reg Registry, interval time.Duration, opts ...Option) error {
+	if interval <= 0 {
+		panic("mux: non-positive interval")
+	}
+
+	loaded, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	if err := mux.reloadConfig(loaded, reg, opts); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var failed []byte   // contents that failed to load, not retried
+	var readFailed bool // whether the last read failed, logged once
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		b, err := os.ReadFile(name)
+		if err != nil {
+			if !readFailed {
+				mux.load().logf(ctx, levelError, "mux: config reload failed", "file", name, "error", err)
+			}
+			readFailed = true
+			continue
+		}
+		readFailed = false
+		if bytes.Equal(b, loaded) || failed != nil && bytes.Equal(b, failed) {
+			continue
+		}
+		if err := mux.reloadConfig(b, reg, opts); err != nil {
+			mux.load().logf(ctx, levelError, "mux: config reload failed", "file", name, "error", err)
+			failed = b
+			continue
+		}
+		mux.load().logf(ctx, levelInfo, "mux: config reloaded", "file", name)
+		loaded, failed = b, nil
+	}
+}
@@ -0,0 +1,106 @@
+package mux_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/touchmarine/mux"
+)
+
+// serveBody returns the body of the response of m to a GET request to path.
+func serveBody(m *mux.Mux, path string) string {
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec.Body.String()
+}
+
+func TestReloadConfig(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "routes.json")
+	write := func(config string) {
+		if err := os.WriteFile(name, []byte(config), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found")))
+	write(`{"routes": [{"pattern": "/x", "handler": "a"}]}`)
+	if err := m.ReloadConfig(name, configRegistry()); err != nil {
+		t.Fatal(err)
+	}
+	if body := serveBody(m, "/x"); body != "a" {
+		t.Errorf("got body %q, want %q", body, "a")
+	}
+
+	write(`{"routes": [{"pattern": "/x", "handler": "c"}]}`)
+	if err := m.ReloadConfig(name, configRegistry()); err == nil {
+		t.Error("got no error, want error")
+	}
+	if body := serveBody(m, "/x"); body != "a" {
+		t.Errorf("got body %q after failed reload, want %q", body, "a")
+	}
+
+	if err := m.ReloadConfig(filepath.Join(t.TempDir(), "missing.json"), configRegistry()); err == nil {
+		t.Error("got no error for missing file, want error")
+	}
+}
+
+func TestWatchConfig(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "routes.json")
+	write := func(config string) {
+		if err := os.WriteFile(name, []byte(config), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// waitFor waits for m to serve want at path.
+	waitFor := func(m *mux.Mux, path, want string) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for serveBody(m, path) != want {
+			if time.Now().After(deadline) {
+				t.Fatalf("got body %q, want %q", serveBody(m, path), want)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found")))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+
+	write(`{"routes": [{"pattern": "/x", "handler": "a"}]}`)
+	go func() {
+		done <- m.WatchConfig(ctx, name, configRegistry(), time.Millisecond)
+	}()
+	waitFor(m, "/x", "a")
+
+	write(`{"routes": [{"pattern": "/x", "handler": "b"}, {"pattern": "/y", "handler": "a"}]}`)
+	waitFor(m, "/x", "b")
+	waitFor(m, "/y", "a")
+
+	write(`{"routes": [{"pattern": "/x", "handler": "c"}]}`)
+	time.Sleep(20 * time.Millisecond)
+	if body := serveBody(m, "/x"); body != "b" {
+		t.Errorf("got body %q after invalid config, want %q", body, "b")
+	}
+
+	write(`{"routes": [{"pattern": "/z", "handler": "a"}]}`)
+	waitFor(m, "/z", "a")
+	waitFor(m, "/x", "not found")
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("got error %v, want %v", err, context.Canceled)
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		write(`{"routes": [{"pattern": "/x", "handler": "c"}]}`)
+		if err := m.WatchConfig(context.Background(), name, configRegistry(), time.Millisecond); err == nil {
+			t.Error("got no error, want error")
+		}
+	})
+}
@@ -0,0 +1,78 @@
+package mux
+
+import "net/http"
+
+// Deregister removes the routes registered for pattern, for all methods and
+// including those registered with Match, and reports whether there were any.
+// The pattern is given as it was registered, with HandleFunc, Method or
+// RegexpHandleFunc, or as it was copied by Mount. Requests in flight keep
+// being served by the removed handlers.
+func (mux *Mux) Deregister(pattern string) bool {
+	mux.lock()
+	defer mux.unlock()
+
+	key, ok := mux.registered(pattern)
+	if !ok {
+		return false
+	}
+	mux.forget(key)
+
+	delete(mux.m, key)
+	mux.patterns = remove(mux.patterns, key)
+	mux.regexps = remove(mux.regexps, key)
+	mux.tree = newTree(mux.patterns, mux.m)
+	return true
+}
+
+// Replace registers the handler function for pattern like HandleFunc, but
+// replaces the routes registered for pattern, for all methods and including
+// those registered with Match, instead of panicking. The replaced routes keep
+// their place in the registration order, and a regexp pattern stays one, so
+// the precedence of pattern is unchanged. The old and new routes are swapped
+// atomically: every request is served by one or the other.
+func (mux *Mux) Replace(pattern string, handler http.HandlerFunc, opts ...RouteOption) {
+	mux.lock()
+	defer mux.unlock()
+
+	e := muxEntry{handler: handler}
+	if key, ok := mux.registered(pattern); ok {
+		e.regexp = mux.m[key].regexp
+		mux.forget(key)
+	}
+	mux.add("", pattern, e, true, opts...)
+}
+
+// registered returns the key of the entry for pattern as given to a
+// registration method of the locked mux and reports whether there is one.
+func (mux *Mux) registered(pattern string) (string, bool) {
+	if e, ok := mux.m[pattern]; ok && e.exactPath {
+		return pattern, true
+	}
+	for _, isRegexp := range []bool{false, true} {
+		key := mux.normalizePattern(pattern, isRegexp)
+		if e, ok := mux.m[key]; ok && e.regexp == isRegexp {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// forget removes the route names of the locked mux naming key.
+func (mux *Mux) forget(key string) {
+	for name, pattern := range mux.names {
+		if pattern == key {
+			delete(mux.names, name)
+		}
+	}
+}
+
+// remove returns s without the first occurrence of v. The backing array of s
+// is not modified.
+func remove(s []string, v string) []string {
+	for i, x := range s {
+		if x == v {
+			return append(s[:i:i], s[i+1:]...)
+		}
+	}
+	return s
+}
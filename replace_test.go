@@ -0,0 +1,121 @@
+package mux_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestDeregister(t *testing.T) {
+	m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found")), mux.RedirectLowercase())
+	m.HandleFunc("/users/{id}", handlerFactory(http.StatusTeapot, "user"), mux.Name("user"))
+	m.Get("/users/new", handlerFactory(http.StatusTeapot, "new"))
+	m.Post("/users/new", handlerFactory(http.StatusTeapot, "create"))
+	m.RegexpHandleFunc(`^/posts/[0-9]+$`, handlerFactory(http.StatusTeapot, "post"))
+	m.HandleFunc("/About", handlerFactory(http.StatusTeapot, "about"))
+
+	for _, pattern := range []string{"/users/new", `^/posts/[0-9]+$`, "/About"} {
+		if !m.Deregister(pattern) {
+			t.Errorf("%s: got false, want true", pattern)
+		}
+	}
+	if m.Deregister("/users/new") {
+		t.Error("got true for deregistered pattern, want false")
+	}
+	if m.Deregister("/none") {
+		t.Error("got true for unregistered pattern, want false")
+	}
+
+	cases := []struct {
+		method string
+		path   string
+		code   int
+		body   string
+	}{
+		{http.MethodGet, "/users/new", http.StatusTeapot, "user"},
+		{http.MethodPost, "/users/new", http.StatusTeapot, "user"},
+		{http.MethodGet, "/users/1", http.StatusTeapot, "user"},
+		{http.MethodGet, "/posts/1", http.StatusNotFound, "not found"},
+		{http.MethodGet, "/about", http.StatusNotFound, "not found"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.method+" "+c.path, func(t *testing.T) {
+			r := httptest.NewRequest(c.method, c.path, nil)
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+			resp := rec.Result()
+
+			if resp.StatusCode != c.code {
+				t.Errorf("got StatusCode %d, want %d", resp.StatusCode, c.code)
+			}
+
+			b, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body := string(b)
+			if body != c.body {
+				t.Errorf("got body %q, want %q", body, c.body)
+			}
+		})
+	}
+
+	t.Run("names", func(t *testing.T) {
+		m.Deregister("/users/{id}")
+		if _, err := m.URL("user", "id", "1"); err == nil {
+			t.Error("got no error for deregistered route, want error")
+		}
+		m.HandleFunc("/people/{id}", handlerFactory(http.StatusTeapot, ""), mux.Name("user"))
+	})
+}
+
+func TestReplace(t *testing.T) {
+	m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found")))
+	m.RegexpHandleFunc(`^/a.*$`, handlerFactory(http.StatusTeapot, "first"))
+	m.RegexpHandleFunc(`^/ab$`, handlerFactory(http.StatusTeapot, "second"))
+	m.Get("/users", handlerFactory(http.StatusTeapot, "get users"))
+	m.Post("/users", handlerFactory(http.StatusTeapot, "post users"))
+
+	m.Replace(`^/a.*$`, handlerFactory(http.StatusTeapot, "first replaced"))
+	m.Replace("/users", handlerFactory(http.StatusTeapot, "users replaced"), mux.Name("users"))
+	m.Replace("/users", handlerFactory(http.StatusTeapot, "users replaced again"), mux.Name("users"))
+	m.Replace("/new", handlerFactory(http.StatusTeapot, "new"))
+
+	cases := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{http.MethodGet, "/ab", "first replaced"},
+		{http.MethodGet, "/users", "users replaced again"},
+		{http.MethodPost, "/users", "users replaced again"},
+		{http.MethodGet, "/new", "new"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.method+" "+c.path, func(t *testing.T) {
+			r := httptest.NewRequest(c.method, c.path, nil)
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+
+			b, err := ioutil.ReadAll(rec.Result().Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body := string(b)
+			if body != c.body {
+				t.Errorf("got body %q, want %q", body, c.body)
+			}
+		})
+	}
+
+	if u, err := m.URL("users"); err != nil || u != "/users" {
+		t.Errorf("got URL %q, %v, want %q", u, err, "/users")
+	}
+}
@@ -0,0 +1,69 @@
+package mux
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// RequestIDHeader is the header carrying request IDs.
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDKey is the context key for the ID of a request.
+type requestIDKey struct{}
+
+// WithRequestID returns an Option that makes mux give each request an ID,
+// returned by RequestID and set in the X-Request-ID header of the response.
+// The ID is taken from the X-Request-ID header of the request, so that it
+// can be followed across services, or generated if the request has none or
+// one longer than 128 bytes or with other than printable ASCII characters.
+// Requests passed on from another mux giving IDs keep theirs.
+func WithRequestID() Option {
+	return func(mux *Mux) {
+		mux.requestID = true
+	}
+}
+
+// RequestID returns the ID of r or "" if r was not served by a mux with
+// WithRequestID.
+func RequestID(r *http.Request) string {
+	id, _ := r.Context().Value(requestIDKey{}).(string)
+	return id
+}
+
+// withRequestID returns r with its ID in its context and sets the ID in the
+// header of w.
+func withRequestID(w http.ResponseWriter, r *http.Request) *http.Request {
+	if RequestID(r) != "" {
+		return r
+	}
+	id := r.Header.Get(RequestIDHeader)
+	if !validRequestID(id) {
+		id = newRequestID()
+	}
+	w.Header().Set(RequestIDHeader, id)
+	return r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id))
+}
+
+// validRequestID reports whether id, taken from a request, is used as is.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID returns a random request ID of 32 hex digits.
+func newRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic("mux: generating request ID: " + err.Error())
+	}
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,79 @@
+package mux_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestRequestID(t *testing.T) {
+	var got string
+	record := func(w http.ResponseWriter, r *http.Request) {
+		got = mux.RequestID(r)
+	}
+	m := mux.New(mux.WithNotFound(record), mux.WithRequestID())
+	m.HandleFunc("/", record)
+	inner := mux.New(mux.WithRequestID())
+	inner.HandleFunc("/a", record)
+	m.MountLive("/inner", inner)
+
+	cases := []struct {
+		name   string
+		path   string
+		header string
+		want   string // "" if generated
+	}{
+		{"generated", "/", "", ""},
+		{"not found", "/missing", "", ""},
+		{"propagated", "/", "abc-123", "abc-123"},
+		{"invalid", "/", "a\x7fb", ""},
+		{"too long", "/", strings.Repeat("a", 129), ""},
+		{"nested", "/inner/a", "outer", "outer"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got = ""
+			r := httptest.NewRequest(http.MethodGet, c.path, nil)
+			if c.header != "" {
+				r.Header.Set(mux.RequestIDHeader, c.header)
+			}
+			w := httptest.NewRecorder()
+			m.ServeHTTP(w, r)
+
+			if c.want != "" && got != c.want {
+				t.Errorf("got RequestID %q, want %q", got, c.want)
+			}
+			if c.want == "" && (len(got) != 32 || got == c.header) {
+				t.Errorf("got RequestID %q, want generated", got)
+			}
+			if h := w.Header().Values(mux.RequestIDHeader); len(h) != 1 || h[0] != got {
+				t.Errorf("got %s %q, want %q", mux.RequestIDHeader, h, got)
+			}
+		})
+	}
+}
+
+func TestRequestIDOnRequest(t *testing.T) {
+	var info mux.RequestInfo
+	m := mux.New(mux.WithRequestID(), mux.OnRequest(func(i mux.RequestInfo) {
+		info = i
+	}))
+	m.HandleFunc("/", handlerFactory(http.StatusOK, "ok"))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(mux.RequestIDHeader, "abc")
+	m.ServeHTTP(httptest.NewRecorder(), r)
+	if info.RequestID != "abc" {
+		t.Errorf("got RequestID %q, want abc", info.RequestID)
+	}
+}
+
+func TestRequestIDWithout(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if id := mux.RequestID(r); id != "" {
+		t.Errorf("got RequestID %q, want empty", id)
+	}
+}
@@ -0,0 +1,125 @@
+package mux
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+)
+
+// ResponseWriter is an http.ResponseWriter recording the status and size of
+// the response written through it, returned by WrapResponseWriter.
+//
+// It implements http.Flusher, http.Hijacker, http.Pusher and io.ReaderFrom
+// by calling the underlying ResponseWriter, so middleware using it does not
+// hide them from handlers. If the underlying ResponseWriter does not
+// implement one of them, Flush does nothing, Hijack returns an error, Push
+// returns http.ErrNotSupported and ReadFrom copies with Write.
+type ResponseWriter interface {
+	http.ResponseWriter
+	http.Flusher
+	http.Hijacker
+	http.Pusher
+	io.ReaderFrom
+
+	// Status returns the status code of the response, 0 if not written
+	// yet, or 101 Switching Protocols if the connection was hijacked
+	// before it was.
+	Status() int
+
+	// BytesWritten returns the number of bytes of the body written.
+	BytesWritten() int64
+
+	// Unwrap returns the underlying ResponseWriter, for
+	// http.ResponseController.
+	Unwrap() http.ResponseWriter
+}
+
+// WrapResponseWriter returns a ResponseWriter writing to w, for middleware
+// to record the response written by the handlers they wrap.
+func WrapResponseWriter(w http.ResponseWriter) ResponseWriter {
+	return &responseWriter{ResponseWriter: w}
+}
+
+// responseWriter is the ResponseWriter returned by WrapResponseWriter.
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (rw *responseWriter) WriteHeader(code int) {
+	if rw.status == 0 && (code >= http.StatusOK || code == http.StatusSwitchingProtocols) {
+		rw.status = code
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytes += int64(n)
+	return n, err
+}
+
+func (rw *responseWriter) Status() int {
+	return rw.status
+}
+
+func (rw *responseWriter) BytesWritten() int64 {
+	return rw.bytes
+}
+
+func (rw *responseWriter) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		if rw.status == 0 {
+			rw.status = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := rw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("mux: ResponseWriter does not implement http.Hijacker")
+	}
+	conn, brw, err := h.Hijack()
+	if err == nil && rw.status == 0 {
+		rw.status = http.StatusSwitchingProtocols
+	}
+	return conn, brw, err
+}
+
+func (rw *responseWriter) Push(target string, opts *http.PushOptions) error {
+	if p, ok := rw.ResponseWriter.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+func (rw *responseWriter) ReadFrom(r io.Reader) (int64, error) {
+	rf, ok := rw.ResponseWriter.(io.ReaderFrom)
+	if !ok {
+		return io.Copy(writerOnly{rw}, r)
+	}
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	n, err := rf.ReadFrom(r)
+	rw.bytes += n
+	return n, err
+}
+
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// writerOnly hides the methods of a Writer other than Write, so that io.Copy
+// to it does not call its ReadFrom.
+type writerOnly struct {
+	io.Writer
+}
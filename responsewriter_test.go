@@ -0,0 +1,156 @@
+package mux_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestWrapResponseWriter(t *testing.T) {
+	cases := []struct {
+		name   string
+		write  func(w mux.ResponseWriter)
+		status int
+		bytes  int64
+		body   string
+	}{
+		{
+			"none",
+			func(w mux.ResponseWriter) {},
+			0,
+			0,
+			"",
+		},
+		{
+			"write",
+			func(w mux.ResponseWriter) {
+				io.WriteString(w, "hello")
+			},
+			http.StatusOK,
+			5,
+			"hello",
+		},
+		{
+			"write header",
+			func(w mux.ResponseWriter) {
+				w.WriteHeader(http.StatusCreated)
+				w.WriteHeader(http.StatusAccepted)
+				io.WriteString(w, "created")
+			},
+			http.StatusCreated,
+			7,
+			"created",
+		},
+		{
+			"read from",
+			func(w mux.ResponseWriter) {
+				w.ReadFrom(strings.NewReader("copied"))
+			},
+			http.StatusOK,
+			6,
+			"copied",
+		},
+		{
+			"flush",
+			func(w mux.ResponseWriter) {
+				w.Flush()
+			},
+			http.StatusOK,
+			0,
+			"",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			w := mux.WrapResponseWriter(rec)
+			c.write(w)
+
+			if got := w.Status(); got != c.status {
+				t.Errorf("got Status %d, want %d", got, c.status)
+			}
+			if got := w.BytesWritten(); got != c.bytes {
+				t.Errorf("got BytesWritten %d, want %d", got, c.bytes)
+			}
+			if got := rec.Body.String(); got != c.body {
+				t.Errorf("got body %q, want %q", got, c.body)
+			}
+			if w.Unwrap() != rec {
+				t.Error("got Unwrap not returning the ResponseWriter wrapped")
+			}
+		})
+	}
+
+	t.Run("unsupported", func(t *testing.T) {
+		w := mux.WrapResponseWriter(httptest.NewRecorder())
+		if _, _, err := w.Hijack(); err == nil {
+			t.Error("got no Hijack error, want error")
+		}
+		if err := w.Push("/style.css", nil); !errors.Is(err, http.ErrNotSupported) {
+			t.Errorf("got Push error %v, want %v", err, http.ErrNotSupported)
+		}
+		if got := w.Status(); got != 0 {
+			t.Errorf("got Status %d, want 0", got)
+		}
+	})
+}
+
+func TestBuiltinWritersKeepInterfaces(t *testing.T) {
+	check := func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := w.(http.Flusher); !ok {
+			t.Errorf("%s %s: got ResponseWriter not implementing http.Flusher", r.Method, r.URL.Path)
+		}
+		if _, ok := w.(http.Hijacker); !ok {
+			t.Errorf("%s %s: got ResponseWriter not implementing http.Hijacker", r.Method, r.URL.Path)
+		}
+		if _, ok := w.(http.Pusher); !ok {
+			t.Errorf("%s %s: got ResponseWriter not implementing http.Pusher", r.Method, r.URL.Path)
+		}
+		rf, ok := w.(io.ReaderFrom)
+		if !ok {
+			t.Errorf("%s %s: got ResponseWriter not implementing io.ReaderFrom", r.Method, r.URL.Path)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		rf.ReadFrom(bytes.NewReader(bytes.Repeat([]byte("a"), 2048)))
+	}
+
+	m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found")), mux.OnRequest(func(mux.RequestInfo) {}))
+	m.UseCompression(mux.Compression{})
+	m.UseETags(false)
+	m.Get("/", check, mux.Cached(mux.Cache{TTL: time.Minute}))
+
+	for _, method := range []string{http.MethodGet, http.MethodHead} {
+		r := httptest.NewRequest(method, "/", nil)
+		r.Header.Set("Accept-Encoding", "gzip")
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, r)
+		if method == http.MethodHead {
+			continue
+		}
+
+		if got := rec.Header().Get("Content-Encoding"); got != "gzip" {
+			t.Fatalf("got Content-Encoding %q, want gzip", got)
+		}
+		zr, err := gzip.NewReader(rec.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(zr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(body) != 2048 {
+			t.Errorf("got body of %d bytes, want 2048", len(body))
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package mux
+
+import (
+	"net/http"
+	"sort"
+)
+
+// Route describes a route registered on a mux.
+type Route struct {
+	// Prefix is the path prefix the mux of the route is mounted at, relative
+	// to the mux walked, or "" for the routes of the mux itself. It is only
+	// set by Walk.
+	Prefix string
+
+	Pattern     string
+	Regexp      bool // whether Pattern is a regular expression
+	Conditional bool // whether the route was registered with Match
+
+	// Methods are the methods the route has handlers for, sorted, or nil if
+	// it was only registered for all methods.
+	Methods []string
+
+	// Handler is the handler for methods not in Methods or nil if there is
+	// none.
+	Handler http.Handler
+
+	// Middleware are the names of the functions added with WithMiddleware,
+	// outermost first.
+	Middleware []string
+
+	// Meta are the values attached with Meta by key and Tags the tags
+	// attached with Tags, or nil if there are none. Meta is a copy and may be
+	// modified.
+	Meta map[string]interface{}
+	Tags []string
+
+	// Formats are the extensions of paths the route also matches, added
+	// with Formats, or nil if there are none.
+	Formats []string
+
+	methods map[string]http.HandlerFunc
+}
+
+// HandlerFor returns the handler of the route for the given method or nil if
+// the route has none. Handlers are returned as they are served, wrapped by
+// the options and middleware of the route.
+func (rt Route) HandlerFor(method string) http.Handler {
+	if h, ok := rt.methods[method]; ok {
+		return h
+	}
+	return rt.Handler
+}
+
+// Routes returns the routes registered on mux, including those copied by
+// Mount, in registration order. A pattern registered with Match has a route
+// for each registration with Match, after the route without, if any.
+func (mux *Mux) Routes() []Route {
+	t := mux.load()
+	routes := make([]Route, 0, len(t.patterns))
+	for _, pattern := range t.patterns {
+		e := t.m[pattern]
+		for _, v := range append([]muxEntry{e}, e.variants...) {
+			if v.handler == nil && len(v.methods) == 0 {
+				continue
+			}
+			routes = append(routes, newRoute(pattern, v))
+		}
+	}
+	return routes
+}
+
+// newRoute returns the route of the entry e registered for pattern.
+func newRoute(pattern string, e muxEntry) Route {
+	rt := Route{Pattern: pattern, Regexp: e.regexp, Conditional: len(e.matchers) > 0, Middleware: append([]string(nil), e.middleware...), methods: e.methods}
+	if e.handler != nil {
+		rt.Handler = e.handler
+	}
+	if e.meta != nil && len(e.meta.values) > 0 {
+		rt.Meta = make(map[string]interface{}, len(e.meta.values))
+		for key, value := range e.meta.values {
+			rt.Meta[key] = value
+		}
+	}
+	if e.meta != nil && len(e.meta.tags) > 0 {
+		rt.Tags = append([]string(nil), e.meta.tags...)
+	}
+	if len(e.formats) > 0 {
+		rt.Formats = append([]string(nil), e.formats...)
+	}
+	for method := range e.methods {
+		rt.Methods = append(rt.Methods, method)
+	}
+	sort.Strings(rt.Methods)
+	return rt
+}
+
+// Match returns the route a request with the given method and path, which
+// may have a query, is routed to and its path parameters and named regexp
+// submatches, without calling any handler, so that tests and tools can check
+// routing decisions directly. Requests routed into the inner mux of Locales or
+// into muxes mounted with MountLive or set as fallback are followed into it.
+// Match reports false if the request is not routed to a route, such as when
+// it is redirected or answered with 405 Method Not Allowed.
+//
+// Route matchers added with Match see a request without headers.
+func (mux *Mux) Match(method, path string) (Route, map[string]string, bool) {
+	r, err := http.NewRequest(method, path, nil)
+	if err != nil {
+		return Route{}, nil, false
+	}
+	ex := mux.Explain(r)
+	for ex.Inner != nil && ex.Outcome != OutcomeMatched {
+		ex = *ex.Inner
+	}
+	if ex.Outcome != OutcomeMatched || ex.route == nil {
+		return Route{}, nil, false
+	}
+	params := ex.Params
+	if params == nil {
+		params = make(map[string]string)
+	}
+	return *ex.route, params, true
+}
+
+// Walk calls fn for the routes of mux, in registration order, and then for
+// those of the muxes mounted with MountLive or MountHandler, longest prefix
+// first, descending into their mounts in turn. Walk stops and returns the
+// error if fn returns one.
+//
+// fn is called without holding any lock of the muxes, so it may register
+// routes; whether Walk visits them is unspecified.
+func (mux *Mux) Walk(fn func(route Route) error) error {
+	return mux.walk("", fn)
+}
+
+// walk is Walk for mux mounted at prefix.
+func (mux *Mux) walk(prefix string, fn func(route Route) error) error {
+	routes := mux.Routes()
+
+	var mounts []mount
+	for _, m := range mux.load().mounts {
+		if _, ok := m.handler.(*Mux); ok {
+			mounts = append(mounts, m)
+		}
+	}
+
+	for _, rt := range routes {
+		rt.Prefix = prefix
+		if err := fn(rt); err != nil {
+			return err
+		}
+	}
+	for _, m := range mounts {
+		if err := m.handler.(*Mux).walk(prefix+m.prefix, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package mux_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestRoutes(t *testing.T) {
+	sub := mux.New()
+	sub.HandleFunc("/report", handlerFactory(http.StatusTeapot, "report"))
+
+	m := mux.New()
+	m.HandleFunc("/", handlerFactory(http.StatusTeapot, "index"))
+	m.Get("/users/{id}", handlerFactory(http.StatusTeapot, "get user"))
+	m.Delete("/users/{id}", handlerFactory(http.StatusTeapot, "delete user"))
+	m.RegexpHandleFunc(`^/posts/[0-9]+$`, handlerFactory(http.StatusTeapot, "post"))
+	m.Mount("/sub", sub)
+
+	var got []string
+	for _, rt := range m.Routes() {
+		got = append(got, fmt.Sprintf("%s %t %v %t", rt.Pattern, rt.Regexp, rt.Methods, rt.Handler != nil))
+	}
+	want := []string{
+		"/ false [] true",
+		"/users/{id} false [DELETE GET] false",
+		"^/posts/[0-9]+$ true [] true",
+		"/sub/report false [] true",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got routes %q, want %q", got, want)
+	}
+
+	rt := m.Routes()[1]
+	rec := httptest.NewRecorder()
+	rt.HandlerFor(http.MethodDelete).ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/users/1", nil))
+	if body := rec.Body.String(); body != "delete user" {
+		t.Errorf("got body %q, want %q", body, "delete user")
+	}
+	if h := rt.HandlerFor(http.MethodPost); h != nil {
+		t.Error("got POST handler, want nil")
+	}
+}
+
+func TestWalk(t *testing.T) {
+	inner := mux.New()
+	inner.HandleFunc("/c", handlerFactory(http.StatusTeapot, ""))
+
+	live := mux.New()
+	live.HandleFunc("/b", handlerFactory(http.StatusTeapot, ""))
+	live.MountLive("/inner", inner)
+
+	m := mux.New()
+	m.HandleFunc("/a", handlerFactory(http.StatusTeapot, ""))
+	m.MountLive("/live", live)
+	m.MountHandler("/static", http.FileServer(http.Dir(".")))
+
+	var got []string
+	err := m.Walk(func(rt mux.Route) error {
+		got = append(got, rt.Prefix+" "+rt.Pattern)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{" /a", "/live /b", "/live/inner /c"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got routes %q, want %q", got, want)
+	}
+
+	t.Run("error", func(t *testing.T) {
+		errStop := errors.New("stop")
+		var n int
+		err := m.Walk(func(rt mux.Route) error {
+			n++
+			if strings.HasPrefix(rt.Prefix, "/live") {
+				return errStop
+			}
+			return nil
+		})
+		if err != errStop {
+			t.Errorf("got error %v, want %v", err, errStop)
+		}
+		if n != 2 {
+			t.Errorf("got %d calls, want 2", n)
+		}
+	})
+}
+
+func TestMuxMatch(t *testing.T) {
+	sub := mux.New()
+	sub.HandleFunc("/posts/{post}", handlerFactory(http.StatusTeapot, "post"))
+
+	m := mux.New()
+	m.Get("/users/{id}", handlerFactory(http.StatusTeapot, "user"))
+	m.RegexpHandleFunc(`^/files/(?P<name>.+)$`, handlerFactory(http.StatusTeapot, "file"))
+	m.HandleFunc("/", handlerFactory(http.StatusTeapot, "index"))
+	m.MountLive("/blog", sub)
+
+	cases := []struct {
+		method  string
+		path    string
+		ok      bool
+		pattern string
+		params  map[string]string
+	}{
+		{http.MethodGet, "/users/1", true, "/users/{id}", map[string]string{"id": "1"}},
+		{http.MethodHead, "/users/1?x=y", true, "/users/{id}", map[string]string{"id": "1"}},
+		{http.MethodPost, "/users/1", false, "", nil},
+		{http.MethodGet, "/users/1/", false, "", nil},
+		{http.MethodGet, "/files/a/b.txt", true, `^/files/(?P<name>.+)$`, map[string]string{"name": "a/b.txt"}},
+		{http.MethodGet, "/", true, "/", map[string]string{}},
+		{http.MethodGet, "/blog/posts/hello", true, "/posts/{post}", map[string]string{"post": "hello"}},
+		{http.MethodGet, "/blog/missing", false, "", nil},
+		{http.MethodGet, "/missing", false, "", nil},
+	}
+	for _, c := range cases {
+		t.Run(c.method+" "+c.path, func(t *testing.T) {
+			rt, params, ok := m.Match(c.method, c.path)
+			if ok != c.ok {
+				t.Fatalf("got ok %t, want %t", ok, c.ok)
+			}
+			if rt.Pattern != c.pattern {
+				t.Errorf("got Pattern %q, want %q", rt.Pattern, c.pattern)
+			}
+			if !reflect.DeepEqual(params, c.params) {
+				t.Errorf("got params %v, want %v", params, c.params)
+			}
+			if ok && rt.HandlerFor(c.method) == nil && rt.HandlerFor(http.MethodGet) == nil {
+				t.Error("got no handler")
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package mux_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	embed := mux.DefaultSecurityHeaders()
+	embed.FrameOptions = "SAMEORIGIN"
+	embed.ContentSecurityPolicy = ""
+
+	m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found")))
+	m.UseSecurityHeaders(mux.DefaultSecurityHeaders())
+	m.Get("/", handlerFactory(http.StatusOK, "home"))
+	m.Get("/embed", handlerFactory(http.StatusOK, "embed"), mux.RouteSecurityHeaders(embed))
+
+	cases := []struct {
+		path         string
+		frameOptions string
+		csp          string
+		nosniff      string
+	}{
+		{"/", "DENY", "default-src 'self'; frame-ancestors 'none'", "nosniff"},
+		{"/missing", "DENY", "default-src 'self'; frame-ancestors 'none'", "nosniff"},
+		{"/embed", "SAMEORIGIN", "", "nosniff"},
+	}
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, c.path, nil))
+			h := rec.Header()
+			if got := h.Get("X-Frame-Options"); got != c.frameOptions {
+				t.Errorf("got X-Frame-Options %q, want %q", got, c.frameOptions)
+			}
+			if got := h.Get("Content-Security-Policy"); got != c.csp {
+				t.Errorf("got Content-Security-Policy %q, want %q", got, c.csp)
+			}
+			if got := h.Get("X-Content-Type-Options"); got != c.nosniff {
+				t.Errorf("got X-Content-Type-Options %q, want %q", got, c.nosniff)
+			}
+			if got, want := h.Get("Strict-Transport-Security"), "max-age=31536000; includeSubDomains"; got != want {
+				t.Errorf("got Strict-Transport-Security %q, want %q", got, want)
+			}
+			if got, want := h.Get("Referrer-Policy"), "strict-origin-when-cross-origin"; got != want {
+				t.Errorf("got Referrer-Policy %q, want %q", got, want)
+			}
+			if _, ok := h["Permissions-Policy"]; ok {
+				t.Error("got Permissions-Policy, want none")
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package mux
+
+// SlashPolicy tells how requests whose path matches a pattern only with its
+// trailing slash removed, like "/a/" for the pattern "/a", are handled.
+type SlashPolicy int
+
+const (
+	// RedirectTrailingSlash redirects the request to the path without the
+	// trailing slash with 308 Permanent Redirect or the code set by
+	// RedirectCode. It is the default.
+	RedirectTrailingSlash SlashPolicy = iota + 1
+	// StrictSlash does not match the request, so it is usually answered by
+	// notFound.
+	StrictSlash
+	// IgnoreTrailingSlash serves the request with the handler of the
+	// pattern, without changing its path.
+	IgnoreTrailingSlash
+)
+
+// TrailingSlash sets the policy for requests with a trailing slash for all
+// routes of the mux that do not set their own with RouteTrailingSlash.
+//
+// Panics if policy is not one of the SlashPolicy constants.
+func TrailingSlash(policy SlashPolicy) Option {
+	checkSlashPolicy(policy)
+	return func(mux *Mux) {
+		mux.slash = policy
+	}
+}
+
+// RouteTrailingSlash sets the policy for requests with a trailing slash for
+// the route.
+//
+// Panics if policy is not one of the SlashPolicy constants.
+func RouteTrailingSlash(policy SlashPolicy) RouteOption {
+	checkSlashPolicy(policy)
+	return func(mux *Mux, e *muxEntry) {
+		e.slash = policy
+	}
+}
+
+// checkSlashPolicy panics if policy is not one of the SlashPolicy constants.
+func checkSlashPolicy(policy SlashPolicy) {
+	if policy < RedirectTrailingSlash || policy > IgnoreTrailingSlash {
+		panic("mux: invalid SlashPolicy")
+	}
+}
@@ -0,0 +1,92 @@
+package mux_test
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestTrailingSlash(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		io.WriteString(w, r.URL.Path+" "+mux.Param(r, "id"))
+	}
+
+	newMux := func(opts ...mux.Option) *mux.Mux {
+		m := mux.New(append([]mux.Option{mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found"))}, opts...)...)
+		m.HandleFunc("/a", h)
+		m.HandleFunc("/users/{id}", h)
+		m.RegexpHandleFunc(`^/posts/(?P<id>[0-9]+)$`, h)
+		m.HandleFunc("/strict", h, mux.RouteTrailingSlash(mux.StrictSlash))
+		m.HandleFunc("/ignore", h, mux.RouteTrailingSlash(mux.IgnoreTrailingSlash))
+		return m
+	}
+
+	cases := []struct {
+		name     string
+		opts     []mux.Option
+		path     string
+		code     int
+		body     string
+		location string
+	}{
+		{"default", nil, "/a/", http.StatusPermanentRedirect, "", "/a"},
+		{"default route strict", nil, "/strict/", http.StatusNotFound, "not found", ""},
+		{"default route ignore", nil, "/ignore/", http.StatusTeapot, "/ignore/ ", ""},
+		{"redirect", []mux.Option{mux.TrailingSlash(mux.RedirectTrailingSlash)}, "/users/1/", http.StatusPermanentRedirect, "", "/users/1"},
+		{"strict", []mux.Option{mux.TrailingSlash(mux.StrictSlash)}, "/a/", http.StatusNotFound, "not found", ""},
+		{"strict params", []mux.Option{mux.TrailingSlash(mux.StrictSlash)}, "/users/1/", http.StatusNotFound, "not found", ""},
+		{"strict regexp", []mux.Option{mux.TrailingSlash(mux.StrictSlash)}, "/posts/1/", http.StatusNotFound, "not found", ""},
+		{"strict without slash", []mux.Option{mux.TrailingSlash(mux.StrictSlash)}, "/a", http.StatusTeapot, "/a ", ""},
+		{"ignore", []mux.Option{mux.TrailingSlash(mux.IgnoreTrailingSlash)}, "/a/", http.StatusTeapot, "/a/ ", ""},
+		{"ignore params", []mux.Option{mux.TrailingSlash(mux.IgnoreTrailingSlash)}, "/users/1/", http.StatusTeapot, "/users/1/ 1", ""},
+		{"ignore regexp", []mux.Option{mux.TrailingSlash(mux.IgnoreTrailingSlash)}, "/posts/1/", http.StatusTeapot, "/posts/1/ 1", ""},
+		{"ignore route strict", []mux.Option{mux.TrailingSlash(mux.IgnoreTrailingSlash)}, "/strict/", http.StatusNotFound, "not found", ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, c.path, nil)
+			rec := httptest.NewRecorder()
+			newMux(c.opts...).ServeHTTP(rec, r)
+			resp := rec.Result()
+
+			if resp.StatusCode != c.code {
+				t.Errorf("got StatusCode %d, want %d", resp.StatusCode, c.code)
+			}
+
+			location := resp.Header.Get("Location")
+			if location != c.location {
+				t.Errorf("got Location %q, want %q", location, c.location)
+			}
+
+			if c.location != "" {
+				return
+			}
+
+			b, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body := string(b)
+			if body != c.body {
+				t.Errorf("got body %q, want %q", body, c.body)
+			}
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("got no panic, want panic")
+			}
+		}()
+
+		mux.TrailingSlash(0)
+	})
+}
@@ -0,0 +1,26 @@
+//go:build go1.21
+// +build go1.21
+
+package mux
+
+import (
+	"context"
+	"log/slog"
+)
+
+// WithSlog returns an Option that makes mux log its diagnostics to logger:
+// conflicting registrations, before panicking, and patterns and mounted
+// handlers shadowing one another at Warn level, redirects to canonical URLs
+// at Debug level and recovered panics, instead of with the log package, at
+// Error level.
+func WithSlog(logger *slog.Logger) Option {
+	return func(mux *Mux) {
+		if logger == nil {
+			mux.log = nil
+			return
+		}
+		mux.log = func(ctx context.Context, level logLevel, msg string, args ...interface{}) {
+			logger.Log(ctx, slog.Level(level), msg, args...)
+		}
+	}
+}
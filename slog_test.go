@@ -0,0 +1,96 @@
+//go:build go1.21
+// +build go1.21
+
+package mux_test
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestWithSlog(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey || a.Key == "stack" {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+
+	m := mux.New(mux.WithSlog(logger), mux.WithRecovery(nil))
+	m.MountHandler("/static", http.NotFoundHandler())
+	m.HandleFunc("/static/app.js", handlerFactory(http.StatusTeapot, ""))
+	m.HandleFunc("/a", handlerFactory(http.StatusTeapot, ""))
+	m.RegexpHandleFunc("^/[a-z]$", handlerFactory(http.StatusTeapot, ""))
+	m.HandleFunc("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	func() {
+		defer func() {
+			recover()
+		}()
+		m.HandleFunc("/a", handlerFactory(http.StatusTeapot, ""))
+	}()
+
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/a/", nil))
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	want := []string{
+		`level=WARN msg="mux: pattern shadows mounted handler" pattern=/static/app.js prefix=/static`,
+		`level=WARN msg="mux: overlapping patterns" pattern=/a shadowed=^/[a-z]$ path=/a`,
+		`level=ERROR msg="mux: conflicting registration" method="" pattern=/a`,
+		`level=DEBUG msg="mux: redirect" method=GET path=/a/ location=/a status=308 reason="trailing slash"`,
+		`level=ERROR msg="mux: panic" method=GET path=/panic panic=boom`,
+	}
+	got := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(got) != len(want) {
+		t.Fatalf("got %d records, want %d:\n%s", len(got), len(want), buf.String())
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got record\n%s\nwant\n%s", got[i], want[i])
+		}
+	}
+}
+
+func TestWithSlogProxy(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey || a.Key == "error" {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+
+	backend := httptest.NewServer(http.NotFoundHandler())
+	target, err := url.Parse(backend.URL + "/v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend.Close()
+
+	m := mux.New(mux.WithSlog(logger))
+	m.Proxy("/api", target)
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/users", nil))
+
+	if rec.Code != http.StatusBadGateway {
+		t.Errorf("got StatusCode %d, want %d", rec.Code, http.StatusBadGateway)
+	}
+	want := `level=ERROR msg="mux: proxy error" method=GET url=` + target.String() + `/users`
+	if got := strings.TrimSpace(buf.String()); got != want {
+		t.Errorf("got record\n%s\nwant\n%s", got, want)
+	}
+}
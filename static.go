@@ -0,0 +1,75 @@
+package mux
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// HandleStatic registers a handler that replies to every request with the
+// given status code, header and body, prepared at registration so that
+// serving it does no work beyond writing. Content-Length is set from body.
+// If header has an ETag, requests with a matching If-None-Match are answered
+// with 304 Not Modified.
+//
+// header and body are copied, so changing them afterwards does not change the
+// responses. Handlers must not modify the header values or body they are
+// given, like those of wrapping middleware.
+func (mux *Mux) HandleStatic(pattern string, status int, header http.Header, body []byte, opts ...RouteOption) {
+	full := make(http.Header, len(header)+1)
+	for k, vv := range header {
+		for _, v := range vv {
+			full.Add(k, v)
+		}
+	}
+	full.Set("Content-Length", strconv.Itoa(len(body)))
+
+	notModified := make(http.Header, len(full))
+	for k, vv := range full {
+		full[k] = vv[:len(vv):len(vv)] // appending must not write to shared memory
+		switch k {
+		case "Content-Type", "Content-Length", "Content-Encoding", "Content-Language":
+		default:
+			notModified[k] = full[k]
+		}
+	}
+
+	body = append([]byte(nil), body...)
+	etag := full.Get("ETag")
+
+	mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		if etag != "" && etagMatch(r.Header.Get("If-None-Match"), etag) {
+			for k, vv := range notModified {
+				h[k] = vv
+			}
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		for k, vv := range full {
+			h[k] = vv
+		}
+		w.WriteHeader(status)
+		w.Write(body)
+	}, opts...)
+}
+
+// etagMatch reports whether the If-None-Match header value inm matches etag
+// using the weak comparison.
+func etagMatch(inm, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for inm != "" {
+		var tag string
+		if i := strings.IndexByte(inm, ','); i >= 0 {
+			tag, inm = inm[:i], inm[i+1:]
+		} else {
+			tag, inm = inm, ""
+		}
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
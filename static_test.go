@@ -0,0 +1,168 @@
+package mux_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestHandleStatic(t *testing.T) {
+	newMux := func() *mux.Mux {
+		header := http.Header{
+			"content-type": {"application/json"},
+			"ETag":         {`"v1"`},
+		}
+		body := []byte(`{"version":"1.0.0"}`)
+
+		m := mux.New()
+		m.HandleStatic("/version", http.StatusOK, header, body)
+		m.HandleStatic("/maintenance", http.StatusServiceUnavailable, nil, []byte("down for maintenance"))
+
+		// must not affect responses
+		header.Set("ETag", `"v2"`)
+		body[0] = '['
+
+		return m
+	}
+
+	t.Run("green", func(t *testing.T) {
+		cases := []struct {
+			path          string
+			code          int
+			contentType   string
+			contentLength string
+			etag          string
+			body          string
+		}{
+			{
+				"/version",
+				http.StatusOK,
+				"application/json",
+				"19",
+				`"v1"`,
+				`{"version":"1.0.0"}`,
+			},
+			{
+				"/maintenance",
+				http.StatusServiceUnavailable,
+				"",
+				"20",
+				"",
+				"down for maintenance",
+			},
+		}
+
+		for _, c := range cases {
+			t.Run(c.path, func(t *testing.T) {
+				r := httptest.NewRequest(http.MethodGet, c.path, nil)
+				rec := httptest.NewRecorder()
+				newMux().ServeHTTP(rec, r)
+				resp := rec.Result()
+
+				if resp.StatusCode != c.code {
+					t.Errorf("got StatusCode %d, want %d", resp.StatusCode, c.code)
+				}
+
+				if contentType := resp.Header.Get("Content-Type"); contentType != c.contentType {
+					t.Errorf("got Content-Type %q, want %q", contentType, c.contentType)
+				}
+
+				if contentLength := resp.Header.Get("Content-Length"); contentLength != c.contentLength {
+					t.Errorf("got Content-Length %q, want %q", contentLength, c.contentLength)
+				}
+
+				if etag := resp.Header.Get("ETag"); etag != c.etag {
+					t.Errorf("got ETag %q, want %q", etag, c.etag)
+				}
+
+				b, err := ioutil.ReadAll(resp.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				body := string(b)
+				if body != c.body {
+					t.Errorf("got body %q, want %q", body, c.body)
+				}
+			})
+		}
+	})
+
+	t.Run("If-None-Match", func(t *testing.T) {
+		cases := []struct {
+			ifNoneMatch string
+			code        int
+		}{
+			{`"v1"`, http.StatusNotModified},
+			{`W/"v1"`, http.StatusNotModified},
+			{`"v0", "v1"`, http.StatusNotModified},
+			{"*", http.StatusNotModified},
+			{`"v2"`, http.StatusOK},
+		}
+
+		for _, c := range cases {
+			t.Run(c.ifNoneMatch, func(t *testing.T) {
+				r := httptest.NewRequest(http.MethodGet, "/version", nil)
+				r.Header.Set("If-None-Match", c.ifNoneMatch)
+				rec := httptest.NewRecorder()
+				newMux().ServeHTTP(rec, r)
+				resp := rec.Result()
+
+				if resp.StatusCode != c.code {
+					t.Errorf("got StatusCode %d, want %d", resp.StatusCode, c.code)
+				}
+
+				if c.code == http.StatusNotModified {
+					if contentLength := resp.Header.Get("Content-Length"); contentLength != "" {
+						t.Errorf("got Content-Length %q, want none", contentLength)
+					}
+					if etag := resp.Header.Get("ETag"); etag != `"v1"` {
+						t.Errorf("got ETag %q, want %q", etag, `"v1"`)
+					}
+				}
+			})
+		}
+	})
+
+	t.Run("shared header", func(t *testing.T) {
+		m := newMux()
+
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/version", nil))
+		rec.Header().Add("ETag", `"changed"`)
+
+		rec = httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/version", nil))
+
+		if etags := rec.Header()["Etag"]; len(etags) != 1 || etags[0] != `"v1"` {
+			t.Errorf("got ETag %q, want %q", etags, `"v1"`)
+		}
+	})
+}
+
+// discardWriter is a http.ResponseWriter that reuses its header and discards
+// everything written.
+type discardWriter struct {
+	header http.Header
+}
+
+func (w *discardWriter) Header() http.Header         { return w.header }
+func (w *discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *discardWriter) WriteHeader(statusCode int)  {}
+
+func BenchmarkHandleStatic(b *testing.B) {
+	m := mux.New()
+	m.HandleFunc("/a", handlerFactory(http.StatusTeapot, "a"))
+	m.HandleStatic("/version", http.StatusOK, http.Header{"Content-Type": {"text/plain"}}, []byte("1.0.0"))
+
+	r := httptest.NewRequest(http.MethodGet, "/version", nil)
+	w := &discardWriter{header: make(http.Header)}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		m.ServeHTTP(w, r)
+	}
+}
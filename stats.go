@@ -0,0 +1,135 @@
+package mux
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// statsSamples is the number of latest durations latency quantiles are
+// computed from.
+const statsSamples = 1000
+
+// RouteStats are the stats of a route of a mux tracking them.
+type RouteStats struct {
+	Pattern string `json:"pattern"`
+	Hits    uint64 `json:"hits"`   // requests served
+	Errors  uint64 `json:"errors"` // requests answered with a 5xx status code
+
+	// Latency quantiles of the latest 1000 requests.
+	P50 time.Duration `json:"p50"`
+	P90 time.Duration `json:"p90"`
+	P99 time.Duration `json:"p99"`
+	Max time.Duration `json:"max"`
+}
+
+// stats are the stats of the routes of a mux.
+type stats struct {
+	mu     sync.Mutex
+	routes map[string]*routeStats
+}
+
+// routeStats are the stats of a route.
+type routeStats struct {
+	hits, errors uint64
+	samples      []time.Duration // ring buffer of the latest durations
+	next         int             // index in samples of the next duration
+}
+
+// TrackStats returns an Option that makes mux count the requests routed to
+// each pattern and keep their latencies, for Stats, without a metrics stack.
+// Requests not routed to a pattern and those routed to Quiet routes are not
+// counted.
+func TrackStats() Option {
+	return func(mux *Mux) {
+		mux.stats = &stats{routes: make(map[string]*routeStats)}
+	}
+}
+
+// observe records a request served by a route.
+func (s *stats) observe(info RequestInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rs, ok := s.routes[info.Pattern]
+	if !ok {
+		rs = new(routeStats)
+		s.routes[info.Pattern] = rs
+	}
+	rs.hits++
+	if info.Status >= 500 {
+		rs.errors++
+	}
+	if len(rs.samples) < statsSamples {
+		rs.samples = append(rs.samples, info.Duration)
+		return
+	}
+	rs.samples[rs.next] = info.Duration
+	rs.next = (rs.next + 1) % statsSamples
+}
+
+// Stats returns the stats of the patterns requests were routed to, sorted by
+// pattern, or nil if mux does not track them with TrackStats. Patterns
+// requests were routed to in muxes mounted with MountLive are included.
+func (mux *Mux) Stats() []RouteStats {
+	s := mux.load().stats
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	routes := make([]RouteStats, 0, len(s.routes))
+	var samples [][]time.Duration
+	for pattern, rs := range s.routes {
+		routes = append(routes, RouteStats{Pattern: pattern, Hits: rs.hits, Errors: rs.errors})
+		samples = append(samples, append([]time.Duration(nil), rs.samples...))
+	}
+	s.mu.Unlock()
+
+	for i := range routes {
+		d := samples[i]
+		sort.Slice(d, func(i, j int) bool { return d[i] < d[j] })
+		routes[i].P50 = quantile(d, 0.5)
+		routes[i].P90 = quantile(d, 0.9)
+		routes[i].P99 = quantile(d, 0.99)
+		routes[i].Max = quantile(d, 1)
+	}
+	sort.Slice(routes, func(i, j int) bool { return routes[i].Pattern < routes[j].Pattern })
+	return routes
+}
+
+// quantile returns the q-quantile of the sorted durations or 0 if there are
+// none.
+func quantile(sorted []time.Duration, q float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(q*float64(len(sorted))+0.5) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+	return sorted[i]
+}
+
+// StatsHandler returns a handler serving Stats as JSON, with durations in
+// nanoseconds. It is meant for debugging, so register it behind
+// authentication if mux is public:
+//
+//	m.Handle("/debug/routes/stats", m.StatsHandler(), mux.Quiet())
+func (mux *Mux) StatsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		routes := mux.Stats()
+		if routes == nil {
+			routes = []RouteStats{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(routes)
+	})
+}
@@ -0,0 +1,65 @@
+package mux_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestStats(t *testing.T) {
+	m := mux.New(mux.TrackStats())
+	m.HandleFunc("/users/{id}", handlerFactory(http.StatusOK, "user"))
+	m.HandleFunc("/fail", handlerFactory(http.StatusInternalServerError, "fail"))
+	m.HandleFunc("/health", handlerFactory(http.StatusOK, "ok"), mux.Quiet())
+	m.Handle("/stats", m.StatsHandler(), mux.Quiet())
+
+	for _, path := range []string{"/users/1", "/users/2", "/users/3", "/fail", "/health", "/missing"} {
+		m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	stats := m.Stats()
+	if len(stats) != 2 {
+		t.Fatalf("got %d routes, want 2: %+v", len(stats), stats)
+	}
+	want := []struct {
+		pattern      string
+		hits, errors uint64
+	}{
+		{"/fail", 1, 1},
+		{"/users/{id}", 3, 0},
+	}
+	for i, w := range want {
+		s := stats[i]
+		if s.Pattern != w.pattern || s.Hits != w.hits || s.Errors != w.errors {
+			t.Errorf("got %s %d hits %d errors, want %s %d hits %d errors", s.Pattern, s.Hits, s.Errors, w.pattern, w.hits, w.errors)
+		}
+		if s.P50 <= 0 || s.P50 > s.P90 || s.P90 > s.P99 || s.P99 > s.Max {
+			t.Errorf("got quantiles %v %v %v %v, want positive and increasing", s.P50, s.P90, s.P99, s.Max)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stats", nil))
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("got Content-Type %q, want application/json", ct)
+	}
+	var got []mux.RouteStats
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[1].Pattern != "/users/{id}" || got[1].Hits != 3 {
+		t.Errorf("got %+v from StatsHandler", got)
+	}
+}
+
+func TestStatsUntracked(t *testing.T) {
+	m := mux.New()
+	m.HandleFunc("/", handlerFactory(http.StatusOK, "ok"))
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if stats := m.Stats(); stats != nil {
+		t.Errorf("got %+v, want nil", stats)
+	}
+}
@@ -0,0 +1,37 @@
+package mux
+
+import "net/url"
+
+// SubtreePatterns returns an Option that makes non-regexp patterns ending in
+// "/" match the subtree rooted at them, as with http.ServeMux, so that
+// ServeMux code can move to mux unchanged. Without it, such patterns panic.
+//
+// A subtree pattern like "/static/" matches "/static/" and any path below
+// it; "/" matches every path. When several subtrees match, the longest wins,
+// and any other pattern matching the path takes precedence over subtrees. A
+// request for the subtree root without the trailing slash, like "/static",
+// is redirected to it unless a pattern equal to the path, like "/static",
+// is registered.
+//
+// A subtree pattern is the wildcard pattern "/static/*" except that it
+// exposes no parameter.
+func SubtreePatterns() Option {
+	return func(mux *Mux) {
+		mux.subtree = true
+	}
+}
+
+// subtree reports whether the pattern of e is a subtree pattern.
+func (e muxEntry) subtree() bool {
+	return e.wildcard() && e.segments[len(e.segments)-1].text == ""
+}
+
+// urlWithSlash determines if the given path needs "/" appended to it to
+// match the subtree pattern of e, which it does only for the subtree root. If
+// it does, it returns a new URL with the path set to path + "/" and true.
+func urlWithSlash(path string, e muxEntry, u *url.URL) (*url.URL, bool) {
+	if !e.subtree() || path == "" || matchSegments(e.segments, path) || !matchSegments(e.segments, path+"/") {
+		return u, false
+	}
+	return canonicalURL(u, path+"/"), true
+}
@@ -0,0 +1,139 @@
+package mux_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestSubtreePatterns(t *testing.T) {
+	newMux := func() *mux.Mux {
+		m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found")), mux.SubtreePatterns())
+		m.HandleFunc("/static/", handlerFactory(http.StatusTeapot, "static"))
+		m.HandleFunc("/static/img/", handlerFactory(http.StatusTeapot, "img"))
+		m.HandleFunc("/static/img/logo.png", handlerFactory(http.StatusTeapot, "logo"))
+		m.HandleFunc("/docs/", handlerFactory(http.StatusTeapot, "docs"))
+		m.HandleFunc("/docs", handlerFactory(http.StatusTeapot, "docs index"))
+		m.HandleFunc("/about", handlerFactory(http.StatusTeapot, "about"))
+		return m
+	}
+
+	t.Run("green", func(t *testing.T) {
+		cases := []struct {
+			path     string
+			code     int
+			body     string
+			location string
+		}{
+			{"/static/", http.StatusTeapot, "static", ""},
+			{"/static/a.css", http.StatusTeapot, "static", ""},
+			{"/static/css/a.css", http.StatusTeapot, "static", ""},
+			{"/static/img/", http.StatusTeapot, "img", ""},
+			{"/static/img/a.png", http.StatusTeapot, "img", ""},
+			{"/static/img/logo.png", http.StatusTeapot, "logo", ""},
+			{"/static/img/logo.png/", http.StatusTeapot, "img", ""},
+			{"/static", http.StatusPermanentRedirect, "", "/static/"},
+			{"/static/img?a=1", http.StatusPermanentRedirect, "", "/static/img/?a=1"},
+			{"/docs", http.StatusTeapot, "docs index", ""},
+			{"/docs/", http.StatusTeapot, "docs", ""},
+			{"/about/", http.StatusPermanentRedirect, "", "/about"},
+			{"/", http.StatusNotFound, "not found", ""},
+			{"/other", http.StatusNotFound, "not found", ""},
+		}
+
+		for _, c := range cases {
+			t.Run(c.path, func(t *testing.T) {
+				r := httptest.NewRequest(http.MethodGet, c.path, nil)
+				rec := httptest.NewRecorder()
+				newMux().ServeHTTP(rec, r)
+				resp := rec.Result()
+
+				if resp.StatusCode != c.code {
+					t.Errorf("got StatusCode %d, want %d", resp.StatusCode, c.code)
+				}
+
+				location := resp.Header.Get("Location")
+				if location != c.location {
+					t.Errorf("got Location %q, want %q", location, c.location)
+				}
+
+				if c.location != "" {
+					return
+				}
+
+				b, err := ioutil.ReadAll(resp.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				body := string(b)
+				if body != c.body {
+					t.Errorf("got body %q, want %q", body, c.body)
+				}
+			})
+		}
+	})
+
+	t.Run("root", func(t *testing.T) {
+		m := mux.New(mux.SubtreePatterns())
+		m.HandleFunc("/", handlerFactory(http.StatusTeapot, "root"))
+		m.HandleFunc("/a", handlerFactory(http.StatusTeapot, "a"))
+
+		for path, want := range map[string]string{"/": "root", "/a": "a", "/b/c": "root"} {
+			r := httptest.NewRequest(http.MethodGet, path, nil)
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+
+			b, err := ioutil.ReadAll(rec.Result().Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if body := string(b); body != want {
+				t.Errorf("%s: got body %q, want %q", path, body, want)
+			}
+		}
+	})
+
+	t.Run("Mount", func(t *testing.T) {
+		sub := mux.New(mux.SubtreePatterns())
+		sub.HandleFunc("/", handlerFactory(http.StatusTeapot, "sub root"))
+		sub.HandleFunc("/files/", handlerFactory(http.StatusTeapot, "sub files"))
+
+		m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found")))
+		m.Mount("/sub", sub)
+
+		for path, want := range map[string]string{
+			"/sub/":        "sub root",
+			"/sub/x":       "sub root",
+			"/sub/files/x": "sub files",
+		} {
+			r := httptest.NewRequest(http.MethodGet, path, nil)
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+
+			b, err := ioutil.ReadAll(rec.Result().Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if body := string(b); body != want {
+				t.Errorf("%s: got body %q, want %q", path, body, want)
+			}
+		}
+	})
+
+	t.Run("red", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("got no panic, want panic")
+			}
+		}()
+
+		m := mux.New()
+		m.HandleFunc("/static/", handlerFactory(http.StatusTeapot, ""))
+	})
+}
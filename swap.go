@@ -0,0 +1,41 @@
+package mux
+
+// Swap atomically replaces the routes of mux, including mounted handlers and
+// route names, with those of newMux, so that a complete new set of routes can
+// be built and put into service at once. The routes are copied and wrapped in
+// the middleware of newMux, as with Mount; mux keeps its options, middleware,
+// notFound and fallback. Requests in flight keep being served by the old
+// routes.
+//
+// Panics if newMux is nil or mux itself.
+func (mux *Mux) Swap(newMux *Mux) {
+	if newMux == nil {
+		panic("mux: nil mux")
+	}
+	if newMux == mux {
+		panic("mux: Swap with the mux itself")
+	}
+
+	t := newMux.load()
+	m := make(map[string]muxEntry, len(t.m))
+	for pattern, e := range t.m {
+		m[pattern] = e.withMiddleware(t.middleware)
+	}
+	patterns := append([]string(nil), t.patterns...)
+	regexps := append([]string(nil), t.regexps...)
+	mounts := append([]mount(nil), t.mounts...)
+	names := make(map[string]string, len(t.names))
+	for name, pattern := range t.names {
+		names[name] = pattern
+	}
+
+	tree := newTree(patterns, m)
+
+	mux.lock()
+	defer mux.unlock()
+
+	mux.m, mux.patterns, mux.regexps, mux.tree = m, patterns, regexps, tree
+	mux.mounts, mux.names = mounts, names
+	// Derived from the routes; formats is copied on write, so it is shared.
+	mux.formats, mux.exactPaths = t.formats, t.exactPaths
+}
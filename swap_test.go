@@ -0,0 +1,121 @@
+package mux_test
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestSwap(t *testing.T) {
+	m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found")))
+	m.Use(tag("outer"))
+	m.HandleFunc("/old", handlerFactory(http.StatusTeapot, "old"))
+	m.HandleFunc("/users/{id}", handlerFactory(http.StatusTeapot, "old user"))
+
+	next := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "next not found")))
+	next.Use(tag("next"))
+	next.HandleFunc("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		io.WriteString(w, "new user "+mux.Param(r, "id"))
+	}, mux.Name("user"))
+	next.RegexpHandleFunc(`^/posts/[0-9]+$`, handlerFactory(http.StatusTeapot, "post"))
+	next.MountHandler("/static", handlerFactory(http.StatusTeapot, "static"))
+
+	m.Swap(next)
+	next.HandleFunc("/later", handlerFactory(http.StatusTeapot, "later"))
+
+	cases := []struct {
+		path string
+		code int
+		body string
+		tags string
+	}{
+		{"/users/1", http.StatusTeapot, "new user 1", "outer next"},
+		{"/posts/1", http.StatusTeapot, "post", "outer next"},
+		{"/static/a.css", http.StatusTeapot, "static", "outer"},
+		{"/old", http.StatusNotFound, "not found", "outer"},
+		{"/later", http.StatusNotFound, "not found", "outer"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, c.path, nil)
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+			resp := rec.Result()
+
+			if resp.StatusCode != c.code {
+				t.Errorf("got StatusCode %d, want %d", resp.StatusCode, c.code)
+			}
+
+			b, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body := string(b)
+			if body != c.body {
+				t.Errorf("got body %q, want %q", body, c.body)
+			}
+
+			tags := strings.Join(resp.Header["X-Tags"], " ")
+			if tags != c.tags {
+				t.Errorf("got X-Tags %q, want %q", tags, c.tags)
+			}
+		})
+	}
+
+	if u, err := m.URL("user", "id", "2"); err != nil || u != "/users/2" {
+		t.Errorf("got URL %q, %v, want %q", u, err, "/users/2")
+	}
+
+	t.Run("concurrent", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				next := mux.New()
+				next.HandleFunc("/users/{id}", handlerFactory(http.StatusTeapot, ""))
+				m.Swap(next)
+			}()
+			go func() {
+				defer wg.Done()
+				m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("derived", func(t *testing.T) {
+		m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found")), mux.RedirectLowercase())
+		next := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "next not found")), mux.RedirectLowercase())
+		next.Get("/r/{id}", handlerFactory(http.StatusTeapot, "report"), mux.Formats("csv"))
+		next.Get("/Files/{name}", handlerFactory(http.StatusTeapot, "file"), mux.ExactPath())
+		m.Swap(next)
+
+		for _, path := range []string{"/r/7.csv", "/Files/A.txt"} {
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+			if rec.Code != http.StatusTeapot {
+				t.Errorf("%s: got StatusCode %d, want %d", path, rec.Code, http.StatusTeapot)
+			}
+		}
+	})
+
+	t.Run("red", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("got no panic, want panic")
+			}
+		}()
+
+		m.Swap(m)
+	})
+}
@@ -0,0 +1,40 @@
+package mux
+
+import (
+	"context"
+	"net/http"
+)
+
+// Tracing returns an Option that makes mux call start before serving each
+// request and the function it returns after, so that a span can be started
+// for the request and ended with the matched pattern and status code. The
+// context start returns, such as one carrying the span, is passed to
+// notFound, middleware and handlers. Unlike OnRequest, the returned function
+// is also called for requests routed to Quiet routes so that their spans are
+// ended.
+//
+// mux has no tracing dependencies; with OpenTelemetry, start may extract the
+// propagated context and start a span, named after the pattern once known:
+//
+//	tracer := otel.Tracer("server")
+//	propagator := otel.GetTextMapPropagator()
+//	m := mux.New(mux.Tracing(func(r *http.Request) (context.Context, func(mux.RequestInfo)) {
+//		ctx := propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+//		ctx, span := tracer.Start(ctx, r.Method, trace.WithSpanKind(trace.SpanKindServer))
+//		return ctx, func(info mux.RequestInfo) {
+//			if info.Pattern != "" {
+//				span.SetName(info.Method + " " + info.Pattern)
+//				span.SetAttributes(semconv.HTTPRoute(info.Pattern))
+//			}
+//			span.SetAttributes(semconv.HTTPResponseStatusCode(info.Status))
+//			if info.Status >= 500 {
+//				span.SetStatus(codes.Error, "")
+//			}
+//			span.End()
+//		}
+//	}))
+func Tracing(start func(r *http.Request) (context.Context, func(info RequestInfo))) Option {
+	return func(mux *Mux) {
+		mux.trace = start
+	}
+}
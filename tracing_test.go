@@ -0,0 +1,57 @@
+package mux_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+type spanKey struct{}
+
+func TestTracing(t *testing.T) {
+	var ended []mux.RequestInfo
+	m := mux.New(mux.Tracing(func(r *http.Request) (context.Context, func(mux.RequestInfo)) {
+		return context.WithValue(r.Context(), spanKey{}, r.URL.Path), func(info mux.RequestInfo) {
+			ended = append(ended, info)
+		}
+	}))
+	var span interface{}
+	m.HandleFunc("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		span = r.Context().Value(spanKey{})
+		w.WriteHeader(http.StatusTeapot)
+	})
+	m.HandleFunc("/health", handlerFactory(http.StatusOK, "ok"), mux.Quiet())
+
+	cases := []struct {
+		path    string
+		pattern string
+		status  int
+	}{
+		{"/users/1", "/users/{id}", http.StatusTeapot},
+		{"/health", "/health", http.StatusOK},
+		{"/missing", "", http.StatusNotFound},
+	}
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			ended = nil
+			m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, c.path, nil))
+
+			if len(ended) != 1 {
+				t.Fatalf("got %d ended spans, want 1", len(ended))
+			}
+			if ended[0].Pattern != c.pattern {
+				t.Errorf("got Pattern %q, want %q", ended[0].Pattern, c.pattern)
+			}
+			if ended[0].Status != c.status {
+				t.Errorf("got Status %d, want %d", ended[0].Status, c.status)
+			}
+		})
+	}
+
+	if span != "/users/1" {
+		t.Errorf("got span %v in handler context, want /users/1", span)
+	}
+}
@@ -0,0 +1,130 @@
+package mux
+
+import (
+	"regexp"
+	"strings"
+)
+
+// node is a node of the tree of the non-regexp patterns of a mux, split into
+// path segments. The tree finds the patterns matching a path in a single
+// walk down the segments of the path instead of trying every pattern.
+type node struct {
+	children map[string]*node // children for literal segments
+	params   []*node          // children for parameter segments, constrained first
+	re       *regexp.Regexp   // constraint of the parameter segment of the node or nil
+	pattern  string           // pattern ending at the node or ""
+	wildcard string           // pattern ending in a wildcard after the node or ""
+}
+
+// newTree returns the tree of the non-regexp patterns among the given keys of
+// m or nil if there are none.
+func newTree(patterns []string, m map[string]muxEntry) *node {
+	var tree *node
+	for _, pattern := range patterns {
+		if e := m[pattern]; !e.regexp {
+			if tree == nil {
+				tree = new(node)
+			}
+			tree.insert(pattern, e.segments)
+		}
+	}
+	return tree
+}
+
+// insert adds the non-regexp pattern with the given segments, nil if it has
+// no parameters, to the tree rooted at n.
+func (n *node) insert(pattern string, segments []segment) {
+	if segments == nil {
+		for _, s := range strings.Split(pattern[1:], "/") {
+			segments = append(segments, segment{text: s})
+		}
+	}
+
+	for _, s := range segments {
+		if s.wildcard {
+			n.wildcard = pattern
+			return
+		}
+		if s.param {
+			n = n.param(s.re)
+			continue
+		}
+
+		child, ok := n.children[s.text]
+		if !ok {
+			if n.children == nil {
+				n.children = make(map[string]*node)
+			}
+			child = new(node)
+			n.children[s.text] = child
+		}
+		n = child
+	}
+	n.pattern = pattern
+}
+
+// param returns the child of n for parameter segments with the constraint
+// re, adding it if needed. Constrained children are kept in the order they
+// were added, before the unconstrained one.
+func (n *node) param(re *regexp.Regexp) *node {
+	for _, child := range n.params {
+		if child.re == nil && re == nil || child.re != nil && re != nil && child.re.String() == re.String() {
+			return child
+		}
+	}
+
+	child := &node{re: re}
+	i := len(n.params)
+	if re != nil && i > 0 && n.params[i-1].re == nil {
+		i--
+	}
+	n.params = append(n.params, nil)
+	copy(n.params[i+1:], n.params[i:])
+	n.params[i] = child
+	return child
+}
+
+// lookup calls visit for the patterns in the tree rooted at n matching path
+// until visit returns true and reports whether it did. Literal segments take
+// precedence over parameters, constrained parameters over unconstrained ones
+// and parameters over wildcards, so "/users/new" is visited before
+// "/users/{id:[0-9]+}", that before "/users/{id}" and that before
+// "/users/*".
+func (n *node) lookup(path string, visit func(pattern string) bool) bool {
+	if n == nil || path == "" || path[0] != '/' {
+		return false
+	}
+	return n.walk(path[1:], visit)
+}
+
+// walk is lookup for the rest of the path after a "/".
+func (n *node) walk(rest string, visit func(pattern string) bool) bool {
+	seg, next := rest, ""
+	i := strings.IndexByte(rest, '/')
+	if i >= 0 {
+		seg, next = rest[:i], rest[i:]
+	}
+
+	if child, ok := n.children[seg]; ok && child.visit(next, visit) {
+		return true
+	}
+	if seg != "" {
+		for _, child := range n.params {
+			if (child.re == nil || child.re.MatchString(seg)) && child.visit(next, visit) {
+				return true
+			}
+		}
+	}
+	return n.wildcard != "" && visit(n.wildcard)
+}
+
+// visit continues the lookup at n with the rest of the path, "" if n is the
+// last segment. The subtree pattern rooted at the last segment, if any, is
+// visited so that it can redirect to the path with a trailing slash.
+func (n *node) visit(next string, visit func(pattern string) bool) bool {
+	if next == "" {
+		return n.pattern != "" && visit(n.pattern) ||
+			strings.HasSuffix(n.wildcard, "/") && visit(n.wildcard)
+	}
+	return n.walk(next[1:], visit)
+}
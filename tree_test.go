@@ -0,0 +1,90 @@
+package mux_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestTree(t *testing.T) {
+	m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found")))
+	m.HandleFunc("/", handlerFactory(http.StatusTeapot, "index"))
+	m.HandleFunc("/users/{id}", handlerFactory(http.StatusTeapot, "user"))
+	m.HandleFunc("/users/new", handlerFactory(http.StatusTeapot, "new"))
+	m.HandleFunc("/users/new/{step}/done", handlerFactory(http.StatusTeapot, "new step done"))
+	m.HandleFunc("/users/{id}/posts", handlerFactory(http.StatusTeapot, "posts"))
+	m.Get("/files/{name}", handlerFactory(http.StatusTeapot, "get file"))
+	m.HandleFunc("/files/readme", handlerFactory(http.StatusTeapot, "readme"))
+	m.Post("/files/upload", handlerFactory(http.StatusTeapot, "upload"))
+	m.HandleFunc("/a//b", handlerFactory(http.StatusTeapot, "empty segment"))
+	m.HandleFunc("/users/{id}/posts/*", handlerFactory(http.StatusTeapot, "posts wildcard"))
+	m.HandleFunc("/files/{path...}", handlerFactory(http.StatusTeapot, "files wildcard"))
+
+	cases := []struct {
+		method string
+		path   string
+		code   int
+		body   string
+	}{
+		{http.MethodGet, "/", http.StatusTeapot, "index"},
+		{http.MethodGet, "/users/new", http.StatusTeapot, "new"},
+		{http.MethodGet, "/users/12", http.StatusTeapot, "user"},
+		{http.MethodGet, "/users/new/posts", http.StatusTeapot, "posts"},
+		{http.MethodGet, "/users/new/1/done", http.StatusTeapot, "new step done"},
+		{http.MethodGet, "/users/12/1/done", http.StatusNotFound, "not found"},
+		{http.MethodGet, "/files/readme", http.StatusTeapot, "readme"},
+		{http.MethodGet, "/files/upload", http.StatusTeapot, "get file"},
+		{http.MethodPost, "/files/upload", http.StatusTeapot, "upload"},
+		{http.MethodGet, "/a//b", http.StatusTeapot, "empty segment"},
+		{http.MethodGet, "/a/b", http.StatusNotFound, "not found"},
+		{http.MethodGet, "/users//posts", http.StatusNotFound, "not found"},
+		{http.MethodGet, "/users/12/posts/1", http.StatusTeapot, "posts wildcard"},
+		{http.MethodGet, "/users/new/posts/1", http.StatusTeapot, "posts wildcard"},
+		{http.MethodGet, "/files/readme/1", http.StatusTeapot, "files wildcard"},
+		{http.MethodPut, "/files/upload", http.StatusTeapot, "files wildcard"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.method+" "+c.path, func(t *testing.T) {
+			r := httptest.NewRequest(c.method, c.path, nil)
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+			resp := rec.Result()
+
+			if resp.StatusCode != c.code {
+				t.Errorf("got StatusCode %d, want %d", resp.StatusCode, c.code)
+			}
+
+			b, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body := string(b)
+			if body != c.body {
+				t.Errorf("got body %q, want %q", body, c.body)
+			}
+		})
+	}
+}
+
+func BenchmarkTree(b *testing.B) {
+	m := mux.New()
+	for i := 0; i < 500; i++ {
+		m.HandleFunc("/static/"+strconv.Itoa(i), func(w http.ResponseWriter, r *http.Request) {})
+		m.HandleFunc("/params/"+strconv.Itoa(i)+"/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/static/499", nil)
+	w := httptest.NewRecorder()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.ServeHTTP(w, r)
+	}
+}
@@ -0,0 +1,50 @@
+package mux
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// TryHandleFunc is HandleFunc except that it returns an error instead of
+// panicking if the pattern is malformed, already registered or rejected by
+// an option, so that code registering routes it does not control, like
+// routes read from configuration, can recover. mux is left unchanged if an
+// error is returned. Panics not caused by the registration, like those of
+// user-supplied options, are not recovered.
+func (mux *Mux) TryHandleFunc(pattern string, handler http.HandlerFunc, opts ...RouteOption) error {
+	return try(func() {
+		mux.HandleFunc(pattern, handler, opts...)
+	})
+}
+
+// TryHandle is Handle returning an error like TryHandleFunc.
+func (mux *Mux) TryHandle(pattern string, handler http.Handler, opts ...RouteOption) error {
+	return try(func() {
+		mux.Handle(pattern, handler, opts...)
+	})
+}
+
+// TryRegexpHandleFunc is RegexpHandleFunc returning an error like
+// TryHandleFunc, including if the pattern is not a valid regular expression.
+func (mux *Mux) TryRegexpHandleFunc(pattern string, handler http.HandlerFunc, opts ...RouteOption) error {
+	return try(func() {
+		mux.RegexpHandleFunc(pattern, handler, opts...)
+	})
+}
+
+// try calls register and returns the panic of a failed registration as an
+// error.
+func try(register func()) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			msg, ok := v.(string)
+			if !ok || !strings.HasPrefix(msg, "mux: ") && !strings.HasPrefix(msg, "regexp: ") {
+				panic(v)
+			}
+			err = errors.New(msg)
+		}
+	}()
+	register()
+	return nil
+}
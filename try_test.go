@@ -0,0 +1,101 @@
+package mux_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestTryHandleFunc(t *testing.T) {
+	m := mux.New()
+	m.HandleFunc("/a", handlerFactory(http.StatusTeapot, "a"))
+	m.HandleFunc("/named", handlerFactory(http.StatusTeapot, "named"), mux.Name("named"))
+
+	cases := []struct {
+		name     string
+		register func() error
+		err      string // substring of the error, "" if none
+	}{
+		{"ok", func() error {
+			return m.TryHandleFunc("/b", handlerFactory(http.StatusTeapot, "b"))
+		}, ""},
+		{"duplicate", func() error {
+			return m.TryHandleFunc("/a", handlerFactory(http.StatusOK, "other"))
+		}, "mux: multiple registrations for /a"},
+		{"malformed", func() error {
+			return m.TryHandleFunc("c", handlerFactory(http.StatusOK, "c"))
+		}, "must begin with"},
+		{"invalid parameter", func() error {
+			return m.TryHandleFunc("/c/{id", handlerFactory(http.StatusOK, "c"))
+		}, "invalid parameter"},
+		{"invalid method", func() error {
+			return m.TryHandleFunc("GE(T /c", handlerFactory(http.StatusOK, "c"))
+		}, "invalid method"},
+		{"nil handler", func() error {
+			return m.TryHandle("/c", nil)
+		}, "nil handler"},
+		{"duplicate name", func() error {
+			return m.TryHandleFunc("/c", handlerFactory(http.StatusOK, "c"), mux.Name("named"))
+		}, "multiple routes named"},
+		{"invalid regexp", func() error {
+			return m.TryRegexpHandleFunc("^/d/(", handlerFactory(http.StatusOK, "d"))
+		}, "regexp: Compile"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.register()
+			if c.err == "" {
+				if err != nil {
+					t.Errorf("got error %v, want none", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), c.err) {
+				t.Errorf("got error %v, want one containing %q", err, c.err)
+			}
+		})
+	}
+
+	// The mux still serves its routes after failed registrations.
+	for path, want := range map[string]int{"/a": http.StatusTeapot, "/b": http.StatusTeapot, "/c": http.StatusNotFound} {
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != want {
+			t.Errorf("%s: got StatusCode %d, want %d", path, rec.Code, want)
+		}
+	}
+}
+
+func TestTryHandleFuncOptionPanic(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("got no panic, want panic")
+		}
+	}()
+	m := mux.New()
+	m.TryHandleFunc("/a", handlerFactory(http.StatusTeapot, "a"), mux.WithMiddleware(func(http.Handler) http.Handler {
+		panic("boom")
+	}))
+}
+
+func TestTryHandleFuncFormats(t *testing.T) {
+	m := mux.New()
+	m.HandleFunc("/a", handlerFactory(http.StatusTeapot, "a"))
+	explain := func() string {
+		return m.Explain(httptest.NewRequest(http.MethodGet, "/a.json", nil)).String()
+	}
+	want := explain()
+
+	if err := m.TryHandleFunc("/a", handlerFactory(http.StatusOK, "other"), mux.Formats("json")); err == nil {
+		t.Fatal("got no error, want error")
+	}
+	if got := explain(); got != want {
+		t.Errorf("got explanation\n%s\nwant\n%s", got, want)
+	}
+	if routes := m.Routes(); len(routes) != 1 || routes[0].Formats != nil {
+		t.Errorf("got routes %+v, want /a without formats", routes)
+	}
+}
@@ -0,0 +1,64 @@
+package mux
+
+import (
+	"net/http"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// unicodeMode tells how request paths are normalized.
+type unicodeMode int
+
+const (
+	unicodeAsIs      unicodeMode = iota // paths are matched as they are
+	unicodeNormalize                    // paths are normalized to NFC
+	unicodeRedirect                     // paths are redirected to NFC
+)
+
+// NormalizeUnicode returns an Option that normalizes request paths to Unicode
+// Normalization Form C before matching, so that "/café" matches whether the
+// "é" arrives composed, as NFC, or decomposed, as NFD. Patterns are normalized
+// the same way when registered. Handlers see the normalized path and path
+// parameters.
+func NormalizeUnicode() Option {
+	return func(mux *Mux) {
+		mux.unicode = unicodeNormalize
+	}
+}
+
+// RedirectUnicode is NormalizeUnicode except that requests whose path is not
+// in NFC are redirected to the normalized path, like a path with a trailing
+// slash is redirected to the path without.
+func RedirectUnicode() Option {
+	return func(mux *Mux) {
+		mux.unicode = unicodeRedirect
+	}
+}
+
+// normalizeUnicode returns the handler for r if the path of r is not in NFC,
+// recording the decision in ex unless ex is nil. Redirects to NFC are left to
+// canonicalize.
+func (t *table) normalizeUnicode(r *http.Request, ex *Explanation) (http.HandlerFunc, bool) {
+	if norm.NFC.IsNormalString(r.URL.Path) || t.routedExactly(r) {
+		return nil, false
+	}
+
+	h, ok := t.route(nfcRequest(r), ex)
+	if !ok {
+		return nil, false
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		h(w, nfcRequest(r))
+	}, true
+}
+
+// nfcRequest returns a shallow copy of r with its path normalized to NFC.
+func nfcRequest(r *http.Request) *http.Request {
+	r2 := new(http.Request)
+	*r2 = *r
+	u := *r.URL
+	u.Path = norm.NFC.String(u.Path)
+	u.RawPath = ""
+	r2.URL = &u
+	return r2
+}
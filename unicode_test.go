@@ -0,0 +1,120 @@
+package mux_test
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+const (
+	composed   = "caf\u00e9"  // NFC
+	decomposed = "cafe\u0301" // NFD
+)
+
+func TestNormalizeUnicode(t *testing.T) {
+	newMux := func(opts ...mux.Option) *mux.Mux {
+		m := mux.New(opts...)
+		m.HandleFunc("/"+composed, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+			io.WriteString(w, r.URL.Path)
+		})
+		m.HandleFunc("/menu/"+decomposed, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+			io.WriteString(w, r.URL.Path)
+		})
+		m.RegexpHandleFunc("^/tags/(?P<tag>.+)$", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+			fmt.Fprint(w, mux.Param(r, "tag"))
+		})
+		return m
+	}
+
+	t.Run("green", func(t *testing.T) {
+		cases := []struct {
+			name string
+			path string
+			body string
+		}{
+			{"literal NFC", "/" + composed, "/" + composed},
+			{"literal NFD", "/" + decomposed, "/" + composed},
+			{"NFD pattern NFC", "/menu/" + composed, "/menu/" + composed},
+			{"NFD pattern NFD", "/menu/" + decomposed, "/menu/" + composed},
+			{"param NFC", "/tags/" + composed, composed},
+			{"param NFD", "/tags/" + decomposed, composed},
+		}
+
+		for _, c := range cases {
+			t.Run(c.name, func(t *testing.T) {
+				r := httptest.NewRequest(http.MethodGet, (&url.URL{Path: c.path}).String(), nil)
+				rec := httptest.NewRecorder()
+				newMux(mux.NormalizeUnicode()).ServeHTTP(rec, r)
+				resp := rec.Result()
+
+				if resp.StatusCode != http.StatusTeapot {
+					t.Errorf("got StatusCode %d, want %d", resp.StatusCode, http.StatusTeapot)
+				}
+
+				b, err := ioutil.ReadAll(resp.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				body := string(b)
+				if body != c.body {
+					t.Errorf("got body %q, want %q", body, c.body)
+				}
+			})
+		}
+	})
+
+	t.Run("off", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, (&url.URL{Path: "/" + decomposed}).String(), nil)
+		rec := httptest.NewRecorder()
+		newMux().ServeHTTP(rec, r)
+
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("got StatusCode %d, want %d", rec.Code, http.StatusNotFound)
+		}
+	})
+
+	t.Run("redirect", func(t *testing.T) {
+		cases := []struct {
+			path     string
+			code     int
+			location string
+		}{
+			{"/" + decomposed + "?q=" + decomposed, http.StatusPermanentRedirect, "/caf%C3%A9?q=" + url.QueryEscape(decomposed)},
+			{"/" + composed, http.StatusTeapot, ""},
+		}
+
+		for _, c := range cases {
+			t.Run(c.path, func(t *testing.T) {
+				u, err := url.Parse(c.path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				u.RawQuery = u.Query().Encode()
+
+				r := httptest.NewRequest(http.MethodGet, u.String(), nil)
+				rec := httptest.NewRecorder()
+				newMux(mux.RedirectUnicode()).ServeHTTP(rec, r)
+				resp := rec.Result()
+
+				if resp.StatusCode != c.code {
+					t.Errorf("got StatusCode %d, want %d", resp.StatusCode, c.code)
+				}
+
+				location := resp.Header.Get("Location")
+				if location != c.location {
+					t.Errorf("got Location %q, want %q", location, c.location)
+				}
+			})
+		}
+	})
+}
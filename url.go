@@ -0,0 +1,90 @@
+package mux
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// Name names the route so that URL can build URLs for it. Routes copied by
+// Mount keep their names.
+//
+// Registration panics if another route of the mux has the same name.
+func Name(name string) RouteOption {
+	if name == "" {
+		panic("mux: empty route name")
+	}
+	return func(mux *Mux, e *muxEntry) {
+		e.names = append(e.names, name)
+	}
+}
+
+// URL returns the path of the route named name with its parameters replaced
+// by the given values. params are name and value pairs, so the URL of a route
+// "/users/{id}/posts/{post}" is URL("post", "id", "12", "post", "7"). Values
+// are path escaped, except for the slashes of a wildcard value.
+//
+// URL returns an error if no route is named name, the route has a regexp
+// pattern, params has an odd length, a parameter of the route has no value or
+// a value that does not match its constraint, or params names a parameter
+// the route does not have.
+func (mux *Mux) URL(name string, params ...string) (string, error) {
+	t := mux.load()
+	pattern, ok := t.names[name]
+	e := t.m[pattern]
+
+	switch {
+	case !ok:
+		return "", errors.New("mux: no route named " + name)
+	case e.regexp:
+		return "", errors.New("mux: route " + name + " has a regexp pattern")
+	case len(params)%2 != 0:
+		return "", errors.New("mux: odd number of parameters for route " + name)
+	}
+
+	values := make(map[string]string, len(params)/2)
+	for i := 0; i < len(params); i += 2 {
+		values[params[i]] = params[i+1]
+	}
+	if e.segments == nil {
+		for param := range values {
+			return "", errors.New("mux: route " + name + " has no parameter " + param)
+		}
+		return pattern, nil
+	}
+
+	var b strings.Builder
+	for _, s := range e.segments {
+		b.WriteByte('/')
+		if !s.param {
+			b.WriteString(s.text)
+			continue
+		}
+		if s.text == "" { // subtree
+			continue
+		}
+
+		value, ok := values[s.text]
+		if !ok || value == "" && !s.wildcard {
+			return "", errors.New("mux: no value for parameter " + s.text + " of route " + name)
+		}
+		delete(values, s.text)
+		if s.re != nil && !s.re.MatchString(value) {
+			return "", errors.New("mux: value " + value + " does not match parameter " + s.text + " of route " + name)
+		}
+
+		if s.wildcard {
+			parts := strings.Split(value, "/")
+			for i, part := range parts {
+				parts[i] = url.PathEscape(part)
+			}
+			b.WriteString(strings.Join(parts, "/"))
+		} else {
+			b.WriteString(url.PathEscape(value))
+		}
+	}
+	for param := range values {
+		return "", errors.New("mux: route " + name + " has no parameter " + param)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,81 @@
+package mux_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestURL(t *testing.T) {
+	sub := mux.New()
+	sub.HandleFunc("/report/{year:[0-9]{4}}", handlerFactory(http.StatusTeapot, ""), mux.Name("report"))
+
+	m := mux.New()
+	m.HandleFunc("/about", handlerFactory(http.StatusTeapot, ""), mux.Name("about"))
+	m.Get("/users/{id}/posts/:post", handlerFactory(http.StatusTeapot, ""), mux.Name("post"))
+	m.HandleFunc("/files/{path...}", handlerFactory(http.StatusTeapot, ""), mux.Name("file"))
+	m.RegexpHandleFunc(`^/tags/[a-z]+$`, handlerFactory(http.StatusTeapot, ""), mux.Name("tag"))
+	m.Mount("/sub", sub)
+
+	t.Run("green", func(t *testing.T) {
+		cases := []struct {
+			name   string
+			params []string
+			url    string
+		}{
+			{"about", nil, "/about"},
+			{"post", []string{"id", "12", "post", "7"}, "/users/12/posts/7"},
+			{"post", []string{"post", "a b/c", "id", "1"}, "/users/1/posts/a%20b%2Fc"},
+			{"file", []string{"path", "a/b c.txt"}, "/files/a/b%20c.txt"},
+			{"file", []string{"path", ""}, "/files/"},
+			{"report", []string{"year", "2024"}, "/sub/report/2024"},
+		}
+
+		for _, c := range cases {
+			t.Run(c.name, func(t *testing.T) {
+				u, err := m.URL(c.name, c.params...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if u != c.url {
+					t.Errorf("got URL %q, want %q", u, c.url)
+				}
+			})
+		}
+	})
+
+	t.Run("red", func(t *testing.T) {
+		cases := []struct {
+			name   string
+			params []string
+		}{
+			{"none", nil},
+			{"tag", nil},
+			{"about", []string{"id"}},
+			{"about", []string{"id", "1"}},
+			{"post", []string{"id", "1"}},
+			{"post", []string{"id", "", "post", "1"}},
+			{"post", []string{"id", "1", "post", "2", "x", "3"}},
+			{"report", []string{"year", "24"}},
+		}
+
+		for _, c := range cases {
+			t.Run(c.name, func(t *testing.T) {
+				if u, err := m.URL(c.name, c.params...); err == nil {
+					t.Errorf("got URL %q, want error", u)
+				}
+			})
+		}
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("got no panic, want panic")
+			}
+		}()
+
+		m.HandleFunc("/contact", handlerFactory(http.StatusTeapot, ""), mux.Name("about"))
+	})
+}
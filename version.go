@@ -0,0 +1,141 @@
+package mux
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// versionRouter routes requests without a version prefix into the submux of
+// an API version registered with Version. It is replaced, not modified, when
+// versions are added.
+type versionRouter struct {
+	subs map[string]*Mux
+	def  string // default version or ""
+}
+
+// Version routes requests for the API version, like "v2", into sub, so that
+// the routes of each version of an API live in their own mux:
+//
+//	m.Version("v1", v1)
+//	m.Version("v2", v2)
+//	m.DefaultVersion("v1")
+//
+// Requests whose path begins with the version, like "/v2/users", are routed
+// into sub with the version stripped from the path, as with MountLive.
+// Requests without a version prefix that no pattern of mux matches are routed
+// into the submux of the version named by their Accept header, as
+// "application/vnd.api.v2+json" names "v2", or else into that of the default
+// version, if any, provided it has a route for them. Handlers get the version
+// with APIVersion.
+//
+// Panics if version is empty or contains "/", sub is nil or mux itself or a
+// handler is already mounted at the version prefix.
+func (mux *Mux) Version(version string, sub *Mux) {
+	mux.lock()
+	defer mux.unlock()
+
+	if version == "" || strings.Contains(version, "/") {
+		panic("mux: invalid version " + version)
+	}
+	if sub == nil {
+		panic("mux: nil submux")
+	}
+	if sub == mux {
+		panic("mux: submux must not be the mux itself")
+	}
+
+	mux.mountHandler(mount{prefix: "/" + version, handler: sub, version: version})
+	v := &versionRouter{subs: map[string]*Mux{version: sub}}
+	if mux.versions != nil {
+		for version, sub := range mux.versions.subs {
+			v.subs[version] = sub
+		}
+		v.def = mux.versions.def
+	}
+	mux.versions = v
+}
+
+// DefaultVersion makes requests without a version prefix that name no version
+// in their Accept header be routed into the submux of the given version, as
+// described by Version.
+//
+// Panics if version was not registered with Version.
+func (mux *Mux) DefaultVersion(version string) {
+	mux.lock()
+	defer mux.unlock()
+
+	if mux.versions == nil || mux.versions.subs[version] == nil {
+		panic("mux: unknown version " + version)
+	}
+	v := *mux.versions
+	v.def = version
+	mux.versions = &v
+}
+
+// bare returns a handler for r, whose path does not begin with a version, if
+// the submux of the version it asks for, or else of the default version, has
+// a route for it, recording the decision in ex unless ex is nil.
+func (v *versionRouter) bare(r *http.Request, ex *Explanation) (http.HandlerFunc, bool) {
+	version := v.accepted(r.Header.Get("Accept"))
+	if version == "" {
+		version = v.def
+	}
+	sub := v.subs[version]
+	if sub == nil {
+		return nil, false
+	}
+
+	st := sub.load()
+	inner := ex.nested(r)
+	h, ok := st.match(r, inner)
+	if !ok {
+		return nil, false
+	}
+	ex.version(version, inner)
+	h = chain(st.middleware, h).ServeHTTP
+	return func(w http.ResponseWriter, r *http.Request) {
+		h(w, withVersion(r, version))
+	}, true
+}
+
+// accepted returns the first registered version named by a vendor media type
+// of the Accept header, as a dot-separated part of its subtype before any
+// "+" suffix, or "" if there is none.
+func (v *versionRouter) accepted(accept string) string {
+	for _, mediatype := range strings.Split(accept, ",") {
+		if i := strings.IndexByte(mediatype, ';'); i >= 0 {
+			mediatype = mediatype[:i]
+		}
+		mediatype = strings.TrimSpace(mediatype)
+		i := strings.IndexByte(mediatype, '/')
+		if i < 0 || !strings.HasPrefix(mediatype[i+1:], "vnd.") {
+			continue
+		}
+		subtype := mediatype[i+1:]
+		if j := strings.IndexByte(subtype, '+'); j >= 0 {
+			subtype = subtype[:j]
+		}
+		for _, part := range strings.Split(subtype, ".")[1:] {
+			if v.subs[part] != nil {
+				return part
+			}
+		}
+	}
+	return ""
+}
+
+// versionKey is the context key for the API version of a request.
+type versionKey struct{}
+
+// withVersion returns a shallow copy of r with the given API version.
+func withVersion(r *http.Request, version string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), versionKey{}, version))
+}
+
+// APIVersion returns the API version of a request routed by Version or "" if
+// there is none.
+func APIVersion(r *http.Request) string {
+	version, _ := r.Context().Value(versionKey{}).(string)
+	return version
+}
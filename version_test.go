@@ -0,0 +1,111 @@
+package mux_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestVersion(t *testing.T) {
+	versioned := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, body+" "+mux.APIVersion(r))
+		}
+	}
+
+	v1 := mux.New()
+	v1.HandleFunc("/users", versioned("users"))
+	v1.HandleFunc("/legacy", versioned("legacy"))
+	v2 := mux.New()
+	v2.Use(tag("v2"))
+	v2.HandleFunc("/users", versioned("new users"))
+
+	m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found")))
+	m.HandleFunc("/health", versioned("health"))
+	m.Version("v1", v1)
+	m.Version("v2", v2)
+	m.DefaultVersion("v1")
+
+	cases := []struct {
+		path   string
+		accept string
+		code   int
+		body   string
+		tags   string
+	}{
+		{"/v1/users", "", http.StatusOK, "users v1", ""},
+		{"/v2/users", "", http.StatusOK, "new users v2", "v2"},
+		{"/v2/legacy", "", http.StatusNotFound, "404 page not found\n", "v2"},
+		{"/users", "", http.StatusOK, "users v1", ""},
+		{"/users", "application/vnd.api.v2+json", http.StatusOK, "new users v2", "v2"},
+		{"/users", "text/html, application/vnd.example.v2+json;q=0.9", http.StatusOK, "new users v2", "v2"},
+		{"/users", "application/vnd.api.v3+json", http.StatusOK, "users v1", ""},
+		{"/legacy", "application/vnd.api.v2+json", http.StatusNotFound, "not found", ""},
+		{"/health", "application/vnd.api.v2+json", http.StatusOK, "health ", ""},
+		{"/missing", "", http.StatusNotFound, "not found", ""},
+	}
+	for _, c := range cases {
+		t.Run(c.path+" "+c.accept, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, c.path, nil)
+			r.Header.Set("Accept", c.accept)
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, r)
+			if rec.Code != c.code {
+				t.Errorf("got StatusCode %d, want %d", rec.Code, c.code)
+			}
+			if body := rec.Body.String(); body != c.body {
+				t.Errorf("got body %q, want %q", body, c.body)
+			}
+			if tags := strings.Join(rec.Header()["X-Tags"], " "); tags != c.tags {
+				t.Errorf("got X-Tags %q, want %q", tags, c.tags)
+			}
+		})
+	}
+
+	t.Run("Explain", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/users", nil)
+		r.Header.Set("Accept", "application/vnd.api.v2+json")
+		ex := m.Explain(r)
+		if ex.Outcome != mux.OutcomeVersion || ex.Version != "v2" || ex.Inner == nil || ex.Inner.Pattern != "/users" {
+			t.Errorf("got explanation\n%s\nwant version v2 routed to /users", ex)
+		}
+	})
+
+	t.Run("without default", func(t *testing.T) {
+		m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found")))
+		m.Version("v1", v1)
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users", nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("got StatusCode %d, want %d", rec.Code, http.StatusNotFound)
+		}
+	})
+}
+
+func TestVersionPanics(t *testing.T) {
+	cases := []struct {
+		name string
+		f    func(m *mux.Mux)
+	}{
+		{"empty", func(m *mux.Mux) { m.Version("", mux.New()) }},
+		{"slash", func(m *mux.Mux) { m.Version("v/1", mux.New()) }},
+		{"nil", func(m *mux.Mux) { m.Version("v1", nil) }},
+		{"self", func(m *mux.Mux) { m.Version("v1", m) }},
+		{"twice", func(m *mux.Mux) { m.Version("v1", mux.New()); m.Version("v1", mux.New()) }},
+		{"unknown default", func(m *mux.Mux) { m.DefaultVersion("v1") }},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("got no panic, want panic")
+				}
+			}()
+			c.f(mux.New())
+		})
+	}
+}
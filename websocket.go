@@ -0,0 +1,215 @@
+package mux
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// WebSocketHandler handles a WebSocket connection once the opening handshake
+// is done: conn is the hijacked connection, rw reads the frames of the client,
+// including any it sent along with the handshake, and writes to conn, and r is
+// the request that opened the connection. The connection is closed by the
+// handler.
+type WebSocketHandler func(conn net.Conn, rw *bufio.ReadWriter, r *http.Request)
+
+// websocketGUID is appended to the key of the client to compute the
+// Sec-WebSocket-Accept header, as RFC 6455 specifies.
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// WebSocket registers the handler for WebSocket connections opened by GET
+// requests to the given pattern. The opening handshake of RFC 6455 is done
+// before handler is called with the hijacked connection; the framing of
+// messages is left to the handler.
+//
+// Requests that are not WebSocket upgrades, or for another version than 13,
+// are answered with 426 Upgrade Required, requests with an invalid key with
+// 400 Bad Request and requests from another origin than the host, as the
+// Origin header says, with 403 Forbidden unless WebSocketOrigins allows it.
+// The headers set by middleware, like request IDs, are sent along with the
+// handshake response.
+//
+// The built-in middleware and RouteOptions pass http.Hijacker through, so the
+// connection can be hijacked behind UseCompression, UseETags, Cached or an
+// OnRequest hook, which records the request with status 101. Requests over
+// HTTP/2, whose connections can not be hijacked, are answered with 500
+// Internal Server Error.
+func (mux *Mux) WebSocket(pattern string, handler WebSocketHandler, opts ...RouteOption) {
+	if handler == nil {
+		panic("mux: nil handler")
+	}
+	mux.Get(pattern, func(w http.ResponseWriter, r *http.Request) {
+		protocol, ok := upgradeWebSocket(w, r)
+		if !ok {
+			return
+		}
+		hj, ok := w.(http.Hijacker)
+		if !ok {
+			http.Error(w, "mux: ResponseWriter does not implement http.Hijacker", http.StatusInternalServerError)
+			return
+		}
+		conn, rw, err := hj.Hijack()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+		h := w.Header().Clone()
+		h.Del("Content-Length")
+		h.Del("Content-Type")
+		h.Set("Upgrade", "websocket")
+		h.Set("Connection", "Upgrade")
+		h.Set("Sec-WebSocket-Accept", websocketAccept(r.Header.Get("Sec-WebSocket-Key")))
+		if protocol != "" {
+			h.Set("Sec-WebSocket-Protocol", protocol)
+		}
+		h.Write(rw)
+		rw.WriteString("\r\n")
+		if err := rw.Flush(); err != nil {
+			conn.Close()
+			return
+		}
+		handler(conn, rw, r.WithContext(context.WithValue(r.Context(), websocketProtocolKey{}, protocol)))
+	}, opts...)
+}
+
+// upgradeWebSocket checks that r opens a WebSocket connection the route
+// accepts and returns the subprotocol chosen for it, or replies with an error
+// and returns false.
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (protocol string, ok bool) {
+	if !headerHasToken(r.Header, "Connection", "upgrade") || !headerHasToken(r.Header, "Upgrade", "websocket") ||
+		r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Upgrade", "websocket")
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, http.StatusText(http.StatusUpgradeRequired), http.StatusUpgradeRequired)
+		return "", false
+	}
+	if key, err := base64.StdEncoding.DecodeString(r.Header.Get("Sec-WebSocket-Key")); err != nil || len(key) != 16 {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return "", false
+	}
+
+	config, _ := r.Context().Value(websocketConfigKey{}).(*websocketConfig)
+	if config == nil {
+		config = new(websocketConfig)
+	}
+	if !config.allowsOrigin(r) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return "", false
+	}
+	offered := splitList(r.Header.Values("Sec-WebSocket-Protocol"))
+	for _, p := range config.protocols {
+		if contains(offered, p) {
+			return p, true
+		}
+	}
+	return "", true
+}
+
+// headerHasToken reports whether the comma-separated values of the header
+// name of h contain token, case-insensitively.
+func headerHasToken(h http.Header, name, token string) bool {
+	for _, v := range splitList(h.Values(name)) {
+		if strings.EqualFold(v, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// websocketAccept returns the Sec-WebSocket-Accept header for the
+// Sec-WebSocket-Key header key.
+func websocketAccept(key string) string {
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// websocketConfig configures the handshake of WebSocket routes, as set by
+// their RouteOptions.
+type websocketConfig struct {
+	protocols []string // subprotocols, most preferred first
+	origins   CORS     // origins allowed besides the host
+}
+
+// allowsOrigin reports whether c allows r from its origin: the host of r or,
+// if set with WebSocketOrigins, the origins allowed. Requests without an
+// Origin header, which do not come from browsers, are allowed.
+func (c *websocketConfig) allowsOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	return c.origins.allowsOrigin(origin)
+}
+
+// websocketConfigKey is the context key for the websocketConfig of a
+// request.
+type websocketConfigKey struct{}
+
+// withWebSocketConfig returns a RouteOption calling set on the
+// websocketConfig of the requests to the route.
+func withWebSocketConfig(set func(c *websocketConfig)) RouteOption {
+	return func(mux *Mux, e *muxEntry) {
+		next := e.handler
+		e.handler = func(w http.ResponseWriter, r *http.Request) {
+			config := new(websocketConfig)
+			if c, ok := r.Context().Value(websocketConfigKey{}).(*websocketConfig); ok {
+				*config = *c
+			}
+			set(config)
+			next(w, r.WithContext(context.WithValue(r.Context(), websocketConfigKey{}, config)))
+		}
+	}
+}
+
+// Subprotocols sets the subprotocols of a WebSocket route, most preferred
+// first. The first one the client offers in its Sec-WebSocket-Protocol header
+// is chosen, and WebSocketProtocol returns it to the handler. Clients offering
+// none of them are connected without a subprotocol.
+//
+// Panics if no subprotocols are given.
+func Subprotocols(protocols ...string) RouteOption {
+	if len(protocols) == 0 {
+		panic("mux: no subprotocols")
+	}
+	protocols = append([]string(nil), protocols...)
+	return withWebSocketConfig(func(c *websocketConfig) {
+		c.protocols = protocols
+	})
+}
+
+// WebSocketOrigins allows browsers to open connections to a WebSocket route
+// from the given origins besides the host, like "https://example.com". An
+// origin may contain one "*" matching any string, like
+// "https://*.example.com", and "*" allows all origins.
+//
+// Panics if no origins are given.
+func WebSocketOrigins(origins ...string) RouteOption {
+	if len(origins) == 0 {
+		panic("mux: no origins")
+	}
+	origins = append([]string(nil), origins...)
+	return withWebSocketConfig(func(c *websocketConfig) {
+		c.origins.AllowedOrigins = origins
+	})
+}
+
+// websocketProtocolKey is the context key for the subprotocol of a WebSocket
+// connection.
+type websocketProtocolKey struct{}
+
+// WebSocketProtocol returns the subprotocol chosen for the WebSocket
+// connection opened by r, or "" if there is none.
+func WebSocketProtocol(r *http.Request) string {
+	protocol, _ := r.Context().Value(websocketProtocolKey{}).(string)
+	return protocol
+}
@@ -0,0 +1,168 @@
+package mux_test
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/touchmarine/mux"
+)
+
+func TestWebSocket(t *testing.T) {
+	infos := make(chan mux.RequestInfo, 10)
+	m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found")), mux.OnRequest(func(info mux.RequestInfo) {
+		infos <- info
+	}))
+	m.UseCompression(mux.Compression{})
+	m.UseETags(false)
+	m.UseSecurityHeaders(mux.DefaultSecurityHeaders())
+	echo := func(conn net.Conn, rw *bufio.ReadWriter, r *http.Request) {
+		defer conn.Close()
+		line, err := rw.ReadString('\n')
+		if err != nil {
+			return
+		}
+		rw.WriteString(mux.WebSocketProtocol(r) + " " + mux.Param(r, "room") + " " + line)
+		rw.Flush()
+	}
+	m.WebSocket("/ws/{room}", echo,
+		mux.Subprotocols("chat.v2", "chat.v1"),
+		mux.WebSocketOrigins("https://*.example.com"),
+		mux.Cached(mux.Cache{TTL: time.Minute}))
+
+	s := httptest.NewServer(m)
+	defer s.Close()
+
+	cases := []struct {
+		name       string
+		header     string
+		statusCode int
+		protocol   string
+	}{
+		{
+			"upgrade",
+			"Sec-WebSocket-Protocol: chat.v1, chat.v2\r\n",
+			http.StatusSwitchingProtocols,
+			"chat.v2",
+		},
+		{
+			"no subprotocol",
+			"Sec-WebSocket-Protocol: chat.v0\r\n",
+			http.StatusSwitchingProtocols,
+			"",
+		},
+		{
+			"same origin",
+			"Origin: " + s.URL + "\r\n",
+			http.StatusSwitchingProtocols,
+			"",
+		},
+		{
+			"allowed origin",
+			"Origin: https://app.example.com\r\n",
+			http.StatusSwitchingProtocols,
+			"",
+		},
+		{
+			"other origin",
+			"Origin: https://example.org\r\n",
+			http.StatusForbidden,
+			"",
+		},
+		{
+			"version",
+			"Sec-WebSocket-Version: 8\r\n",
+			http.StatusUpgradeRequired,
+			"",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			conn, err := net.Dial("tcp", s.Listener.Addr().String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+			conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+			version := "Sec-WebSocket-Version: 13\r\n"
+			if strings.HasPrefix(c.header, "Sec-WebSocket-Version") {
+				version = ""
+			}
+			io.WriteString(conn, "GET /ws/lobby HTTP/1.1\r\n"+
+				"Host: "+s.Listener.Addr().String()+"\r\n"+
+				"Connection: keep-alive, Upgrade\r\n"+
+				"Upgrade: websocket\r\n"+
+				"Accept-Encoding: gzip\r\n"+
+				"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n"+
+				version+c.header+"\r\n")
+
+			br := bufio.NewReader(conn)
+			resp, err := http.ReadResponse(br, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != c.statusCode {
+				t.Fatalf("got StatusCode %d, want %d", resp.StatusCode, c.statusCode)
+			}
+			if c.statusCode != http.StatusSwitchingProtocols {
+				return
+			}
+
+			if got, want := resp.Header.Get("Sec-WebSocket-Accept"), "s3pPLMBiTxaQ9kYGzzhZRbK+xOo="; got != want {
+				t.Errorf("got Sec-WebSocket-Accept %q, want %q", got, want)
+			}
+			if got := resp.Header.Get("Sec-WebSocket-Protocol"); got != c.protocol {
+				t.Errorf("got Sec-WebSocket-Protocol %q, want %q", got, c.protocol)
+			}
+			if got := resp.Header.Get("X-Content-Type-Options"); got != "nosniff" {
+				t.Errorf("got X-Content-Type-Options %q, want nosniff", got)
+			}
+			io.WriteString(conn, "hello\n")
+			line, err := br.ReadString('\n')
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := c.protocol + " lobby hello\n"; line != want {
+				t.Errorf("got %q, want %q", line, want)
+			}
+		})
+	}
+
+	statuses := make(map[int]int)
+	for range cases {
+		select {
+		case info := <-infos:
+			statuses[info.Status]++
+		case <-time.After(5 * time.Second):
+			t.Fatal("got no RequestInfo, want one per request")
+		}
+	}
+	if got := statuses[http.StatusSwitchingProtocols]; got != 4 {
+		t.Errorf("got %d requests with Status %d, want 4", got, http.StatusSwitchingProtocols)
+	}
+}
+
+func TestWebSocketBadKey(t *testing.T) {
+	m := mux.New(mux.WithNotFound(handlerFactory(http.StatusNotFound, "not found")))
+	m.WebSocket("/ws", func(conn net.Conn, rw *bufio.ReadWriter, r *http.Request) {
+		t.Error("handler called")
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	r.Header.Set("Connection", "Upgrade")
+	r.Header.Set("Upgrade", "websocket")
+	r.Header.Set("Sec-WebSocket-Version", "13")
+	r.Header.Set("Sec-WebSocket-Key", "short")
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, r)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("got StatusCode %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}